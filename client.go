@@ -8,8 +8,13 @@ import (
 	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// defaultTaskPollInterval is used by WaitForTask when the server does not
+// suggest a poll interval for the task.
+const defaultTaskPollInterval = time.Second
+
 // Client implements the MCP client.
 type Client struct {
 	transport Interface
@@ -22,8 +27,28 @@ type Client struct {
 	serverCapabilities ServerCapabilities
 	protocolVersion    string
 	samplingHandler    SamplingHandler
+	samplingTools      bool
 	rootsHandler       RootsHandler
 	elicitationHandler ElicitationHandler
+	taskWaitersMu      sync.Mutex
+	taskWaiters        map[string][]chan struct{}
+	toolsMu            sync.RWMutex
+	tools              map[string]Tool // Tools seen in tools/list responses, by name
+
+	// Connection lifecycle and session state replayed on reconnection
+	connMu                sync.Mutex
+	ctx                   context.Context
+	cancel                context.CancelFunc
+	closed                bool
+	state                 ConnectionState
+	stateHandlers         []ConnectionStateHandler
+	connectionLostHandler func(error)
+	reconnectPolicy       *ReconnectPolicy
+	reconnectDone         chan struct{} // Non-nil while a reconnection is in progress
+	reconnectErr          error
+	initRequest           *InitializeRequest
+	logLevel              *SetLevelRequest
+	subscriptions         map[string]SubscribeRequest
 }
 
 type ClientOption func(*Client)
@@ -43,6 +68,15 @@ func WithSamplingHandler(handler SamplingHandler) ClientOption {
 	}
 }
 
+// WithSamplingTools declares that the sampling handler supports the tools
+// and toolChoice of sampling requests, and may answer with tool_use
+// content. It has no effect without WithSamplingHandler.
+func WithSamplingTools() ClientOption {
+	return func(c *Client) {
+		c.samplingTools = true
+	}
+}
+
 // WithRootsHandler sets the roots handler for the client.
 // WithRootsHandler returns a ClientOption that sets the client's RootsHandler.
 // When provided, the client will declare the roots capability (ListChanged) during initialization.
@@ -77,8 +111,10 @@ func WithInitializedSession() ClientOption {
 //	}
 func NewClient(transport Interface, options ...ClientOption) *Client {
 	client := &Client{
-		transport: transport,
+		transport:     transport,
+		subscriptions: make(map[string]SubscribeRequest),
 	}
+	client.ctx, client.cancel = context.WithCancel(context.Background())
 
 	for _, opt := range options {
 		opt(client)
@@ -100,7 +136,22 @@ func (c *Client) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Reconnections reopen the transport for as long as the start context lives
+	c.connMu.Lock()
+	c.cancel()
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	reconnect := c.reconnectPolicy != nil
+	c.connMu.Unlock()
+	if reconnect {
+		c.installConnectionLostHandler()
+	}
+
 	c.transport.SetNotificationHandler(func(notification JSONRPCNotification) {
+		c.wakeTaskWaiters(notification)
+		if notification.Method == MethodNotificationToolsListChanged {
+			c.clearToolCache()
+		}
+
 		c.notifyMu.RLock()
 		defer c.notifyMu.RUnlock()
 		for _, handler := range c.notifications {
@@ -118,6 +169,11 @@ func (c *Client) Start(ctx context.Context) error {
 
 // Close shuts down the client and closes the transport.
 func (c *Client) Close() error {
+	c.connMu.Lock()
+	c.closed = true
+	c.cancel()
+	c.connMu.Unlock()
+
 	return c.transport.Close()
 }
 
@@ -133,22 +189,42 @@ func (c *Client) OnNotification(
 
 // OnConnectionLost registers a handler function to be called when the connection is lost.
 // This is useful for handling HTTP2 idle timeout disconnections that should not be treated as errors.
+// It is also called before an automatic reconnection when WithReconnect is used.
 func (c *Client) OnConnectionLost(handler func(error)) {
-	type connectionLostSetter interface {
-		SetConnectionLostHandler(func(error))
-	}
-	if setter, ok := c.transport.(connectionLostSetter); ok {
-		setter.SetConnectionLostHandler(handler)
-	}
+	c.connMu.Lock()
+	c.connectionLostHandler = handler
+	c.connMu.Unlock()
+
+	c.installConnectionLostHandler()
 }
 
 // sendRequest sends a JSON-RPC request to the server and waits for a response.
 // Returns the raw JSON response message or an error if the request fails.
+// With WithReconnect, a request failing because the session was lost is
+// retried once the session has been re-established.
 func (c *Client) sendRequest(
 	ctx context.Context,
 	method string,
 	params any,
 	header http.Header,
+) (*json.RawMessage, error) {
+	response, err := c.doSendRequest(ctx, method, params, header)
+	if err == nil || method == "initialize" || !isSessionLost(err) || !c.canReconnect() {
+		return response, err
+	}
+
+	if reconnectErr := c.awaitReconnect(ctx, err); reconnectErr != nil {
+		return nil, fmt.Errorf("%w (%w)", err, reconnectErr)
+	}
+	return c.doSendRequest(ctx, method, params, header)
+}
+
+// doSendRequest sends a single JSON-RPC request without reconnecting.
+func (c *Client) doSendRequest(
+	ctx context.Context,
+	method string,
+	params any,
+	header http.Header,
 ) (*json.RawMessage, error) {
 	if !c.initialized && method != "initialize" {
 		return nil, fmt.Errorf("client not initialized")
@@ -172,6 +248,9 @@ func (c *Client) sendRequest(
 	}
 
 	if response.Error != nil {
+		if response.Error.Code == INSUFFICIENT_SCOPE {
+			return nil, newInsufficientScopeError(response.Error.Message, response.Error.Data)
+		}
 		return nil, &jsonRPCError{
 			code:    response.Error.Code,
 			message: response.Error.Message,
@@ -193,11 +272,32 @@ func (c *Client) sendRequest(
 func (c *Client) Initialize(
 	ctx context.Context,
 	request InitializeRequest,
+) (*InitializeResult, error) {
+	result, err := c.initialize(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember the request to re-initialize after a reconnection
+	c.connMu.Lock()
+	c.initRequest = &request
+	c.connMu.Unlock()
+	c.setConnectionState(ConnectionStateConnected, nil)
+
+	return result, nil
+}
+
+func (c *Client) initialize(
+	ctx context.Context,
+	request InitializeRequest,
 ) (*InitializeResult, error) {
 	// Merge client capabilities with sampling capability if handler is configured
 	capabilities := request.Params.Capabilities
 	if c.samplingHandler != nil {
-		capabilities.Sampling = &struct{}{}
+		capabilities.Sampling = &SamplingCapability{}
+		if c.samplingTools {
+			capabilities.Sampling.Tools = &struct{}{}
+		}
 	}
 	if c.rootsHandler != nil {
 		capabilities.Roots = &struct {
@@ -227,7 +327,7 @@ func (c *Client) Initialize(
 		params.ProtocolVersion = LATEST_PROTOCOL_VERSION
 	}
 
-	response, err := c.sendRequest(ctx, "initialize", params, request.Header)
+	response, err := c.doSendRequest(ctx, "initialize", params, request.Header)
 	if err != nil {
 		return nil, err
 	}
@@ -366,7 +466,14 @@ func (c *Client) Subscribe(
 	request SubscribeRequest,
 ) error {
 	_, err := c.sendRequest(ctx, "resources/subscribe", request.Params, request.Header)
-	return err
+	if err != nil {
+		return err
+	}
+
+	c.connMu.Lock()
+	c.subscriptions[request.Params.URI] = request
+	c.connMu.Unlock()
+	return nil
 }
 
 func (c *Client) Unsubscribe(
@@ -374,7 +481,14 @@ func (c *Client) Unsubscribe(
 	request UnsubscribeRequest,
 ) error {
 	_, err := c.sendRequest(ctx, "resources/unsubscribe", request.Params, request.Header)
-	return err
+	if err != nil {
+		return err
+	}
+
+	c.connMu.Lock()
+	delete(c.subscriptions, request.Params.URI)
+	c.connMu.Unlock()
+	return nil
 }
 
 func (c *Client) ListPromptsByPage(
@@ -433,6 +547,7 @@ func (c *Client) ListToolsByPage(
 	if err != nil {
 		return nil, err
 	}
+	c.cacheTools(result.Tools)
 	return result, nil
 }
 
@@ -473,12 +588,252 @@ func (c *Client) CallTool(
 	return ParseCallToolResult(response)
 }
 
+// CallToolAsTask calls a tool with task augmentation. The server returns as
+// soon as the task is created; use WaitForTask or TaskResult to obtain the
+// tool result. If request.Params.Task is nil, a task without TTL is requested.
+func (c *Client) CallToolAsTask(
+	ctx context.Context,
+	request CallToolRequest,
+) (*CreateTaskResult, error) {
+	if request.Params.Task == nil {
+		request.Params.Task = &TaskParams{}
+	}
+
+	response, err := c.sendRequest(ctx, "tools/call", request.Params, request.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CreateTaskResult
+	if err := json.Unmarshal(*response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Task.TaskId == "" {
+		return nil, fmt.Errorf("server did not create a task for tool '%s'", request.Params.Name)
+	}
+
+	return &result, nil
+}
+
+// GetTask retrieves the current state of a task.
+func (c *Client) GetTask(
+	ctx context.Context,
+	request GetTaskRequest,
+) (*GetTaskResult, error) {
+	response, err := c.sendRequest(ctx, string(MethodTasksGet), request.Params, request.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var result GetTaskResult
+	if err := json.Unmarshal(*response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListTasksByPage manually list tasks by page.
+func (c *Client) ListTasksByPage(
+	ctx context.Context,
+	request ListTasksRequest,
+) (*ListTasksResult, error) {
+	result, err := listByPage[ListTasksResult](ctx, c, request.PaginatedRequest, request.Header, string(MethodTasksList))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListTasks lists the tasks of the session, following cursors until all
+// pages have been retrieved.
+func (c *Client) ListTasks(
+	ctx context.Context,
+	request ListTasksRequest,
+) (*ListTasksResult, error) {
+	result, err := c.ListTasksByPage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for result.NextCursor != "" {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			request.Params.Cursor = result.NextCursor
+			newPageRes, err := c.ListTasksByPage(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			result.Tasks = append(result.Tasks, newPageRes.Tasks...)
+			result.NextCursor = newPageRes.NextCursor
+		}
+	}
+	return result, nil
+}
+
+// TaskResult retrieves the result of a task-augmented tool call.
+// The server blocks until the task reaches a terminal status. A failed task
+// is reported as an error.
+func (c *Client) TaskResult(
+	ctx context.Context,
+	request TaskResultRequest,
+) (*CallToolResult, error) {
+	response, err := c.sendRequest(ctx, string(MethodTasksResult), request.Params, request.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTaskResult(response)
+}
+
+// CancelTask cancels a running task and returns its final state.
+func (c *Client) CancelTask(
+	ctx context.Context,
+	request CancelTaskRequest,
+) (*CancelTaskResult, error) {
+	response, err := c.sendRequest(ctx, string(MethodTasksCancel), request.Params, request.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CancelTaskResult
+	if err := json.Unmarshal(*response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// WaitForTask polls a task until it reaches a terminal status and returns the
+// tool result. It waits the task's PollInterval between polls (one second if
+// the server does not suggest one) and polls early whenever a
+// notifications/tasks/status message for the task arrives.
+// A cancelled task returns an error wrapping ErrTaskCancelled.
+func (c *Client) WaitForTask(ctx context.Context, taskID string) (*CallToolResult, error) {
+	wake := c.watchTask(taskID)
+	defer c.unwatchTask(taskID, wake)
+
+	request := GetTaskRequest{Params: GetTaskParams{TaskId: taskID}}
+	for {
+		task, err := c.GetTask(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if task.Status == TaskStatusCancelled {
+			return nil, fmt.Errorf("task %s: %w", taskID, ErrTaskCancelled)
+		}
+		if task.Status.IsTerminal() {
+			break
+		}
+
+		interval := defaultTaskPollInterval
+		if task.PollInterval != nil && *task.PollInterval > 0 {
+			interval = time.Duration(*task.PollInterval) * time.Millisecond
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+
+	return c.TaskResult(ctx, TaskResultRequest{Params: TaskResultParams{TaskId: taskID}})
+}
+
+// watchTask registers a channel that is signalled whenever a status
+// notification for taskID is received.
+func (c *Client) watchTask(taskID string) chan struct{} {
+	wake := make(chan struct{}, 1)
+
+	c.taskWaitersMu.Lock()
+	defer c.taskWaitersMu.Unlock()
+	if c.taskWaiters == nil {
+		c.taskWaiters = make(map[string][]chan struct{})
+	}
+	c.taskWaiters[taskID] = append(c.taskWaiters[taskID], wake)
+	return wake
+}
+
+// unwatchTask removes a channel registered with watchTask.
+func (c *Client) unwatchTask(taskID string, wake chan struct{}) {
+	c.taskWaitersMu.Lock()
+	defer c.taskWaitersMu.Unlock()
+
+	waiters := slices.DeleteFunc(c.taskWaiters[taskID], func(ch chan struct{}) bool {
+		return ch == wake
+	})
+	if len(waiters) == 0 {
+		delete(c.taskWaiters, taskID)
+	} else {
+		c.taskWaiters[taskID] = waiters
+	}
+}
+
+// wakeTaskWaiters signals WaitForTask callers waiting on the task referenced
+// by a notifications/tasks/status message.
+func (c *Client) wakeTaskWaiters(notification JSONRPCNotification) {
+	if notification.Method != MethodNotificationTasksStatus {
+		return
+	}
+	taskID, _ := notification.Notification.Params.AdditionalFields["taskId"].(string)
+	if taskID == "" {
+		return
+	}
+
+	c.taskWaitersMu.Lock()
+	defer c.taskWaitersMu.Unlock()
+	for _, wake := range c.taskWaiters[taskID] {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// parseTaskResult parses a tasks/result response into a CallToolResult.
+// Unlike tools/call responses, task results may omit the content field.
+func parseTaskResult(rawMessage *json.RawMessage) (*CallToolResult, error) {
+	if rawMessage == nil {
+		return nil, fmt.Errorf("response is nil")
+	}
+
+	var jsonContent map[string]any
+	if err := json.Unmarshal(*rawMessage, &jsonContent); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if _, ok := jsonContent["content"]; ok {
+		return ParseCallToolResult(rawMessage)
+	}
+
+	jsonContent["content"] = []any{}
+	normalized, err := json.Marshal(jsonContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	raw := json.RawMessage(normalized)
+	return ParseCallToolResult(&raw)
+}
+
 func (c *Client) SetLevel(
 	ctx context.Context,
 	request SetLevelRequest,
 ) error {
 	_, err := c.sendRequest(ctx, "logging/setLevel", request.Params, request.Header)
-	return err
+	if err != nil {
+		return err
+	}
+
+	c.connMu.Lock()
+	c.logLevel = &request
+	c.connMu.Unlock()
+	return nil
 }
 
 func (c *Client) Complete(
@@ -558,14 +913,12 @@ func (c *Client) handleSamplingRequestTransport(ctx context.Context, request JSO
 	// Fix content parsing - HTTP transport unmarshals TextContent as map[string]any
 	// Use the helper function to properly handle content from different transports
 	for i := range params.Messages {
-		if contentMap, ok := params.Messages[i].Content.(map[string]any); ok {
-			// Parse the content map into a proper Content type
-			content, err := ParseContent(contentMap)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse content for message %d: %w", i, err)
-			}
-			params.Messages[i].Content = content
+		// Parse the content map, or array of them, into proper Content types
+		content, err := parseSamplingContent(params.Messages[i].Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse content for message %d: %w", i, err)
 		}
+		params.Messages[i].Content = content
 	}
 
 	// Create the MCP request
@@ -668,14 +1021,14 @@ func (c *Client) handleElicitationRequestTransport(ctx context.Context, request
 	}
 
 	// Create the transport response
-	response := NewJSONRPCResultResponse(request.ID, resultBytes)
+	response := NewJSONRPCResultResponse(request.ID, json.RawMessage(resultBytes))
 
 	return &response, nil
 }
 
 func (c *Client) handlePingRequestTransport(ctx context.Context, request JSONRPCRequest) (*JSONRPCResponse, error) {
 	b, _ := json.Marshal(&EmptyResult{})
-	response := NewJSONRPCResultResponse(request.ID, b)
+	response := NewJSONRPCResultResponse(request.ID, json.RawMessage(b))
 	return &response, nil
 }
 
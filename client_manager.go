@@ -0,0 +1,373 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Transport types of a ServerConfig
+const (
+	ServerTypeStdio = "stdio"
+	ServerTypeHTTP  = "http"
+	ServerTypeSSE   = "sse"
+)
+
+// ServerConfig describes one MCP server entry of an IDE-style mcp.json file.
+type ServerConfig struct {
+	Name     string
+	Type     string            // ServerTypeStdio, ServerTypeHTTP or ServerTypeSSE
+	Command  string            // Executable of a stdio server
+	Args     []string          // Arguments of a stdio server
+	Env      map[string]string // Extra environment of a stdio server
+	URL      string            // Endpoint of an http or sse server
+	Headers  map[string]string // HTTP headers sent to an http or sse server
+	Disabled bool
+}
+
+// serverConfigEntry is the union of the server entry shapes used by VS Code
+// ("servers"/"url"), Antigravity ("mcpServers"/"serverUrl") and Claude Code
+// ("mcpServers"/"url").
+type serverConfigEntry struct {
+	Type      string            `json:"type"`
+	Command   string            `json:"command"`
+	Args      []string          `json:"args"`
+	Env       map[string]string `json:"env"`
+	URL       string            `json:"url"`
+	ServerURL string            `json:"serverUrl"`
+	Headers   map[string]string `json:"headers"`
+	Disabled  bool              `json:"disabled"`
+}
+
+// LoadServerConfigs reads the MCP servers declared in an IDE-style config file.
+// See ParseServerConfigs for the supported formats.
+func LoadServerConfigs(path string) ([]ServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := ParseServerConfigs(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return configs, nil
+}
+
+// ParseServerConfigs parses the MCP servers declared in an IDE-style config
+// file. Servers are read from the "servers" (VS Code) or "mcpServers"
+// (Antigravity, Claude Code, Cursor...) object; comments and trailing commas
+// are allowed. Entries with a "command" are stdio servers, entries with a
+// "url" or "serverUrl" are Streamable HTTP servers unless their "type" is
+// "sse". ${VAR} and ${env:VAR} references are expanded from the environment.
+// Configs are returned sorted by name.
+func ParseServerConfigs(data []byte) ([]ServerConfig, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	entries := make(map[string]serverConfigEntry)
+	for _, key := range []string{"servers", "mcpServers"} {
+		section, ok := raw[key]
+		if !ok {
+			continue
+		}
+		var servers map[string]serverConfigEntry
+		if err := json.Unmarshal(section, &servers); err != nil {
+			return nil, fmt.Errorf("invalid %q: %w", key, err)
+		}
+		for name, entry := range servers {
+			entries[name] = entry
+		}
+	}
+
+	configs := make([]ServerConfig, 0, len(entries))
+	for name, entry := range entries {
+		config, err := entry.toServerConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs, nil
+}
+
+func (e serverConfigEntry) toServerConfig(name string) (ServerConfig, error) {
+	config := ServerConfig{
+		Name:     name,
+		Command:  expandConfigVars(e.Command),
+		URL:      expandConfigVars(e.URL),
+		Disabled: e.Disabled,
+	}
+	if config.URL == "" {
+		config.URL = expandConfigVars(e.ServerURL)
+	}
+	for _, arg := range e.Args {
+		config.Args = append(config.Args, expandConfigVars(arg))
+	}
+	if len(e.Env) > 0 {
+		config.Env = make(map[string]string, len(e.Env))
+		for k, v := range e.Env {
+			config.Env[k] = expandConfigVars(v)
+		}
+	}
+	if len(e.Headers) > 0 {
+		config.Headers = make(map[string]string, len(e.Headers))
+		for k, v := range e.Headers {
+			config.Headers[k] = expandConfigVars(v)
+		}
+	}
+
+	switch strings.ToLower(e.Type) {
+	case "stdio":
+		config.Type = ServerTypeStdio
+	case "sse":
+		config.Type = ServerTypeSSE
+	case "http", "streamable-http", "streamablehttp":
+		config.Type = ServerTypeHTTP
+	case "":
+		if config.Command != "" {
+			config.Type = ServerTypeStdio
+		} else {
+			config.Type = ServerTypeHTTP
+		}
+	default:
+		return config, fmt.Errorf("server %q: unsupported type %q", name, e.Type)
+	}
+
+	if config.Type == ServerTypeStdio && config.Command == "" {
+		return config, fmt.Errorf("server %q: missing command", name)
+	}
+	if config.Type != ServerTypeStdio && config.URL == "" {
+		return config, fmt.Errorf("server %q: missing url", name)
+	}
+	return config, nil
+}
+
+// expandConfigVars replaces ${VAR} and ${env:VAR} with environment values.
+func expandConfigVars(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return os.Expand(s, func(name string) string {
+		if strings.HasPrefix(name, "input:") {
+			// VS Code prompts for inputs; leave the reference untouched
+			return "${" + name + "}"
+		}
+		return os.Getenv(strings.TrimPrefix(name, "env:"))
+	})
+}
+
+// ManagedTool is a tool exposed by one of the servers of a ClientManager.
+type ManagedTool struct {
+	Server string
+	Tool   Tool
+}
+
+// ClientManagerOption configures a ClientManager.
+type ClientManagerOption func(*ClientManager)
+
+// WithManagerClientInfo sets the client info sent when initializing each server.
+func WithManagerClientInfo(info Implementation) ClientManagerOption {
+	return func(m *ClientManager) {
+		m.clientInfo = info
+	}
+}
+
+// WithManagerClientOptions sets options applied to the client of every server.
+func WithManagerClientOptions(options ...ClientOption) ClientManagerOption {
+	return func(m *ClientManager) {
+		m.clientOptions = append(m.clientOptions, options...)
+	}
+}
+
+// ClientManager runs one Client per MCP server declared in an IDE-style
+// config file and offers a single entry point to all of them.
+type ClientManager struct {
+	configs       []ServerConfig
+	clientInfo    Implementation
+	clientOptions []ClientOption
+
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewClientManager creates a manager for the given servers. Disabled servers
+// are ignored. Call Start to connect to them.
+func NewClientManager(configs []ServerConfig, options ...ClientManagerOption) *ClientManager {
+	m := &ClientManager{
+		clientInfo: Implementation{Name: "mcp-client-manager", Version: "1.0.0"},
+		clients:    make(map[string]*Client),
+	}
+	for _, config := range configs {
+		if !config.Disabled {
+			m.configs = append(m.configs, config)
+		}
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+// NewClientManagerFromFile creates a manager for the servers declared in an
+// IDE-style config file.
+func NewClientManagerFromFile(path string, options ...ClientManagerOption) (*ClientManager, error) {
+	configs, err := LoadServerConfigs(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientManager(configs, options...), nil
+}
+
+// Start connects to and initializes all servers concurrently. Servers that
+// fail are reported in the returned error, joined per server; the others are
+// available through Client.
+func (m *ClientManager) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(m.configs))
+
+	for i, config := range m.configs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client, err := m.startClient(ctx, config)
+			if err != nil {
+				errs[i] = fmt.Errorf("server %q: %w", config.Name, err)
+				return
+			}
+			m.mu.Lock()
+			m.clients[config.Name] = client
+			m.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// startClient creates the transport of a server, then starts and initializes its client.
+func (m *ClientManager) startClient(ctx context.Context, config ServerConfig) (*Client, error) {
+	var transport Interface
+	switch config.Type {
+	case ServerTypeStdio:
+		env := make([]string, 0, len(config.Env))
+		for k, v := range config.Env {
+			env = append(env, k+"="+v)
+		}
+		transport = NewStdio(config.Command, env, config.Args...)
+	case ServerTypeSSE:
+		sse, err := NewSSE(config.URL, WithSSEHeaders(config.Headers))
+		if err != nil {
+			return nil, err
+		}
+		transport = sse
+	default:
+		streamable, err := NewStreamableHTTP(config.URL, WithHTTPHeaders(config.Headers))
+		if err != nil {
+			return nil, err
+		}
+		transport = streamable
+	}
+
+	client := NewClient(transport, m.clientOptions...)
+	if err := client.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start: %w", err)
+	}
+
+	request := InitializeRequest{}
+	request.Params.ProtocolVersion = LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = m.clientInfo
+	if _, err := client.Initialize(ctx, request); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	return client, nil
+}
+
+// Client returns the client of the named server, if it was started successfully.
+func (m *ClientManager) Client(name string) (*Client, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	client, ok := m.clients[name]
+	return client, ok
+}
+
+// Names returns the names of the servers with a running client, sorted.
+func (m *ClientManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListTools lists the tools of all running servers concurrently. Tools are
+// grouped by server, in server name order. Servers that fail are reported in
+// the returned error alongside the tools of the others.
+func (m *ClientManager) ListTools(ctx context.Context) ([]ManagedTool, error) {
+	names := m.Names()
+	results := make([][]Tool, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		client, _ := m.Client(name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := client.ListTools(ctx, ListToolsRequest{})
+			if err != nil {
+				errs[i] = fmt.Errorf("server %q: %w", name, err)
+				return
+			}
+			results[i] = result.Tools
+		}()
+	}
+	wg.Wait()
+
+	var tools []ManagedTool
+	for i, name := range names {
+		for _, tool := range results[i] {
+			tools = append(tools, ManagedTool{Server: name, Tool: tool})
+		}
+	}
+	return tools, errors.Join(errs...)
+}
+
+// Close shuts down all clients concurrently and waits for them to finish.
+func (m *ClientManager) Close() error {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = make(map[string]*Client)
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var errs []error
+	for name, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Close(); err != nil {
+				errMu.Lock()
+				errs = append(errs, fmt.Errorf("server %q: %w", name, err))
+				errMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
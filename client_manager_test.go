@@ -0,0 +1,161 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func TestParseServerConfigs(t *testing.T) {
+	t.Setenv("MANAGER_TOKEN", "secret")
+
+	tests := []struct {
+		name     string
+		config   string
+		expected []mcp.ServerConfig
+	}{
+		{
+			name: "vscode",
+			config: `{
+				// VS Code keeps its servers under "servers"
+				"servers": {
+					"web": {"type": "http", "url": "http://localhost:3000/mcp", "headers": {"Authorization": "Bearer ${env:MANAGER_TOKEN}"}},
+					"files": {"type": "stdio", "command": "fs-server", "args": ["--root", "/tmp"], "env": {"TOKEN": "${MANAGER_TOKEN}"}},
+				},
+			}`,
+			expected: []mcp.ServerConfig{
+				{Name: "files", Type: mcp.ServerTypeStdio, Command: "fs-server", Args: []string{"--root", "/tmp"}, Env: map[string]string{"TOKEN": "secret"}},
+				{Name: "web", Type: mcp.ServerTypeHTTP, URL: "http://localhost:3000/mcp", Headers: map[string]string{"Authorization": "Bearer secret"}},
+			},
+		},
+		{
+			name:   "antigravity",
+			config: `{"mcpServers": {"app": {"serverUrl": "http://localhost:3000/mcp"}}}`,
+			expected: []mcp.ServerConfig{
+				{Name: "app", Type: mcp.ServerTypeHTTP, URL: "http://localhost:3000/mcp"},
+			},
+		},
+		{
+			name: "claude code",
+			config: `{"numStartups": 3, "mcpServers": {
+				"events": {"type": "sse", "url": "http://localhost:3000/sse"},
+				"off": {"command": "unused", "disabled": true}
+			}}`,
+			expected: []mcp.ServerConfig{
+				{Name: "events", Type: mcp.ServerTypeSSE, URL: "http://localhost:3000/sse"},
+				{Name: "off", Type: mcp.ServerTypeStdio, Command: "unused", Disabled: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := mcp.ParseServerConfigs([]byte(tt.config))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, configs)
+		})
+	}
+}
+
+func TestParseServerConfigs_Invalid(t *testing.T) {
+	for name, config := range map[string]string{
+		"unknown type":    `{"servers": {"a": {"type": "carrier-pigeon", "url": "x"}}}`,
+		"missing url":     `{"servers": {"a": {"type": "http"}}}`,
+		"missing command": `{"servers": {"a": {"type": "stdio"}}}`,
+		"malformed":       `{"servers": `,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := mcp.ParseServerConfigs([]byte(config))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// toolServer is a minimal stateless Streamable HTTP server exposing one tool.
+func toolServer(t *testing.T, tool string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var message map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, isRequest := message["id"]; !isRequest {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		var result any
+		switch message["method"] {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": tool, "version": "1.0.0"},
+			}
+		case "tools/list":
+			result = map[string]any{"tools": []any{
+				map[string]any{"name": tool, "inputSchema": map[string]any{"type": "object"}},
+			}}
+		default:
+			result = map[string]any{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": message["id"], "result": result})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientManager(t *testing.T) {
+	alpha := toolServer(t, "alpha_tool")
+	beta := toolServer(t, "beta_tool")
+
+	path := filepath.Join(t.TempDir(), "mcp.json")
+	config := `{
+		"mcpServers": {
+			"beta": {"serverUrl": "` + beta.URL + `"},
+			"alpha": {"type": "http", "url": "` + alpha.URL + `"},
+			"broken": {"url": "http://127.0.0.1:1/mcp"},
+			"skipped": {"url": "http://127.0.0.1:1/mcp", "disabled": true}
+		}
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+
+	manager, err := mcp.NewClientManagerFromFile(path)
+	require.NoError(t, err)
+
+	err = manager.Start(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server "broken"`)
+	assert.Equal(t, []string{"alpha", "beta"}, manager.Names())
+
+	_, ok := manager.Client("broken")
+	assert.False(t, ok)
+	client, ok := manager.Client("alpha")
+	require.True(t, ok)
+	require.NotNil(t, client)
+
+	tools, err := manager.ListTools(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+	assert.Equal(t, "alpha", tools[0].Server)
+	assert.Equal(t, "alpha_tool", tools[0].Tool.Name)
+	assert.Equal(t, "beta", tools[1].Server)
+	assert.Equal(t, "beta_tool", tools[1].Tool.Name)
+
+	require.NoError(t, manager.Close())
+	assert.Empty(t, manager.Names())
+}
@@ -0,0 +1,259 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// ConnectionState describes the connection between a Client and its server.
+type ConnectionState string
+
+const (
+	// ConnectionStateConnected means the session is initialized and usable.
+	ConnectionStateConnected ConnectionState = "connected"
+	// ConnectionStateReconnecting means the connection was lost and the
+	// client is trying to re-establish the session.
+	ConnectionStateReconnecting ConnectionState = "reconnecting"
+	// ConnectionStateFailed means reconnection was given up. The client must
+	// be rebuilt or re-initialized by the caller.
+	ConnectionStateFailed ConnectionState = "failed"
+)
+
+// ConnectionStateHandler is called when the connection state of a Client
+// changes. err is the cause of the transition, nil when connected.
+type ConnectionStateHandler func(state ConnectionState, err error)
+
+// ReconnectPolicy configures how a Client re-establishes a lost connection.
+//
+// The delay before attempt n is InitialBackoff*Multiplier^(n-1), capped at
+// MaxBackoff, then randomly spread by up to ±Jitter of its value.
+type ReconnectPolicy struct {
+	MaxAttempts    int           // Attempts before giving up; 0 retries forever
+	InitialBackoff time.Duration // Delay before the first attempt
+	MaxBackoff     time.Duration // Upper bound of the delay; 0 means no bound
+	Multiplier     float64       // Growth factor of the delay; values below 1 mean 1
+	Jitter         float64       // Random spread of the delay, between 0 and 1
+}
+
+// DefaultReconnectPolicy returns the policy used by WithReconnect when none
+// of its fields are set: 5 attempts, starting after 500ms and doubling up to
+// 30s, with 20% jitter.
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// backoff returns the delay before the given attempt, starting at 1.
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	multiplier := math.Max(p.Multiplier, 1)
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 {
+		delay = math.Min(delay, float64(p.MaxBackoff))
+	}
+	if jitter := math.Min(math.Max(p.Jitter, 0), 1); jitter > 0 {
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// WithReconnect makes the client re-establish its session when the
+// connection is lost or the server reports the session as terminated (HTTP
+// 404). The client reconnects the transport if it implements Reconnectable,
+// re-runs initialization with the original InitializeRequest, and restores
+// resource subscriptions and the logging level.
+//
+// A request failing because the session was terminated is retried once after
+// a successful reconnection.
+func WithReconnect(policy ReconnectPolicy) ClientOption {
+	return func(c *Client) {
+		if policy == (ReconnectPolicy{}) {
+			policy = DefaultReconnectPolicy()
+		}
+		c.reconnectPolicy = &policy
+	}
+}
+
+// OnConnectionStateChange registers a handler called on every connection
+// state transition. Multiple handlers can be registered.
+func (c *Client) OnConnectionStateChange(handler ConnectionStateHandler) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.stateHandlers = append(c.stateHandlers, handler)
+}
+
+// ConnectionState returns the current connection state, or an empty state
+// before the client has been initialized.
+func (c *Client) ConnectionState() ConnectionState {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.state
+}
+
+// setConnectionState records a state transition and notifies the handlers.
+func (c *Client) setConnectionState(state ConnectionState, err error) {
+	c.connMu.Lock()
+	if c.state == state {
+		c.connMu.Unlock()
+		return
+	}
+	c.state = state
+	handlers := append([]ConnectionStateHandler(nil), c.stateHandlers...)
+	c.connMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(state, err)
+	}
+}
+
+// installConnectionLostHandler routes connection loss reported by the
+// transport to the user handler and the reconnect logic.
+func (c *Client) installConnectionLostHandler() {
+	type connectionLostSetter interface {
+		SetConnectionLostHandler(func(error))
+	}
+	if setter, ok := c.transport.(connectionLostSetter); ok {
+		setter.SetConnectionLostHandler(c.handleConnectionLost)
+	}
+}
+
+func (c *Client) handleConnectionLost(err error) {
+	c.connMu.Lock()
+	handler := c.connectionLostHandler
+	c.connMu.Unlock()
+
+	if handler != nil {
+		handler(err)
+	}
+	if c.canReconnect() {
+		c.startReconnect(err)
+	}
+}
+
+// canReconnect reports whether a lost session may be re-established.
+func (c *Client) canReconnect() bool {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.reconnectPolicy != nil && !c.closed && c.initRequest != nil
+}
+
+// startReconnect begins re-establishing the session in the background unless
+// a reconnection is already in progress, and returns a channel closed once it
+// has finished.
+func (c *Client) startReconnect(cause error) <-chan struct{} {
+	c.connMu.Lock()
+	if c.reconnectDone != nil {
+		done := c.reconnectDone
+		c.connMu.Unlock()
+		return done
+	}
+	done := make(chan struct{})
+	c.reconnectDone = done
+	c.reconnectErr = nil
+	c.connMu.Unlock()
+
+	go func() {
+		err := c.runReconnect(cause)
+
+		c.connMu.Lock()
+		c.reconnectErr = err
+		c.reconnectDone = nil
+		c.connMu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// awaitReconnect re-establishes the session after cause and waits for the
+// outcome. Concurrent callers share a single reconnection.
+func (c *Client) awaitReconnect(ctx context.Context, cause error) error {
+	done := c.startReconnect(cause)
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.reconnectErr
+}
+
+// runReconnect retries re-establishing the session following the reconnect
+// policy until it succeeds, the attempts run out or the client is closed.
+func (c *Client) runReconnect(cause error) error {
+	c.setConnectionState(ConnectionStateReconnecting, cause)
+
+	c.connMu.Lock()
+	ctx := c.ctx
+	policy := *c.reconnectPolicy
+	c.connMu.Unlock()
+
+	lastErr := cause
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(policy.backoff(attempt)):
+		case <-ctx.Done():
+			err := fmt.Errorf("%w: %w", ErrReconnectFailed, ctx.Err())
+			c.setConnectionState(ConnectionStateFailed, err)
+			return err
+		}
+
+		if lastErr = c.reestablishSession(ctx); lastErr == nil {
+			c.setConnectionState(ConnectionStateConnected, nil)
+			return nil
+		}
+	}
+
+	err := fmt.Errorf("%w after %d attempts: %w", ErrReconnectFailed, policy.MaxAttempts, lastErr)
+	c.setConnectionState(ConnectionStateFailed, err)
+	return err
+}
+
+// reestablishSession reconnects the transport and replays the session setup:
+// initialization, logging level and resource subscriptions.
+func (c *Client) reestablishSession(ctx context.Context) error {
+	if reconnectable, ok := c.transport.(Reconnectable); ok {
+		if err := reconnectable.Reconnect(ctx); err != nil {
+			return fmt.Errorf("failed to reconnect transport: %w", err)
+		}
+	}
+
+	c.connMu.Lock()
+	request := *c.initRequest
+	level := c.logLevel
+	subscriptions := make([]SubscribeRequest, 0, len(c.subscriptions))
+	for _, subscription := range c.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	c.connMu.Unlock()
+
+	if _, err := c.initialize(ctx, request); err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	if level != nil {
+		if _, err := c.doSendRequest(ctx, "logging/setLevel", level.Params, level.Header); err != nil {
+			return fmt.Errorf("failed to restore logging level: %w", err)
+		}
+	}
+	for _, subscription := range subscriptions {
+		if _, err := c.doSendRequest(ctx, "resources/subscribe", subscription.Params, subscription.Header); err != nil {
+			return fmt.Errorf("failed to restore subscription to %s: %w", subscription.Params.URI, err)
+		}
+	}
+	return nil
+}
+
+// isSessionLost reports whether err means the server no longer knows the
+// session and it must be re-established.
+func isSessionLost(err error) bool {
+	return errors.Is(err, ErrSessionTerminated)
+}
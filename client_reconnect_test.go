@@ -0,0 +1,272 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// expiringSessionServer is a minimal Streamable HTTP server whose sessions
+// can be expired, after which requests carrying the old session ID get 404.
+type expiringSessionServer struct {
+	mu          sync.Mutex
+	sessions    int
+	current     string
+	refuseInits bool
+	methods     []string
+}
+
+func (s *expiringSessionServer) expire(refuseInits bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = ""
+	s.refuseInits = refuseInits
+}
+
+func (s *expiringSessionServer) calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+func (s *expiringSessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var message map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	method, _ := message["method"].(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result any = map[string]any{}
+	switch {
+	case method == "initialize":
+		if s.refuseInits {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		s.sessions++
+		s.current = fmt.Sprintf("session-%d", s.sessions)
+		w.Header().Set(mcp.HeaderKeySessionID, s.current)
+		result = map[string]any{
+			"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+			"capabilities":    map[string]any{},
+			"serverInfo":      map[string]any{"name": "expiring", "version": "1.0.0"},
+		}
+	case r.Header.Get(mcp.HeaderKeySessionID) != s.current || s.current == "":
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	s.methods = append(s.methods, method)
+
+	if _, isRequest := message["id"]; !isRequest {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": message["id"], "result": result})
+}
+
+func newReconnectingClient(t *testing.T, url string, policy mcp.ReconnectPolicy) (*mcp.Client, func() []mcp.ConnectionState) {
+	t.Helper()
+
+	trans, err := mcp.NewStreamableHTTP(url)
+	require.NoError(t, err)
+	client := mcp.NewClient(trans, mcp.WithReconnect(policy))
+
+	var mu sync.Mutex
+	var states []mcp.ConnectionState
+	client.OnConnectionStateChange(func(state mcp.ConnectionState, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, state)
+	})
+
+	require.NoError(t, client.Start(context.Background()))
+	_, err = client.Initialize(context.Background(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	return client, func() []mcp.ConnectionState {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]mcp.ConnectionState(nil), states...)
+	}
+}
+
+func TestClient_ReconnectsAfterSessionExpired(t *testing.T) {
+	backend := &expiringSessionServer{}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client, states := newReconnectingClient(t, server.URL, mcp.ReconnectPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	require.NoError(t, client.SetLevel(ctx, mcp.SetLevelRequest{
+		Params: mcp.SetLevelParams{Level: mcp.LoggingLevelDebug},
+	}))
+	subscribe := mcp.SubscribeRequest{}
+	subscribe.Params.URI = "file:///watched"
+	require.NoError(t, client.Subscribe(ctx, subscribe))
+
+	backend.expire(false)
+
+	// The failed ping triggers a reconnection and is then retried
+	require.NoError(t, client.Ping(ctx))
+
+	assert.Equal(t, []string{
+		"initialize", "notifications/initialized", "logging/setLevel", "resources/subscribe",
+		"initialize", "notifications/initialized", "logging/setLevel", "resources/subscribe",
+		"ping",
+	}, backend.calls())
+	assert.Equal(t, []mcp.ConnectionState{
+		mcp.ConnectionStateConnected,
+		mcp.ConnectionStateReconnecting,
+		mcp.ConnectionStateConnected,
+	}, states())
+	assert.Equal(t, "session-2", client.GetSessionId())
+}
+
+func TestClient_ReconnectGivesUpAfterMaxAttempts(t *testing.T) {
+	backend := &expiringSessionServer{}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	client, states := newReconnectingClient(t, server.URL, mcp.ReconnectPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	})
+	defer client.Close()
+
+	backend.expire(true)
+
+	err := client.Ping(context.Background())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, mcp.ErrSessionTerminated)
+	assert.ErrorIs(t, err, mcp.ErrReconnectFailed)
+	assert.Equal(t, mcp.ConnectionStateFailed, client.ConnectionState())
+	assert.Equal(t, []mcp.ConnectionState{
+		mcp.ConnectionStateConnected,
+		mcp.ConnectionStateReconnecting,
+		mcp.ConnectionStateFailed,
+	}, states())
+}
+
+func TestReconnectPolicy_Default(t *testing.T) {
+	policy := mcp.DefaultReconnectPolicy()
+	assert.Equal(t, 5, policy.MaxAttempts)
+	assert.Greater(t, policy.Multiplier, 1.0)
+}
+
+func TestSSE_ReconnectWhileSending(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: endpoint\ndata: /message\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	trans, err := mcp.NewSSE(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, trans.Start(context.Background()))
+
+	var senders sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_ = trans.SendNotification(context.Background(), mcp.JSONRPCNotification{
+					JSONRPC:      mcp.JSONRPC_VERSION,
+					Notification: mcp.Notification{Method: "notifications/ping"},
+				})
+				_ = trans.GetEndpoint()
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, trans.Reconnect(context.Background()))
+		assert.NotNil(t, trans.GetEndpoint())
+	}
+	close(stop)
+	senders.Wait()
+	require.NoError(t, trans.Close())
+}
+
+func TestSSE_ReconnectDoesNotReportConnectionLost(t *testing.T) {
+	drop := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: endpoint\ndata: /message\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-drop:
+		}
+	}))
+	defer server.Close()
+
+	trans, err := mcp.NewSSE(server.URL)
+	require.NoError(t, err)
+	var mu sync.Mutex
+	var lost []error
+	trans.SetConnectionLostHandler(func(err error) {
+		mu.Lock()
+		lost = append(lost, err)
+		mu.Unlock()
+	})
+	lostCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(lost)
+	}
+	require.NoError(t, trans.Start(context.Background()))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, trans.Reconnect(context.Background()))
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, lostCount())
+
+	// A stream ended by the server is still reported
+	close(drop)
+	require.Eventually(t, func() bool { return lostCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, trans.Close())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, lostCount())
+}
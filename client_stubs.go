@@ -0,0 +1,372 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// StubConfig configures GenerateClientStubs.
+type StubConfig struct {
+	// PackageName is the package of the generated file, "mcpclient" if empty
+	PackageName string
+	// TypeName is the name of the generated client wrapper, "Client" if empty
+	TypeName string
+	// ServerName is mentioned in the doc comment of the wrapper (optional)
+	ServerName string
+	// Generator is named in the "Code generated" header, "mcp-stubgen" if empty
+	Generator string
+}
+
+// GenerateClientStubs generates a Go file with a typed client for tools, as
+// listed by a server. Each tool gets an args struct built from its
+// InputSchema, a result struct built from its OutputSchema if it has one,
+// and a method on a wrapper around *Client. Tools with an output schema are
+// called with CallToolTyped; the others return the *CallToolResult.
+//
+// Schemas are mapped to Go types as far as they can be: objects with
+// properties become structs, other objects maps, and schemas the generator
+// cannot express (such as anyOf) become any.
+func GenerateClientStubs(tools []Tool, config StubConfig) ([]byte, error) {
+	if config.PackageName == "" {
+		config.PackageName = "mcpclient"
+	}
+	if config.TypeName == "" {
+		config.TypeName = "Client"
+	}
+	if config.Generator == "" {
+		config.Generator = "mcp-stubgen"
+	}
+
+	sorted := make([]Tool, len(tools))
+	copy(sorted, tools)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	g := &stubGenerator{
+		usedTypes: map[string]bool{config.TypeName: true, "New" + config.TypeName: true},
+	}
+
+	var methods bytes.Buffer
+	usedMethods := make(map[string]bool)
+	for _, tool := range sorted {
+		inputSchema, err := toolSchemaMap(tool.RawInputSchema, tool.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input schema of tool '%s': %w", tool.Name, err)
+		}
+		g.useDefs(inputSchema)
+		// Type names derive from the method name, so that tools whose names
+		// map to the same identifier get matching methods and types
+		method := uniqueName(stubIdentifier(tool.Name), usedMethods)
+		argsType := g.objectType(method+"Args", fmt.Sprintf("the arguments of the %s tool", tool.Name), inputSchema)
+
+		resultType := ""
+		if tool.RawOutputSchema != nil || tool.OutputSchema.Type != "" {
+			outputSchema, err := toolSchemaMap(tool.RawOutputSchema, tool.OutputSchema)
+			if err != nil {
+				return nil, fmt.Errorf("invalid output schema of tool '%s': %w", tool.Name, err)
+			}
+			g.useDefs(outputSchema)
+			resultType = g.objectType(method+"Result", fmt.Sprintf("the result of the %s tool", tool.Name), outputSchema)
+		}
+
+		fmt.Fprintf(&methods, "\n// %s calls the %s tool.\n", method, tool.Name)
+		writeDocLines(&methods, "", tool.Description, true)
+		if resultType != "" {
+			fmt.Fprintf(&methods, "func (c *%s) %s(ctx context.Context, args %s, options ...mcp.TypedCallOption) (%s, error) {\n", config.TypeName, method, argsType, resultType)
+			fmt.Fprintf(&methods, "\treturn mcp.CallToolTyped[%s, %s](ctx, c.client, %s, args, options...)\n}\n", argsType, resultType, strconv.Quote(tool.Name))
+		} else {
+			fmt.Fprintf(&methods, "func (c *%s) %s(ctx context.Context, args %s) (*mcp.CallToolResult, error) {\n", config.TypeName, method, argsType)
+			fmt.Fprintf(&methods, "\trequest := mcp.CallToolRequest{}\n\trequest.Params.Name = %s\n\trequest.Params.Arguments = args\n", strconv.Quote(tool.Name))
+			methods.WriteString("\treturn c.client.CallTool(ctx, request)\n}\n")
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by %s. DO NOT EDIT.\n\n", config.Generator)
+	fmt.Fprintf(&out, "package %s\n\n", config.PackageName)
+	if len(sorted) > 0 {
+		out.WriteString("import (\n\t\"context\"\n\n\t\"github.com/tinywasm/mcp\"\n)\n\n")
+	} else {
+		out.WriteString("import \"github.com/tinywasm/mcp\"\n\n")
+	}
+	if config.ServerName != "" {
+		fmt.Fprintf(&out, "// %s calls the tools of the %s server with typed arguments and results.\n", config.TypeName, config.ServerName)
+	} else {
+		fmt.Fprintf(&out, "// %s calls the tools of an MCP server with typed arguments and results.\n", config.TypeName)
+	}
+	fmt.Fprintf(&out, "type %s struct {\n\tclient *mcp.Client\n}\n\n", config.TypeName)
+	fmt.Fprintf(&out, "// New%s wraps an initialized client.\n", config.TypeName)
+	fmt.Fprintf(&out, "func New%[1]s(client *mcp.Client) *%[1]s {\n\treturn &%[1]s{client: client}\n}\n", config.TypeName)
+	out.Write(methods.Bytes())
+	out.Write(g.types.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// stubGenerator accumulates the type declarations of a generated file.
+type stubGenerator struct {
+	types     bytes.Buffer
+	usedTypes map[string]bool
+	// defs are the $defs of the schema being converted
+	defs map[string]any
+	// refTypes maps the $ref values of the current schema to their types
+	refTypes map[string]string
+}
+
+// goType returns the Go type for schema, declaring named types for nested
+// objects as needed. name is the type name to use for such an object, and
+// doc says what it holds.
+func (g *stubGenerator) goType(name, doc string, schema map[string]any) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return g.refType(ref)
+	}
+
+	types := schemaTypes(schema["type"])
+	if _, ok := schema["properties"].(map[string]any); ok && len(types) == 0 {
+		types = []string{"object"}
+	}
+	nullable := false
+	nonNull := types[:0:0]
+	for _, t := range types {
+		if t == "null" {
+			nullable = true
+		} else {
+			nonNull = append(nonNull, t)
+		}
+	}
+	if len(nonNull) != 1 {
+		return "any"
+	}
+
+	var goType string
+	switch nonNull[0] {
+	case "string":
+		goType = "string"
+	case "integer":
+		goType = "int64"
+	case "number":
+		goType = "float64"
+	case "boolean":
+		goType = "bool"
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			return "[]any"
+		}
+		return "[]" + g.goType(name+"Item", "an item of "+doc, items)
+	case "object":
+		if properties, _ := schema["properties"].(map[string]any); len(properties) > 0 {
+			goType = "*" + g.objectType(name, doc, schema)
+		} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+			return "map[string]" + g.goType(name+"Value", "a value of "+doc, additional)
+		} else {
+			return "map[string]any"
+		}
+	default:
+		return "any"
+	}
+	if nullable && !strings.HasPrefix(goType, "*") {
+		goType = "*" + goType
+	}
+	return goType
+}
+
+// refType returns the type of a local "#/$defs/Name" reference.
+func (g *stubGenerator) refType(ref string) string {
+	if goType, ok := g.refTypes[ref]; ok {
+		return goType
+	}
+	var defName string
+	for _, prefix := range []string{"#/$defs/", "#/definitions/"} {
+		if strings.HasPrefix(ref, prefix) {
+			defName = strings.TrimPrefix(ref, prefix)
+		}
+	}
+	def, ok := g.defs[defName].(map[string]any)
+	if !ok {
+		return "any"
+	}
+	// Reserve the name first, so that recursive definitions terminate
+	name := uniqueName(stubIdentifier(defName), g.usedTypes)
+	g.refTypes[ref] = "*" + name
+	goType := g.declareType(name, fmt.Sprintf("the %s schema definition", defName), def)
+	if goType != name {
+		// Not an object: use the type directly
+		g.refTypes[ref] = goType
+	}
+	return g.refTypes[ref]
+}
+
+// objectType declares a struct for an object schema and returns its name.
+func (g *stubGenerator) objectType(name, doc string, schema map[string]any) string {
+	return g.declareType(uniqueName(name, g.usedTypes), doc, schema)
+}
+
+// declareType declares the struct name for an object schema. For other
+// schemas it declares nothing and returns their Go type.
+func (g *stubGenerator) declareType(name, doc string, schema map[string]any) string {
+	properties, _ := schema["properties"].(map[string]any)
+	if schemaTypes(schema["type"]) == nil && properties == nil {
+		return g.goType(name, doc, schema)
+	}
+	if t := schemaTypes(schema["type"]); len(t) > 0 && t[0] != "object" {
+		return g.goType(name, doc, schema)
+	}
+
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, r := range list {
+			if r, ok := r.(string); ok {
+				required[r] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(properties))
+	for propertyName := range properties {
+		names = append(names, propertyName)
+	}
+	sort.Strings(names)
+
+	// Declare nested types before writing this one
+	var body bytes.Buffer
+	usedFields := make(map[string]bool)
+	for _, propertyName := range names {
+		property, _ := properties[propertyName].(map[string]any)
+		field := uniqueName(stubIdentifier(propertyName), usedFields)
+		fieldType := g.goType(name+field, fmt.Sprintf("the %s property of %s", propertyName, name), property)
+		tag := propertyName
+		if !required[propertyName] {
+			tag += ",omitempty"
+			if fieldType == "bool" || fieldType == "int64" || fieldType == "float64" {
+				// Keep explicit zero values apart from omitted ones
+				fieldType = "*" + fieldType
+			}
+		}
+		if description, ok := property["description"].(string); ok {
+			writeDocLines(&body, "\t", description, false)
+		}
+		fmt.Fprintf(&body, "\t%s %s `json:%s`\n", field, fieldType, strconv.Quote(tag))
+	}
+
+	g.types.WriteString("\n")
+	fmt.Fprintf(&g.types, "// %s holds %s.\n", name, doc)
+	if description, ok := schema["description"].(string); ok {
+		writeDocLines(&g.types, "", description, true)
+	}
+	fmt.Fprintf(&g.types, "type %s struct {\n", name)
+	g.types.Write(body.Bytes())
+	g.types.WriteString("}\n")
+	return name
+}
+
+// toolSchemaMap returns the JSON form of a tool schema, preferring the raw
+// schema if set.
+func toolSchemaMap(raw []byte, schema any) (map[string]any, error) {
+	if raw != nil {
+		return schemaToMap(json.RawMessage(raw))
+	}
+	return schemaToMap(schema)
+}
+
+// useDefs makes references resolve against the $defs of schema, the root
+// of the schema being converted.
+func (g *stubGenerator) useDefs(schema map[string]any) {
+	g.defs, _ = schema["$defs"].(map[string]any)
+	if g.defs == nil {
+		g.defs, _ = schema["definitions"].(map[string]any)
+	}
+	g.refTypes = make(map[string]string)
+}
+
+// writeDocLines writes text as comment lines. If separate is set, an empty
+// comment line separates them from the lines before.
+func writeDocLines(buf *bytes.Buffer, indent, text string, separate bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if separate {
+		fmt.Fprintf(buf, "%s//\n", indent)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			fmt.Fprintf(buf, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+// stubInitialisms are written in upper case in generated identifiers.
+var stubInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// stubIdentifier turns a tool or property name such as "get_weather",
+// "list-files" or "userId" into an exported Go identifier.
+func stubIdentifier(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(word) > 0 &&
+			(unicode.IsLower(word[len(word)-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			// camelCase boundary, or the end of an acronym as in "HTTPServer"
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); stubInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	id := b.String()
+	if id == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+// uniqueName returns name, or name with a number appended if it is taken,
+// and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,134 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+type stubAddress struct {
+	Street string `json:"street" jsonschema:"required"`
+}
+
+type stubUserArgs struct {
+	UserID  string            `json:"user_id" jsonschema:"required,description=ID of the user"`
+	Verbose bool              `json:"verbose,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Home    stubAddress       `json:"home"`
+}
+
+func TestGenerateClientStubs(t *testing.T) {
+	tools := []mcp.Tool{
+		mcp.NewTool("get-user",
+			mcp.WithDescription("Gets a user.\n\nUsers are cached."),
+			mcp.WithInputSchema[stubUserArgs](),
+			mcp.WithOutputSchema[stubAddress](),
+		),
+		mcp.NewTool("echo", mcp.WithString("message", mcp.Required()), mcp.WithNumber("times")),
+		mcp.NewToolWithRawSchema("walk", "", json.RawMessage(`{
+			"type": "object",
+			"$defs": {"Node": {"type": "object", "properties": {
+				"next": {"$ref": "#/$defs/Node"},
+				"value": {"type": ["string", "null"]}
+			}}},
+			"properties": {
+				"root": {"$ref": "#/$defs/Node"},
+				"choice": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+			}
+		}`)),
+	}
+
+	code, err := mcp.GenerateClientStubs(tools, mcp.StubConfig{PackageName: "users", TypeName: "UsersClient", ServerName: "users"})
+	require.NoError(t, err)
+	file, err := parser.ParseFile(token.NewFileSet(), "users.go", code, parser.ParseComments)
+	require.NoError(t, err)
+
+	// Every declaration is documented
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					assert.NotNil(t, decl.Doc, "type "+spec.Name.Name+" has no doc comment")
+				}
+			}
+		case *ast.FuncDecl:
+			assert.NotNil(t, decl.Doc, "func "+decl.Name.Name+" has no doc comment")
+		}
+	}
+
+	src := string(code)
+	for _, want := range []string{
+		"// Code generated by mcp-stubgen. DO NOT EDIT.",
+		"package users",
+		"func NewUsersClient(client *mcp.Client) *UsersClient {",
+
+		// Tools with an output schema decode their result
+		"// GetUser calls the get-user tool.\n//\n// Gets a user.\n//\n// Users are cached.\n",
+		"func (c *UsersClient) GetUser(ctx context.Context, args GetUserArgs, options ...mcp.TypedCallOption) (GetUserResult, error) {",
+		`mcp.CallToolTyped[GetUserArgs, GetUserResult](ctx, c.client, "get-user", args, options...)`,
+		"type GetUserResult struct {\n\tStreet string `json:\"street\"`\n}",
+
+		// Required properties have no omitempty; optional scalars are pointers
+		"\t// ID of the user\n\tUserID string `json:\"user_id\"`",
+		"Verbose *bool `json:\"verbose,omitempty\"`",
+		"Tags []string `json:\"tags,omitempty\"`",
+		"Labels map[string]string `json:\"labels,omitempty\"`",
+		"Home *GetUserArgsHome `json:\"home\"`",
+		"// GetUserArgsHome holds the home property of GetUserArgs.\ntype GetUserArgsHome struct {",
+
+		// Tools without an output schema return the raw result
+		"func (c *UsersClient) Echo(ctx context.Context, args EchoArgs) (*mcp.CallToolResult, error) {",
+		"Message string `json:\"message\"`",
+		"Times *float64 `json:\"times,omitempty\"`",
+
+		// References, nullable types and unsupported schemas
+		"Root *Node `json:\"root,omitempty\"`",
+		"Next *Node `json:\"next,omitempty\"`",
+		"Value *string `json:\"value,omitempty\"`",
+		"Choice any `json:\"choice,omitempty\"`",
+		"// Node holds the Node schema definition.\ntype Node struct {",
+	} {
+		assert.Contains(t, normalizeSpaces(src), normalizeSpaces(want))
+	}
+}
+
+func TestGenerateClientStubs_Names(t *testing.T) {
+	tools := []mcp.Tool{
+		mcp.NewTool("list_HTTPServers", mcp.WithString("serverURL"), mcp.WithString("server_url")),
+		mcp.NewTool("list-http-servers"),
+		mcp.NewTool("3d.render"),
+	}
+
+	code, err := mcp.GenerateClientStubs(tools, mcp.StubConfig{})
+	require.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "client.go", code, 0)
+	require.NoError(t, err)
+
+	src := normalizeSpaces(string(code))
+	assert.Contains(t, src, "package mcpclient")
+	assert.Contains(t, src, "func (c *Client) X3dRender(ctx context.Context, args X3dRenderArgs)")
+	assert.Contains(t, src, "func (c *Client) ListHTTPServers(ctx context.Context, args ListHTTPServersArgs)")
+	assert.Contains(t, src, "func (c *Client) ListHTTPServers2(ctx context.Context, args ListHTTPServers2Args)")
+	assert.Contains(t, src, "ServerURL string `json:\"serverURL,omitempty\"`")
+	assert.Contains(t, src, "ServerURL2 string `json:\"server_url,omitempty\"`")
+}
+
+// normalizeSpaces collapses the alignment gofmt adds to struct fields.
+func normalizeSpaces(s string) string {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' && len(out) > 0 && out[len(out)-1] == ' ' {
+			continue
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
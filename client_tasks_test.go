@@ -0,0 +1,102 @@
+package mcp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func newTaskTestClient(t *testing.T, handler mcp.ToolHandlerFunc) *mcp.Client {
+	t.Helper()
+
+	srv := mcp.NewMCPServer("task-server", "1.0.0",
+		mcp.WithTaskCapabilities(true, true, true),
+	)
+	srv.AddTool(mcp.NewTool("slow",
+		mcp.WithTaskSupport(mcp.TaskSupportOptional),
+	), handler)
+
+	client, err := mcp.NewInProcessClient(srv)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	_, err = client.Initialize(ctx, mcp.InitializeRequest{})
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient_CallToolAsTaskAndWait(t *testing.T) {
+	client := newTaskTestClient(t, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		time.Sleep(50 * time.Millisecond)
+		return mcp.NewToolResultText("done"), nil
+	})
+	ctx := context.Background()
+
+	created, err := client.CallToolAsTask(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "slow"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.Task.TaskId)
+	assert.Equal(t, mcp.TaskStatusWorking, created.Task.Status)
+
+	result, err := client.WaitForTask(ctx, created.Task.TaskId)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "done", result.Content[0].(mcp.TextContent).Text)
+
+	task, err := client.GetTask(ctx, mcp.GetTaskRequest{
+		Params: mcp.GetTaskParams{TaskId: created.Task.TaskId},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.TaskStatusCompleted, task.Status)
+
+	tasks, err := client.ListTasks(ctx, mcp.ListTasksRequest{})
+	require.NoError(t, err)
+	require.Len(t, tasks.Tasks, 1)
+	assert.Equal(t, created.Task.TaskId, tasks.Tasks[0].TaskId)
+}
+
+func TestClient_CancelTask(t *testing.T) {
+	client := newTaskTestClient(t, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	ctx := context.Background()
+
+	created, err := client.CallToolAsTask(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "slow"},
+	})
+	require.NoError(t, err)
+
+	cancelled, err := client.CancelTask(ctx, mcp.CancelTaskRequest{
+		Params: mcp.CancelTaskParams{TaskId: created.Task.TaskId},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.TaskStatusCancelled, cancelled.Status)
+
+	_, err = client.WaitForTask(ctx, created.Task.TaskId)
+	assert.ErrorIs(t, err, mcp.ErrTaskCancelled)
+}
+
+func TestClient_WaitForTaskHonorsContext(t *testing.T) {
+	client := newTaskTestClient(t, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	created, err := client.CallToolAsTask(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Name: "slow"},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForTask(ctx, created.Task.TaskId)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
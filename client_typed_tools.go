@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedCallOption configures CallToolTyped.
+type TypedCallOption func(*typedCallOptions)
+
+type typedCallOptions struct {
+	validateArguments bool
+}
+
+// WithArgumentValidation makes CallToolTyped check the arguments against
+// the tool's InputSchema before sending them. The schema comes from the
+// tools cached by ListTools, which is called if the tool is not cached yet.
+// Tools the server does not list are called without validation.
+func WithArgumentValidation() TypedCallOption {
+	return func(o *typedCallOptions) {
+		o.validateArguments = true
+	}
+}
+
+// CallToolTyped calls the named tool with args, usually a struct, and
+// decodes its result into TResult.
+//
+// The result is decoded from the StructuredContent of the tool result or,
+// for tools without an output schema, from JSON in its first TextContent.
+// Results with IsError set are returned as a *ToolError.
+func CallToolTyped[TArgs any, TResult any](
+	ctx context.Context,
+	client *Client,
+	name string,
+	args TArgs,
+	options ...TypedCallOption,
+) (TResult, error) {
+	var zero TResult
+	var opts typedCallOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	arguments, err := json.Marshal(args)
+	if err != nil {
+		return zero, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	if opts.validateArguments {
+		if err := client.validateToolArguments(ctx, name, arguments); err != nil {
+			return zero, err
+		}
+	}
+
+	request := CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = json.RawMessage(arguments)
+	result, err := client.CallTool(ctx, request)
+	if err != nil {
+		return zero, err
+	}
+	if result.IsError {
+		return zero, &ToolError{Name: name, Content: result.Content}
+	}
+
+	var data []byte
+	if result.StructuredContent != nil {
+		if data, err = json.Marshal(result.StructuredContent); err != nil {
+			return zero, fmt.Errorf("failed to marshal structured content: %w", err)
+		}
+	} else {
+		for _, content := range result.Content {
+			if text, ok := content.(TextContent); ok {
+				data = []byte(text.Text)
+				break
+			}
+		}
+		if data == nil {
+			return zero, fmt.Errorf("tool '%s' returned no structured or text content", name)
+		}
+	}
+
+	var typed TResult
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return zero, fmt.Errorf("failed to decode result of tool '%s': %w", name, err)
+	}
+	return typed, nil
+}
+
+// validateToolArguments checks arguments against the InputSchema of the
+// named tool.
+func (c *Client) validateToolArguments(ctx context.Context, name string, arguments []byte) error {
+	tool, ok := c.CachedTool(name)
+	if !ok {
+		if _, err := c.ListTools(ctx, ListToolsRequest{}); err != nil {
+			return fmt.Errorf("failed to list tools: %w", err)
+		}
+		if tool, ok = c.CachedTool(name); !ok {
+			return nil
+		}
+	}
+
+	schema, err := schemaToMap(tool.InputSchema)
+	if err != nil {
+		return fmt.Errorf("invalid input schema of tool '%s': %w", name, err)
+	}
+	var value any
+	if err := json.Unmarshal(arguments, &value); err != nil {
+		return fmt.Errorf("failed to decode arguments: %w", err)
+	}
+	if err := validateJSONSchema("arguments", value, schema); err != nil {
+		return fmt.Errorf("%w for tool '%s': %w", ErrInvalidToolArguments, name, err)
+	}
+	return nil
+}
+
+// CachedTool returns the definition of the named tool from the last
+// tools/list responses. The cache is cleared when the server notifies that
+// its tools changed.
+func (c *Client) CachedTool(name string) (Tool, bool) {
+	c.toolsMu.RLock()
+	defer c.toolsMu.RUnlock()
+	tool, ok := c.tools[name]
+	return tool, ok
+}
+
+func (c *Client) cacheTools(tools []Tool) {
+	c.toolsMu.Lock()
+	defer c.toolsMu.Unlock()
+	if c.tools == nil {
+		c.tools = make(map[string]Tool, len(tools))
+	}
+	for _, tool := range tools {
+		c.tools[tool.Name] = tool
+	}
+}
+
+func (c *Client) clearToolCache() {
+	c.toolsMu.Lock()
+	defer c.toolsMu.Unlock()
+	c.tools = nil
+}
@@ -0,0 +1,131 @@
+package mcp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+type weatherArgs struct {
+	City  string `json:"city" jsonschema:"required"`
+	Units string `json:"units,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+}
+
+type weatherReport struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+}
+
+func newTypedToolsClient(t *testing.T) *mcp.Client {
+	t.Helper()
+	server := mcp.NewMCPServer("typed-server", "1.0.0", mcp.WithToolCapabilities(true))
+	server.AddTool(
+		mcp.NewTool("weather", mcp.WithInputSchema[weatherArgs](), mcp.WithOutputSchema[weatherReport]()),
+		mcp.NewStructuredToolHandler(func(ctx context.Context, request mcp.CallToolRequest, args weatherArgs) (weatherReport, error) {
+			if args.City == "Atlantis" {
+				return weatherReport{}, errors.New("city not found")
+			}
+			return weatherReport{City: args.City, Temperature: 21.5}, nil
+		}),
+	)
+	// A tool without output schema returning JSON as text
+	server.AddTool(
+		mcp.NewTool("legacy_weather", mcp.WithString("city", mcp.Required())),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(fmt.Sprintf(`{"city": %q, "temperature": 18}`, request.GetString("city", ""))), nil
+		},
+	)
+
+	client, err := mcp.NewInProcessClient(server)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+	return client
+}
+
+func TestCallToolTyped_StructuredContent(t *testing.T) {
+	client := newTypedToolsClient(t)
+
+	report, err := mcp.CallToolTyped[weatherArgs, weatherReport](context.Background(), client, "weather", weatherArgs{City: "Lisbon"})
+	require.NoError(t, err)
+	assert.Equal(t, weatherReport{City: "Lisbon", Temperature: 21.5}, report)
+}
+
+func TestCallToolTyped_TextFallback(t *testing.T) {
+	client := newTypedToolsClient(t)
+
+	report, err := mcp.CallToolTyped[map[string]string, weatherReport](context.Background(), client, "legacy_weather", map[string]string{"city": "Porto"})
+	require.NoError(t, err)
+	assert.Equal(t, weatherReport{City: "Porto", Temperature: 18}, report)
+}
+
+func TestCallToolTyped_ToolError(t *testing.T) {
+	client := newTypedToolsClient(t)
+
+	_, err := mcp.CallToolTyped[weatherArgs, weatherReport](context.Background(), client, "weather", weatherArgs{City: "Atlantis"})
+	var toolErr *mcp.ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.ErrorIs(t, err, mcp.ErrToolCallFailed)
+	assert.Equal(t, "weather", toolErr.Name)
+	require.Len(t, toolErr.Content, 1)
+	assert.Contains(t, err.Error(), "city not found")
+}
+
+func TestCallToolTyped_ArgumentValidation(t *testing.T) {
+	client := newTypedToolsClient(t)
+	ctx := context.Background()
+
+	// The schema is fetched with tools/list on first use
+	_, ok := client.CachedTool("weather")
+	assert.False(t, ok)
+	_, err := mcp.CallToolTyped[weatherArgs, weatherReport](ctx, client, "weather", weatherArgs{City: "Lisbon", Units: "kelvin"}, mcp.WithArgumentValidation())
+	assert.ErrorIs(t, err, mcp.ErrInvalidToolArguments)
+	assert.Contains(t, err.Error(), "arguments.units")
+	_, ok = client.CachedTool("weather")
+	assert.True(t, ok)
+
+	_, err = mcp.CallToolTyped[map[string]any, weatherReport](ctx, client, "legacy_weather", map[string]any{"city": 42}, mcp.WithArgumentValidation())
+	assert.ErrorIs(t, err, mcp.ErrInvalidToolArguments)
+	_, err = mcp.CallToolTyped[map[string]any, weatherReport](ctx, client, "legacy_weather", map[string]any{}, mcp.WithArgumentValidation())
+	assert.ErrorIs(t, err, mcp.ErrInvalidToolArguments)
+
+	report, err := mcp.CallToolTyped[weatherArgs, weatherReport](ctx, client, "weather", weatherArgs{City: "Lisbon", Units: "celsius"}, mcp.WithArgumentValidation())
+	require.NoError(t, err)
+	assert.Equal(t, "Lisbon", report.City)
+}
+
+func TestCallToolTyped_CacheClearedOnListChanged(t *testing.T) {
+	// The cache is cleared by a server notification, so this test needs a
+	// transport with a session
+	server := mcp.NewMCPServer("typed-server", "1.0.0", mcp.WithToolCapabilities(true))
+	server.AddTool(mcp.NewTool("echo", mcp.WithString("message")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.GetString("message", "")), nil
+	})
+	httpServer := httptest.NewServer(mcp.NewWebSocketServer(server))
+	defer httpServer.Close()
+	client, err := mcp.NewWebSocketMCPClient(webSocketURL(httpServer))
+	require.NoError(t, err)
+	defer client.Close()
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	initializeClient(t, client)
+
+	_, err = client.ListTools(ctx, mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	_, ok := client.CachedTool("echo")
+	require.True(t, ok)
+
+	server.DeleteTools("echo")
+	assert.Eventually(t, func() bool {
+		_, ok := client.CachedTool("echo")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
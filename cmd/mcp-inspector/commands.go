@@ -0,0 +1,551 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tinywasm/mcp"
+)
+
+// inspector runs commands against a connected client.
+type inspector struct {
+	client  *mcp.Client
+	out     *printer
+	term    *mcp.Terminal
+	timeout time.Duration
+}
+
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(i *inspector, ctx context.Context, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"tools", "tools", "list the tools", (*inspector).listTools},
+		{"call", "call <tool> [json | key=value...]", "call a tool", (*inspector).callTool},
+		{"resources", "resources", "list the resources", (*inspector).listResources},
+		{"templates", "templates", "list the resource templates", (*inspector).listTemplates},
+		{"read", "read <uri>", "read a resource", (*inspector).readResource},
+		{"prompts", "prompts", "list the prompts", (*inspector).listPrompts},
+		{"prompt", "prompt <name> [key=value...]", "render a prompt", (*inspector).getPrompt},
+		{"complete", "complete <prompt | uri> <argument> [value] [key=value...]", "complete a prompt or resource template argument", (*inspector).complete},
+		{"loglevel", "loglevel <level>", "set the level of the server's log messages", (*inspector).setLogLevel},
+		{"ping", "ping", "ping the server", (*inspector).ping},
+		{"listen", "listen", "print notifications until interrupted", (*inspector).listen},
+	}
+}
+
+func printCommands(w io.Writer) {
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-58s %s\n", c.usage, c.help)
+	}
+}
+
+// execute runs a single command line. Ctrl-C cancels the command only, so
+// the shell keeps running.
+func (i *inspector) execute(ctx context.Context, args []string) error {
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		if c.name != "listen" {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, i.timeout)
+			defer cancel()
+		}
+		return c.run(i, ctx, args[1:])
+	}
+	return fmt.Errorf("unknown command %q, see 'help'", args[0])
+}
+
+// repl reads and runs commands until the input ends or the user quits.
+func (i *inspector) repl(ctx context.Context) error {
+	for {
+		line, err := i.term.ReadLine(ctx, "mcp> ")
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		args, err := splitCommandLine(line)
+		if err != nil {
+			i.term.Printf("error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "help":
+			printCommands(i.term)
+			i.term.Printf("  %-58s %s\n", "exit", "leave the shell")
+			continue
+		}
+		if err := i.execute(ctx, args); err != nil {
+			i.term.Printf("error: %v\n", err)
+		}
+	}
+}
+
+func (i *inspector) listTools(ctx context.Context, args []string) error {
+	result, err := i.client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		for _, tool := range result.Tools {
+			fmt.Fprintf(w, "%s%s\n", tool.Name, describe(tool.Description))
+			schema, _ := toMap(tool.InputSchema)
+			if tool.RawInputSchema != nil {
+				_ = json.Unmarshal(tool.RawInputSchema, &schema)
+			}
+			properties, _ := schema["properties"].(map[string]any)
+			required := make(map[string]bool)
+			if list, ok := schema["required"].([]any); ok {
+				for _, name := range list {
+					if name, ok := name.(string); ok {
+						required[name] = true
+					}
+				}
+			}
+			for _, name := range sortedKeys(properties) {
+				property, _ := properties[name].(map[string]any)
+				kind, _ := property["type"].(string)
+				flags := kind
+				if required[name] {
+					flags += ", required"
+				}
+				description, _ := property["description"].(string)
+				fmt.Fprintf(w, "    %s (%s)%s\n", name, flags, describe(description))
+			}
+		}
+	})
+}
+
+func (i *inspector) callTool(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: call <tool> [json | key=value...]")
+	}
+	name := args[0]
+	arguments, err := i.toolArguments(ctx, name, args[1:])
+	if err != nil {
+		return err
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	result, err := i.client.CallTool(ctx, request)
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		if result.IsError {
+			fmt.Fprintln(w, "tool returned an error:")
+		}
+		for _, content := range result.Content {
+			printContent(w, content)
+		}
+		if result.StructuredContent != nil {
+			data, _ := json.MarshalIndent(result.StructuredContent, "", "  ")
+			fmt.Fprintf(w, "structured content:\n%s\n", data)
+		}
+	})
+}
+
+// toolArguments parses the arguments of a tool call, either a JSON object or
+// key=value pairs. Values are converted to the types of the tool's input
+// schema, so that "count=3" sends a number but "zip=01234" a string.
+func (i *inspector) toolArguments(ctx context.Context, name string, args []string) (map[string]any, error) {
+	arguments := make(map[string]any)
+	if len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		if err := json.Unmarshal([]byte(args[0]), &arguments); err != nil {
+			return nil, fmt.Errorf("invalid JSON arguments: %w", err)
+		}
+		return arguments, nil
+	}
+	if len(args) == 0 {
+		return arguments, nil
+	}
+
+	tool, ok := i.client.CachedTool(name)
+	if !ok {
+		if _, err := i.client.ListTools(ctx, mcp.ListToolsRequest{}); err != nil {
+			return nil, err
+		}
+		tool, _ = i.client.CachedTool(name)
+	}
+	schema, _ := toMap(tool.InputSchema)
+	if tool.RawInputSchema != nil {
+		_ = json.Unmarshal(tool.RawInputSchema, &schema)
+	}
+	properties, _ := schema["properties"].(map[string]any)
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		property, _ := properties[key].(map[string]any)
+		switch property["type"] {
+		case "string":
+			arguments[key] = value
+		case nil:
+			// Unknown type: JSON if it parses, a string otherwise
+			var decoded any
+			if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+				decoded = value
+			}
+			arguments[key] = decoded
+		default:
+			var decoded any
+			if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+				return nil, fmt.Errorf("argument %s: expected %v, got %q", key, property["type"], value)
+			}
+			arguments[key] = decoded
+		}
+	}
+	return arguments, nil
+}
+
+func (i *inspector) listResources(ctx context.Context, args []string) error {
+	result, err := i.client.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		for _, resource := range result.Resources {
+			fmt.Fprintf(w, "%s  %s%s%s\n", resource.URI, resource.Name, mimeType(resource.MIMEType), describe(resource.Description))
+		}
+	})
+}
+
+func (i *inspector) listTemplates(ctx context.Context, args []string) error {
+	result, err := i.client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		for _, template := range result.ResourceTemplates {
+			uriTemplate := ""
+			if template.URITemplate != nil {
+				uriTemplate = template.URITemplate.Raw()
+			}
+			fmt.Fprintf(w, "%s  %s%s%s\n", uriTemplate, template.Name, mimeType(template.MIMEType), describe(template.Description))
+		}
+	})
+}
+
+func (i *inspector) readResource(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: read <uri>")
+	}
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = args[0]
+	result, err := i.client.ReadResource(ctx, request)
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		for _, contents := range result.Contents {
+			printResourceContents(w, contents)
+		}
+	})
+}
+
+func (i *inspector) listPrompts(ctx context.Context, args []string) error {
+	result, err := i.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		for _, prompt := range result.Prompts {
+			fmt.Fprintf(w, "%s%s\n", prompt.Name, describe(prompt.Description))
+			for _, argument := range prompt.Arguments {
+				required := ""
+				if argument.Required {
+					required = " (required)"
+				}
+				fmt.Fprintf(w, "    %s%s%s\n", argument.Name, required, describe(argument.Description))
+			}
+		}
+	})
+}
+
+func (i *inspector) getPrompt(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: prompt <name> [key=value...]")
+	}
+	arguments, err := keyValues(args[1:])
+	if err != nil {
+		return err
+	}
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = args[0]
+	request.Params.Arguments = arguments
+	result, err := i.client.GetPrompt(ctx, request)
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		if result.Description != "" {
+			fmt.Fprintf(w, "%s\n\n", result.Description)
+		}
+		for _, message := range result.Messages {
+			fmt.Fprintf(w, "[%s]\n", message.Role)
+			switch content := message.Content.(type) {
+			case mcp.Content:
+				printContent(w, content)
+			case []mcp.Content:
+				for _, c := range content {
+					printContent(w, c)
+				}
+			default:
+				data, _ := json.Marshal(content)
+				fmt.Fprintf(w, "%s\n", data)
+			}
+		}
+	})
+}
+
+func (i *inspector) complete(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: complete <prompt | uri> <argument> [value] [key=value...]")
+	}
+	request := mcp.CompleteRequest{}
+	if strings.Contains(args[0], "://") {
+		request.Params.Ref = mcp.ResourceReference{Type: "ref/resource", URI: args[0]}
+	} else {
+		request.Params.Ref = mcp.PromptReference{Type: "ref/prompt", Name: args[0]}
+	}
+	request.Params.Argument.Name = args[1]
+	rest := args[2:]
+	if len(rest) > 0 && !strings.Contains(rest[0], "=") {
+		request.Params.Argument.Value = rest[0]
+		rest = rest[1:]
+	}
+	resolved, err := keyValues(rest)
+	if err != nil {
+		return err
+	}
+	request.Params.Context.Arguments = resolved
+
+	result, err := i.client.Complete(ctx, request)
+	if err != nil {
+		return err
+	}
+	return i.out.result(result, func(w io.Writer) {
+		for _, value := range result.Completion.Values {
+			fmt.Fprintln(w, value)
+		}
+		if result.Completion.HasMore || result.Completion.Total > len(result.Completion.Values) {
+			fmt.Fprintf(w, "(%d of %d shown)\n", len(result.Completion.Values), result.Completion.Total)
+		}
+	})
+}
+
+func (i *inspector) setLogLevel(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: loglevel <debug|info|notice|warning|error|critical|alert|emergency>")
+	}
+	request := mcp.SetLevelRequest{}
+	request.Params.Level = mcp.LoggingLevel(args[0])
+	if err := i.client.SetLevel(ctx, request); err != nil {
+		return err
+	}
+	return i.out.result(map[string]any{}, func(w io.Writer) {
+		fmt.Fprintf(w, "log level set to %s\n", args[0])
+	})
+}
+
+func (i *inspector) ping(ctx context.Context, args []string) error {
+	start := time.Now()
+	if err := i.client.Ping(ctx); err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+	return i.out.result(map[string]any{"latencyMs": elapsed.Milliseconds()}, func(w io.Writer) {
+		fmt.Fprintf(w, "pong in %s\n", elapsed.Round(time.Millisecond))
+	})
+}
+
+func (i *inspector) listen(ctx context.Context, args []string) error {
+	i.term.Printf("Listening for notifications, press Ctrl-C to stop.\n")
+	<-ctx.Done()
+	return nil
+}
+
+// printer writes command results as text or JSON.
+type printer struct {
+	w          io.Writer
+	jsonOutput bool
+}
+
+func newPrinter(w io.Writer, jsonOutput bool) *printer {
+	return &printer{w: &syncWriter{w: w}, jsonOutput: jsonOutput}
+}
+
+func (p *printer) result(result any, text func(w io.Writer)) error {
+	if p.jsonOutput {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", data)
+		return err
+	}
+	var buf strings.Builder
+	text(&buf)
+	_, err := io.WriteString(p.w, buf.String())
+	return err
+}
+
+// notification prints a server notification. In JSON mode notifications
+// are written as single lines between the results.
+func (p *printer) notification(notification mcp.JSONRPCNotification) {
+	if p.jsonOutput {
+		data, _ := json.Marshal(notification)
+		fmt.Fprintf(p.w, "%s\n", data)
+		return
+	}
+	params, _ := json.Marshal(notification.Params)
+	fmt.Fprintf(p.w, "<- %s %s\n", notification.Method, params)
+}
+
+func printContent(w io.Writer, content mcp.Content) {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		fmt.Fprintln(w, c.Text)
+	case mcp.ImageContent:
+		fmt.Fprintf(w, "[image %s, %d base64 bytes]\n", c.MIMEType, len(c.Data))
+	case mcp.AudioContent:
+		fmt.Fprintf(w, "[audio %s, %d base64 bytes]\n", c.MIMEType, len(c.Data))
+	case mcp.ResourceLink:
+		fmt.Fprintf(w, "[resource link %s%s]\n", c.URI, describe(c.Name))
+	case mcp.EmbeddedResource:
+		printResourceContents(w, c.Resource)
+	default:
+		data, _ := json.Marshal(content)
+		fmt.Fprintf(w, "%s\n", data)
+	}
+}
+
+func printResourceContents(w io.Writer, contents mcp.ResourceContents) {
+	switch c := contents.(type) {
+	case mcp.TextResourceContents:
+		fmt.Fprintf(w, "--- %s%s\n%s\n", c.URI, mimeType(c.MIMEType), c.Text)
+	case mcp.BlobResourceContents:
+		fmt.Fprintf(w, "--- %s%s\n[%d base64 bytes]\n", c.URI, mimeType(c.MIMEType), len(c.Blob))
+	default:
+		data, _ := json.Marshal(contents)
+		fmt.Fprintf(w, "%s\n", data)
+	}
+}
+
+func describe(description string) string {
+	if description == "" {
+		return ""
+	}
+	return " - " + strings.ReplaceAll(strings.TrimSpace(description), "\n", " ")
+}
+
+func mimeType(mimeType string) string {
+	if mimeType == "" {
+		return ""
+	}
+	return " (" + mimeType + ")"
+}
+
+func keyValues(args []string) (map[string]string, error) {
+	values := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func toMap(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitCommandLine splits a shell line into words, honoring single and
+// double quotes and backslash escapes, so JSON arguments can be quoted.
+func splitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,239 @@
+// Command mcp-inspector connects to an MCP server and exercises it from the
+// terminal: listing and calling tools, reading resources, rendering prompts,
+// running completions and watching notifications.
+//
+// Usage:
+//
+//	mcp-inspector [flags] [command [args...]] -- server-command [args...]
+//	mcp-inspector [flags] -url https://example.com/mcp [command [args...]]
+//	mcp-inspector [flags] -sse https://example.com/sse [command [args...]]
+//	mcp-inspector [flags] -ws ws://localhost:8080/mcp [command [args...]]
+//	mcp-inspector [flags] -unix /tmp/mcp.sock [command [args...]]
+//
+// Without a command it starts an interactive shell. Sampling and
+// elicitation requests from the server are answered on the terminal.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tinywasm/mcp"
+)
+
+// listFlag collects a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ", ") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type options struct {
+	url          string
+	sseURL       string
+	wsURL        string
+	socketPath   string
+	headers      listFlag
+	env          listFlag
+	jsonOutput   bool
+	oauth        bool
+	clientID     string
+	clientSecret string
+	scopes       string
+	timeout      time.Duration
+	serverCmd    []string
+}
+
+func main() {
+	// Everything after the first "--" is the stdio server command
+	args, serverCmd := os.Args[1:], []string(nil)
+	for i, arg := range args {
+		if arg == "--" {
+			args, serverCmd = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	opts := options{serverCmd: serverCmd}
+	flags := flag.NewFlagSet("mcp-inspector", flag.ExitOnError)
+	flags.StringVar(&opts.url, "url", "", "Streamable HTTP endpoint of the server")
+	flags.StringVar(&opts.sseURL, "sse", "", "SSE endpoint of the server")
+	flags.StringVar(&opts.wsURL, "ws", "", "WebSocket endpoint of the server")
+	flags.StringVar(&opts.socketPath, "unix", "", "Unix socket of the server")
+	flags.Var(&opts.headers, "header", "HTTP header as 'Name: value' (repeatable)")
+	flags.Var(&opts.env, "env", "environment variable as KEY=VALUE for the stdio server (repeatable)")
+	flags.BoolVar(&opts.jsonOutput, "json", false, "print results as JSON")
+	flags.BoolVar(&opts.oauth, "oauth", false, "authorize with OAuth, opening a browser if needed")
+	flags.StringVar(&opts.clientID, "client-id", "", "OAuth client ID (registered dynamically if empty)")
+	flags.StringVar(&opts.clientSecret, "client-secret", "", "OAuth client secret")
+	flags.StringVar(&opts.scopes, "scopes", "", "OAuth scopes, separated by spaces")
+	flags.DurationVar(&opts.timeout, "timeout", 60*time.Second, "timeout of each request")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "usage: mcp-inspector [flags] [command [args...]] -- server-command [args...]\n")
+		fmt.Fprintf(out, "       mcp-inspector [flags] -url|-sse|-ws|-unix ADDRESS [command [args...]]\n\ncommands:\n")
+		printCommands(out)
+		fmt.Fprintf(out, "\nflags:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, opts, flags.Args(), os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-inspector: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	term := mcp.NewTerminal(stdin, &syncWriter{w: stderr})
+	out := newPrinter(stdout, opts.jsonOutput)
+
+	client, err := connect(opts, term)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	client.OnNotification(func(notification mcp.JSONRPCNotification) {
+		out.notification(notification)
+	})
+
+	loopback := mcp.LoopbackAuthConfig{
+		ClientName: "mcp-inspector",
+		OpenURL: func(ctx context.Context, authURL string) error {
+			term.Printf("Open this URL to authorize mcp-inspector:\n  %s\n", authURL)
+			openBrowser(authURL)
+			return nil
+		},
+	}
+	// The connection outlives interrupts: the transport keeps the context
+	// it was started with, and Ctrl-C must only stop the running command.
+	if _, err := mcp.CallWithLoopbackAuth(ctx, loopback, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, client.Start(context.WithoutCancel(ctx))
+	}); err != nil {
+		return fmt.Errorf("failed to start client: %w", err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "mcp-inspector", Version: "1.0.0"}
+	initCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+	initResult, err := mcp.CallWithLoopbackAuth(initCtx, loopback, func(ctx context.Context) (*mcp.InitializeResult, error) {
+		return client.Initialize(ctx, initRequest)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	insp := &inspector{client: client, out: out, term: term, timeout: opts.timeout}
+	if len(command) > 0 {
+		return insp.execute(ctx, command)
+	}
+	term.Printf("Connected to %s %s (protocol %s). Type 'help' for commands.\n",
+		initResult.ServerInfo.Name, initResult.ServerInfo.Version, initResult.ProtocolVersion)
+	// Interrupts stop the running command, not the shell. The signal
+	// context stays registered, so Ctrl-C at the prompt is ignored.
+	return insp.repl(context.WithoutCancel(ctx))
+}
+
+func connect(opts options, term *mcp.Terminal) (*mcp.Client, error) {
+	clientOptions := []mcp.ClientOption{
+		mcp.WithSamplingHandler(mcp.NewTerminalSamplingHandler(&humanSampler{term: term}, term)),
+		mcp.WithElicitationHandler(mcp.NewTerminalElicitationHandler(term)),
+	}
+	headers, err := parseHeaders(opts.headers)
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig := mcp.OAuthConfig{
+		ClientID:     opts.clientID,
+		ClientSecret: opts.clientSecret,
+		Scopes:       strings.Fields(opts.scopes),
+		PKCEEnabled:  true,
+	}
+
+	endpoints := 0
+	for _, endpoint := range []string{opts.url, opts.sseURL, opts.wsURL, opts.socketPath} {
+		if endpoint != "" {
+			endpoints++
+		}
+	}
+	if len(opts.serverCmd) > 0 {
+		endpoints++
+	}
+	if endpoints != 1 {
+		return nil, fmt.Errorf("exactly one of -url, -sse, -ws, -unix or a server command after -- is required")
+	}
+
+	var transport mcp.Interface
+	switch {
+	case opts.url != "":
+		httpOptions := []mcp.StreamableHTTPCOption{mcp.WithHTTPHeaders(headers)}
+		if opts.oauth {
+			httpOptions = append(httpOptions, mcp.WithHTTPOAuth(oauthConfig))
+		}
+		transport, err = mcp.NewStreamableHTTP(opts.url, httpOptions...)
+	case opts.sseURL != "":
+		sseOptions := []mcp.SSEOption{mcp.WithSSEHeaders(headers)}
+		if opts.oauth {
+			sseOptions = append(sseOptions, mcp.WithSSEOAuth(oauthConfig))
+		}
+		transport, err = mcp.NewSSE(opts.sseURL, sseOptions...)
+	case opts.wsURL != "":
+		transport, err = mcp.NewWebSocket(opts.wsURL, mcp.WithWebSocketHeaders(headers))
+	case opts.socketPath != "":
+		transport = mcp.NewUnixSocket(opts.socketPath)
+	default:
+		stdio := mcp.NewStdio(opts.serverCmd[0], opts.env, opts.serverCmd[1:]...)
+		if err := stdio.Start(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to start server: %w", err)
+		}
+		// Show the server's log output
+		go func() { _, _ = io.Copy(os.Stderr, stdio.Stderr()) }()
+		transport = stdio
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewClient(transport, clientOptions...), nil
+}
+
+func parseHeaders(headers []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(headers))
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q, expected 'Name: value'", header)
+		}
+		parsed[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
+
+// openBrowser opens url in the default browser, if there is one.
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	_ = cmd.Start()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+)
+
+// sseServer is a minimal MCP server on the SSE transport that answers
+// initialize and ping.
+type sseServer struct {
+	mu      sync.Mutex
+	streams []chan []byte
+}
+
+// send sends a message to the client on the current stream.
+func (s *sseServer) send(data []byte) {
+	s.mu.Lock()
+	stream := s.streams[len(s.streams)-1]
+	s.mu.Unlock()
+	stream <- data
+}
+
+func (s *sseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		stream := make(chan []byte, 16)
+		s.mu.Lock()
+		s.streams = append(s.streams, stream)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: endpoint\ndata: /message\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case data := <-stream:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	var request mcp.JSONRPCRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+	w.WriteHeader(http.StatusAccepted)
+	var result any = map[string]any{}
+	switch request.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+			"serverInfo":      map[string]any{"name": "sse-server", "version": "1.0.0"},
+			"capabilities":    map[string]any{},
+		}
+	case "ping":
+	default:
+		return // A notification
+	}
+	data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": result})
+	s.send(data)
+}
+
+// lockedBuilder is a strings.Builder safe for concurrent writes.
+type lockedBuilder struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuilder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// waitFor waits until the output contains text.
+func (b *lockedBuilder) waitFor(t *testing.T, text string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		found := strings.Contains(b.buf.String(), text)
+		b.mu.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t.Fatalf("timed out waiting for %q, output:\n%s", text, b.buf.String())
+}
+
+func TestRun_InterruptListenOverSSE(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts cannot be sent to the own process on Windows")
+	}
+	server := httptest.NewServer(&sseServer{})
+	defer server.Close()
+
+	// As in main
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	input, commands := io.Pipe()
+	var stdout, stderr lockedBuilder
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, options{sseURL: server.URL, timeout: 5 * time.Second}, nil, input, &stdout, &stderr)
+	}()
+	stderr.waitFor(t, "Connected to sse-server")
+
+	_, _ = io.WriteString(commands, "listen\n")
+	stderr.waitFor(t, "Listening for notifications")
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The shell and its connection survive the interrupt
+	_, _ = io.WriteString(commands, "ping\n")
+	stdout.waitFor(t, "pong in")
+	_ = commands.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+func TestRun_ElicitationAtPrompt(t *testing.T) {
+	sessions := make(chan mcp.ClientSession, 1)
+	hooks := &mcp.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session mcp.ClientSession) {
+		sessions <- session
+	})
+	mcpServer := mcp.NewMCPServer("ws-server", "1.0.0", mcp.WithElicitation(), mcp.WithHooks(hooks))
+	server := httptest.NewServer(mcp.NewWebSocketServer(mcpServer))
+	defer server.Close()
+
+	input, commands := io.Pipe()
+	var stdout, stderr lockedBuilder
+	done := make(chan error, 1)
+	go func() {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+		done <- run(context.Background(), options{wsURL: wsURL, timeout: 5 * time.Second}, nil, input, &stdout, &stderr)
+	}()
+	stderr.waitFor(t, "mcp> ")
+
+	// The server asks while the shell waits for a command
+	session := (<-sessions).(mcp.SessionWithElicitation)
+	elicited := make(chan *mcp.ElicitationResult, 1)
+	go func() {
+		result, err := session.RequestElicitation(context.Background(), mcp.ElicitationRequest{Params: mcp.ElicitationParams{
+			Message:         "Who are you?",
+			RequestedSchema: map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}},
+		}})
+		if err != nil {
+			t.Error(err)
+		}
+		elicited <- result
+	}()
+	stderr.waitFor(t, "Provide this information?")
+	_, _ = io.WriteString(commands, "accept\nAda\n")
+
+	select {
+	case result := <-elicited:
+		if result == nil || result.Action != mcp.ElicitationResponseActionAccept {
+			t.Fatalf("unexpected elicitation result %+v", result)
+		}
+		if name := result.Content.(map[string]any)["name"]; name != "Ada" {
+			t.Fatalf("got name %v, want Ada", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no elicitation result")
+	}
+
+	// The shell still gets the next command
+	_, _ = io.WriteString(commands, "ping\n")
+	stdout.waitFor(t, "pong in")
+	_ = commands.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/tinywasm/mcp"
+)
+
+// syncWriter serializes writes from the shell and notification handlers.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// humanSampler answers approved sampling requests with a reply typed by the
+// user. It is wrapped in an mcp.TerminalSamplingHandler, which shows the
+// request and asks for approval first.
+type humanSampler struct {
+	term *mcp.Terminal
+}
+
+func (s *humanSampler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	s.term.Lock()
+	defer s.term.Unlock()
+	reply, err := s.term.ReadLine(ctx, "Reply as the assistant (empty to decline): ")
+	if err != nil {
+		return nil, err
+	}
+	if reply == "" {
+		return nil, errors.New("sampling request declined by the user")
+	}
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{
+			Role:    mcp.RoleAssistant,
+			Content: mcp.NewTextContent(reply),
+		},
+		Model:      "human",
+		StopReason: "endTurn",
+	}, nil
+}
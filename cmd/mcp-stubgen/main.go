@@ -0,0 +1,128 @@
+// Command mcp-stubgen generates a typed Go client for the tools of an MCP
+// server. It connects to the server, lists its tools and writes one args
+// struct, one result struct (for tools with an output schema) and one method
+// per tool.
+//
+// Usage:
+//
+//	mcp-stubgen [flags] -url https://example.com/mcp
+//	mcp-stubgen [flags] -- command [args...]
+//
+// With -url the server is reached over Streamable HTTP; otherwise the
+// command is started and spoken to over stdio.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tinywasm/mcp"
+)
+
+// listFlag collects a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ", ") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	var (
+		output   = flag.String("o", "", "output file (default stdout)")
+		pkg      = flag.String("pkg", "mcpclient", "package name of the generated file")
+		typeName = flag.String("type", "Client", "name of the generated client type")
+		url      = flag.String("url", "", "Streamable HTTP endpoint of the server")
+		timeout  = flag.Duration("timeout", 30*time.Second, "time allowed to connect and list the tools")
+		headers  listFlag
+		env      listFlag
+	)
+	flag.Var(&headers, "header", "HTTP header as 'Name: value' (repeatable)")
+	flag.Var(&env, "env", "environment variable as KEY=VALUE for the stdio server (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: mcp-stubgen [flags] -url URL\n       mcp-stubgen [flags] -- command [args...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*output, *pkg, *typeName, *url, headers, env, flag.Args(), *timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "mcp-stubgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(output, pkg, typeName, url string, headers, env, command []string, timeout time.Duration) error {
+	client, err := connect(url, headers, env, command)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := client.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start client: %w", err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "mcp-stubgen", Version: "1.0.0"}
+	initResult, err := client.Initialize(ctx, initRequest)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	var tools []mcp.Tool
+	request := mcp.ListToolsRequest{}
+	for {
+		result, err := client.ListTools(ctx, request)
+		if err != nil {
+			return fmt.Errorf("failed to list tools: %w", err)
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			break
+		}
+		request.Params.Cursor = result.NextCursor
+	}
+
+	code, err := mcp.GenerateClientStubs(tools, mcp.StubConfig{
+		PackageName: pkg,
+		TypeName:    typeName,
+		ServerName:  initResult.ServerInfo.Name,
+	})
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(output, code, 0o644)
+}
+
+func connect(url string, headers, env, command []string) (*mcp.Client, error) {
+	if url != "" {
+		if len(command) > 0 {
+			return nil, fmt.Errorf("-url and a command are mutually exclusive")
+		}
+		headerMap := make(map[string]string, len(headers))
+		for _, header := range headers {
+			name, value, ok := strings.Cut(header, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid header %q, expected 'Name: value'", header)
+			}
+			headerMap[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		return mcp.NewStreamableHttpClient(url, mcp.WithHTTPHeaders(headerMap))
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("either -url or a command is required")
+	}
+	return mcp.NewStdioMCPClient(command[0], env, command[1:]...)
+}
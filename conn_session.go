@@ -0,0 +1,262 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// sessionConn is a message-oriented connection carrying one client session.
+type sessionConn interface {
+	writeMessage(message []byte) error
+	done() <-chan struct{}
+	closeError() error
+}
+
+// connSession is the ClientSession of a connection accepted by a
+// connection-oriented server transport, such as WebSocket or Unix sockets.
+// Requests to the client travel on the same connection as its requests.
+type connSession struct {
+	sessionID          string
+	conn               sessionConn
+	notifications      chan JSONRPCNotification
+	initialized        atomic.Bool
+	loggingLevel       atomic.Value
+	clientInfo         atomic.Value
+	clientCapabilities atomic.Value
+
+	requestID atomic.Int64
+	mu        sync.Mutex
+	pending   map[string]chan *JSONRPCResponse
+}
+
+// newConnSession creates the session of conn, with a random ID starting with prefix.
+func newConnSession(prefix string, conn sessionConn) *connSession {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return &connSession{
+		sessionID:     prefix + hex.EncodeToString(id[:]),
+		conn:          conn,
+		notifications: make(chan JSONRPCNotification, 100),
+		pending:       make(map[string]chan *JSONRPCResponse),
+	}
+}
+
+func (s *connSession) SessionID() string {
+	return s.sessionID
+}
+
+func (s *connSession) NotificationChannel() chan<- JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *connSession) Initialize() {
+	s.loggingLevel.Store(LoggingLevelError)
+	s.initialized.Store(true)
+}
+
+func (s *connSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+func (s *connSession) GetClientInfo() Implementation {
+	if clientInfo, ok := s.clientInfo.Load().(Implementation); ok {
+		return clientInfo
+	}
+	return Implementation{}
+}
+
+func (s *connSession) SetClientInfo(clientInfo Implementation) {
+	s.clientInfo.Store(clientInfo)
+}
+
+func (s *connSession) GetClientCapabilities() ClientCapabilities {
+	if clientCapabilities, ok := s.clientCapabilities.Load().(ClientCapabilities); ok {
+		return clientCapabilities
+	}
+	return ClientCapabilities{}
+}
+
+func (s *connSession) SetClientCapabilities(clientCapabilities ClientCapabilities) {
+	s.clientCapabilities.Store(clientCapabilities)
+}
+
+func (s *connSession) SetLogLevel(level LoggingLevel) {
+	s.loggingLevel.Store(level)
+}
+
+func (s *connSession) GetLogLevel() LoggingLevel {
+	if level, ok := s.loggingLevel.Load().(LoggingLevel); ok {
+		return level
+	}
+	return LoggingLevelError
+}
+
+// serve handles the messages read from the connection until reading fails.
+// Requests and notifications are handled concurrently by server with ctx,
+// responses are routed to the requests waiting for them.
+func (s *connSession) serve(ctx context.Context, server *MCPServer, readMessage func() ([]byte, error)) {
+	go s.forwardNotifications()
+
+	for {
+		message, err := readMessage()
+		if err != nil {
+			return
+		}
+
+		var baseMessage struct {
+			ID     *RequestId `json:"id,omitempty"`
+			Method string     `json:"method,omitempty"`
+		}
+		if err := json.Unmarshal(message, &baseMessage); err == nil && baseMessage.Method == "" && baseMessage.ID != nil {
+			s.deliverResponse(message)
+			continue
+		}
+
+		// Handle requests concurrently, so that handlers can wait for responses
+		go func() {
+			response := server.HandleMessage(ctx, message)
+			if response == nil {
+				return
+			}
+			responseBytes, err := json.Marshal(response)
+			if err != nil {
+				return
+			}
+			_ = s.conn.writeMessage(responseBytes)
+		}()
+	}
+}
+
+// forwardNotifications writes queued notifications until the connection closes.
+func (s *connSession) forwardNotifications() {
+	for {
+		select {
+		case <-s.conn.done():
+			return
+		case notification := <-s.notifications:
+			notificationBytes, err := json.Marshal(notification)
+			if err != nil {
+				continue
+			}
+			if err := s.conn.writeMessage(notificationBytes); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// deliverResponse routes a response from the client to the request waiting for it.
+func (s *connSession) deliverResponse(message []byte) {
+	var response JSONRPCResponse
+	if err := json.Unmarshal(message, &response); err != nil {
+		return
+	}
+	idKey := response.ID.String()
+	s.mu.Lock()
+	ch, ok := s.pending[idKey]
+	delete(s.pending, idKey)
+	s.mu.Unlock()
+	if ok {
+		ch <- &response
+	}
+}
+
+// request sends a request to the client and decodes its result into result.
+func (s *connSession) request(ctx context.Context, method MCPMethod, params any, result any) error {
+	id := NewRequestId(s.requestID.Add(1))
+	requestBytes, err := json.Marshal(struct {
+		JSONRPC string    `json:"jsonrpc"`
+		ID      RequestId `json:"id"`
+		Method  MCPMethod `json:"method"`
+		Params  any       `json:"params,omitempty"`
+	}{JSONRPC: JSONRPC_VERSION, ID: id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	idKey := id.String()
+	responseChan := make(chan *JSONRPCResponse, 1)
+	s.mu.Lock()
+	s.pending[idKey] = responseChan
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, idKey)
+		s.mu.Unlock()
+	}()
+
+	if err := s.conn.writeMessage(requestBytes); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var response *JSONRPCResponse
+	select {
+	case response = <-responseChan:
+	case <-s.conn.done():
+		return fmt.Errorf("%w: %w", ErrTransportClosed, s.conn.closeError())
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("%s request failed: %s (code %d)", method, response.Error.Message, response.Error.Code)
+	}
+	resultBytes, err := json.Marshal(response.Result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	if err := json.Unmarshal(resultBytes, result); err != nil {
+		return fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	return nil
+}
+
+// RequestSampling sends a sampling request to the client and waits for the response.
+func (s *connSession) RequestSampling(ctx context.Context, request CreateMessageRequest) (*CreateMessageResult, error) {
+	if err := checkSamplingTools(s.GetClientCapabilities(), request); err != nil {
+		return nil, err
+	}
+	var result CreateMessageResult
+	if err := s.request(ctx, MethodSamplingCreateMessage, request.CreateMessageParams, &result); err != nil {
+		return nil, err
+	}
+	content, err := parseSamplingContent(result.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sampling content: %w", err)
+	}
+	result.Content = content
+	return &result, nil
+}
+
+// RequestElicitation sends an elicitation request to the client and waits for the response.
+func (s *connSession) RequestElicitation(ctx context.Context, request ElicitationRequest) (*ElicitationResult, error) {
+	var result ElicitationResult
+	if err := s.request(ctx, MethodElicitationCreate, request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListRoots sends a list roots request to the client and waits for the response.
+func (s *connSession) ListRoots(ctx context.Context, request ListRootsRequest) (*ListRootsResult, error) {
+	var result ListRootsResult
+	if err := s.request(ctx, MethodListRoots, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ensure interface compliance
+var (
+	_ ClientSession          = (*connSession)(nil)
+	_ SessionWithLogging     = (*connSession)(nil)
+	_ SessionWithClientInfo  = (*connSession)(nil)
+	_ SessionWithSampling    = (*connSession)(nil)
+	_ SessionWithElicitation = (*connSession)(nil)
+	_ SessionWithRoots       = (*connSession)(nil)
+)
@@ -4,4 +4,5 @@ package mcp
 const (
 	HeaderKeySessionID       = "Mcp-Session-Id"
 	HeaderKeyProtocolVersion = "Mcp-Protocol-Version"
+	HeaderKeyLastEventID     = "Last-Event-ID"
 )
@@ -3,6 +3,7 @@ package mcp
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -20,10 +21,59 @@ var (
 	ErrSessionDoesNotSupportResources         = errors.New("session does not support per-session resources")
 	ErrSessionDoesNotSupportResourceTemplates = errors.New("session does not support resource templates")
 	ErrSessionDoesNotSupportLogging           = errors.New("session does not support setting logging level")
+	ErrSessionDoesNotSupportElicitation       = errors.New("session does not support elicitation")
+	ErrSessionDoesNotSupportSampling          = errors.New("session does not support sampling")
 
 	// Notification-related errors
 	ErrNotificationNotInitialized = errors.New("notification channel not initialized")
 	ErrNotificationChannelBlocked = errors.New("notification channel queue is full - client may not be processing notifications fast enough")
+
+	// Task-related errors
+	ErrTaskTerminated = errors.New("task already in terminal status")
+	ErrTaskCancelled  = errors.New("task was cancelled")
+
+	// Stream resumption errors
+	ErrEventNotFound     = errors.New("event not found")
+	ErrEventStreamClosed = errors.New("event stream closed")
+
+	// Client connection errors
+	ErrReconnectFailed = errors.New("failed to re-establish session")
+
+	// WebSocket errors
+	ErrWebSocketHandshake = errors.New("websocket handshake failed")
+
+	// Unix socket errors
+	ErrUnixSocketInUse        = errors.New("unix socket already in use")
+	ErrUnixSocketServerClosed = errors.New("unix socket server closed")
+
+	// OAuth resource server errors
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrInsufficientScope = errors.New("insufficient scope")
+
+	// Typed tool call errors
+	ErrToolCallFailed       = errors.New("tool call failed")
+	ErrInvalidToolArguments = errors.New("invalid tool arguments")
+
+	// Typed elicitation errors
+	ErrUnsupportedElicitationSchema = errors.New("unsupported elicitation schema")
+	ErrInvalidElicitationContent    = errors.New("invalid elicitation content")
+
+	// Sampling errors
+	ErrSamplingRejected          = errors.New("sampling request rejected by the user")
+	ErrSamplingToolsNotSupported = errors.New("client does not support tools in sampling requests")
+	ErrSamplingLoopLimit         = errors.New("model kept using tools")
+
+	// URL elicitation errors
+	ErrURLElicitationNotFound    = errors.New("url elicitation not found")
+	ErrURLElicitationNotAccepted = errors.New("url elicitation not accepted by the user")
+	ErrURLElicitationExpired     = errors.New("url elicitation expired")
+	ErrURLElicitationTimeout     = errors.New("timed out waiting for url elicitation")
+
+	// Token store errors
+	ErrTokenStoreDecrypt = errors.New("failed to decrypt token store")
+
+	// IDE configuration errors
+	ErrInvalidIDEConfig = errors.New("invalid IDE config file")
 )
 
 // ErrDynamicPathConfig is returned when attempting to use static path methods with dynamic path configuration
@@ -34,3 +84,45 @@ type ErrDynamicPathConfig struct {
 func (e *ErrDynamicPathConfig) Error() string {
 	return fmt.Sprintf("%s cannot be used with WithDynamicBasePath. Use dynamic path logic in your router.", e.Method)
 }
+
+// InsufficientScopeError is returned by Client methods when the server
+// rejects a request because the access token lacks scopes required by a
+// tool, prompt or resource. Clients can request a token with the missing
+// scopes and retry (step-up authorization).
+type InsufficientScopeError struct {
+	Message        string
+	RequiredScopes []string // All scopes required by the tool, prompt or resource
+	MissingScopes  []string // The required scopes that were not granted
+}
+
+func (e *InsufficientScopeError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", INSUFFICIENT_SCOPE, e.Message)
+}
+
+func (e *InsufficientScopeError) Unwrap() error {
+	return ErrInsufficientScope
+}
+
+// ToolError is returned by CallToolTyped when the tool reports an error
+// with an IsError result. Content holds what the tool returned.
+type ToolError struct {
+	Name    string
+	Content []Content
+}
+
+func (e *ToolError) Error() string {
+	var texts []string
+	for _, content := range e.Content {
+		if text, ok := content.(TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return fmt.Sprintf("tool '%s' failed", e.Name)
+	}
+	return fmt.Sprintf("tool '%s' failed: %s", e.Name, strings.Join(texts, "\n"))
+}
+
+func (e *ToolError) Unwrap() error {
+	return ErrToolCallFailed
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// DefaultEventStoreCapacity is the number of events kept by an
+// InMemoryEventStore created with a non-positive capacity.
+const DefaultEventStoreCapacity = 1024
+
+// EventStore persists the SSE events sent on Streamable HTTP streams so that a
+// client reconnecting with a Last-Event-ID header receives the events it missed
+// while disconnected.
+//
+// https://modelcontextprotocol.io/specification/2025-03-26/basic/transports#resumability-and-redelivery
+type EventStore interface {
+	// StoreEvent records message as the next event of the stream and returns
+	// the event ID to send with it. Event IDs must be unique across all
+	// streams of the store.
+	StoreEvent(ctx context.Context, streamID string, message json.RawMessage) (string, error)
+
+	// ReplayEventsAfter calls send, in order, for every event stored after
+	// lastEventID on the stream that event belongs to, and returns that
+	// stream's ID. It returns ErrEventNotFound if lastEventID is unknown,
+	// for example because the event has already been evicted.
+	ReplayEventsAfter(ctx context.Context, lastEventID string, send func(eventID string, message json.RawMessage) error) (string, error)
+}
+
+// storedEvent is a single event held by an InMemoryEventStore
+type storedEvent struct {
+	seq      uint64
+	streamID string
+	message  json.RawMessage
+}
+
+// InMemoryEventStore is an EventStore backed by a fixed-size ring buffer
+// shared by all streams. Once the buffer is full the oldest events are
+// evicted, so a client that stays disconnected for too long cannot resume.
+type InMemoryEventStore struct {
+	mu     sync.Mutex
+	events []storedEvent // Ring buffer, events[(first+i)%len(events)] is the i-th oldest
+	first  int
+	count  int
+	next   uint64 // Sequence number of the next stored event
+}
+
+var _ EventStore = (*InMemoryEventStore)(nil)
+
+// NewInMemoryEventStore creates an in-memory event store keeping at most
+// capacity events. A non-positive capacity uses DefaultEventStoreCapacity.
+func NewInMemoryEventStore(capacity int) *InMemoryEventStore {
+	if capacity <= 0 {
+		capacity = DefaultEventStoreCapacity
+	}
+	return &InMemoryEventStore{
+		events: make([]storedEvent, capacity),
+		next:   1,
+	}
+}
+
+// StoreEvent implements EventStore. Event IDs are decimal sequence numbers.
+func (s *InMemoryEventStore) StoreEvent(ctx context.Context, streamID string, message json.RawMessage) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := storedEvent{seq: s.next, streamID: streamID, message: message}
+	s.next++
+
+	if s.count < len(s.events) {
+		s.events[(s.first+s.count)%len(s.events)] = event
+		s.count++
+	} else {
+		// Overwrite the oldest event
+		s.events[s.first] = event
+		s.first = (s.first + 1) % len(s.events)
+	}
+
+	return strconv.FormatUint(event.seq, 10), nil
+}
+
+// ReplayEventsAfter implements EventStore.
+func (s *InMemoryEventStore) ReplayEventsAfter(
+	ctx context.Context,
+	lastEventID string,
+	send func(eventID string, message json.RawMessage) error,
+) (string, error) {
+	seq, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrEventNotFound, lastEventID)
+	}
+
+	// Copy the events to replay so that send runs without holding the lock
+	s.mu.Lock()
+	if s.count == 0 || seq < s.events[s.first].seq || seq >= s.next {
+		s.mu.Unlock()
+		return "", fmt.Errorf("%w: %q", ErrEventNotFound, lastEventID)
+	}
+	offset := int(seq - s.events[s.first].seq)
+	streamID := s.events[(s.first+offset)%len(s.events)].streamID
+	var replay []storedEvent
+	for i := offset + 1; i < s.count; i++ {
+		event := s.events[(s.first+i)%len(s.events)]
+		if event.streamID == streamID {
+			replay = append(replay, event)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, event := range replay {
+		if err := ctx.Err(); err != nil {
+			return streamID, err
+		}
+		if err := send(strconv.FormatUint(event.seq, 10), event.message); err != nil {
+			return streamID, err
+		}
+	}
+	return streamID, nil
+}
@@ -0,0 +1,299 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func TestInMemoryEventStore_ReplaysStreamAfterLastEventID(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryEventStore(10)
+
+	first, err := store.StoreEvent(ctx, "a", json.RawMessage(`1`))
+	require.NoError(t, err)
+	_, err = store.StoreEvent(ctx, "b", json.RawMessage(`2`))
+	require.NoError(t, err)
+	_, err = store.StoreEvent(ctx, "a", json.RawMessage(`3`))
+	require.NoError(t, err)
+
+	var replayed []string
+	streamID, err := store.ReplayEventsAfter(ctx, first, func(eventID string, message json.RawMessage) error {
+		replayed = append(replayed, string(message))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a", streamID)
+	assert.Equal(t, []string{"3"}, replayed)
+}
+
+func TestInMemoryEventStore_EvictsOldestEvents(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryEventStore(2)
+
+	evicted, err := store.StoreEvent(ctx, "a", json.RawMessage(`1`))
+	require.NoError(t, err)
+	kept, err := store.StoreEvent(ctx, "a", json.RawMessage(`2`))
+	require.NoError(t, err)
+	_, err = store.StoreEvent(ctx, "a", json.RawMessage(`3`))
+	require.NoError(t, err)
+
+	noop := func(string, json.RawMessage) error { return nil }
+	_, err = store.ReplayEventsAfter(ctx, evicted, noop)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	_, err = store.ReplayEventsAfter(ctx, "unknown", noop)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	_, err = store.ReplayEventsAfter(ctx, kept, noop)
+	assert.NoError(t, err)
+}
+
+func TestStreamableHTTP_ResumesInterruptedPOSTStream(t *testing.T) {
+	retryInterval = 10 * time.Millisecond
+	store := NewInMemoryEventStore(0)
+
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var request map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+			stream := NewEventStream(w, r, store, "post-1")
+			require.NoError(t, stream.Send(r.Context(), map[string]any{
+				"jsonrpc": "2.0",
+				"method":  "notifications/message",
+				"params":  map[string]any{"level": "info", "data": "before disconnect"},
+			}))
+
+			// The response is produced after the stream was closed
+			stream.Close()
+			require.NoError(t, stream.Send(r.Context(), map[string]any{
+				"jsonrpc": "2.0",
+				"id":      request["id"],
+				"result":  map[string]any{"resumed": true},
+			}))
+
+		case http.MethodGet:
+			mu.Lock()
+			lastEventIDs = append(lastEventIDs, r.Header.Get(HeaderKeyLastEventID))
+			mu.Unlock()
+
+			stream, err := ResumeEventStream(w, r, store)
+			require.NoError(t, err)
+			assert.Equal(t, "post-1", stream.ID())
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	trans, err := NewStreamableHTTP(server.URL)
+	require.NoError(t, err)
+	defer trans.Close()
+
+	notifications := make(chan JSONRPCNotification, 10)
+	trans.SetNotificationHandler(func(notification JSONRPCNotification) {
+		notifications <- notification
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	response, err := trans.SendRequest(ctx, JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      NewRequestId(int64(1)),
+		Request: Request{Method: "tools/call"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"resumed": true}, response.Result)
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, "notifications/message", notification.Method)
+	case <-time.After(time.Second):
+		t.Fatal("notification sent before the disconnect was not delivered")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"1"}, lastEventIDs)
+}
+
+func TestStreamableHTTP_ListeningStreamSendsLastEventID(t *testing.T) {
+	retryInterval = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	var lastEventIDs []string
+	connections := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 0, "result": map[string]any{}})
+		case http.MethodGet:
+			mu.Lock()
+			connections++
+			n := connections
+			lastEventIDs = append(lastEventIDs, r.Header.Get(HeaderKeyLastEventID))
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "id: ev-%d\nretry: 5\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/tools/list_changed\"}\n\n", n)
+			w.(http.Flusher).Flush()
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	trans, err := NewStreamableHTTP(server.URL, WithContinuousListening())
+	require.NoError(t, err)
+	defer trans.Close()
+	require.NoError(t, trans.Start(context.Background()))
+
+	_, err = trans.SendRequest(context.Background(), JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      NewRequestId(int64(0)),
+		Request: Request{Method: "initialize"},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(lastEventIDs) >= 3
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"", "ev-1", "ev-2"}, lastEventIDs[:3])
+}
+
+func TestStreamableHTTP_ListeningStreamRestartsWithNewSession(t *testing.T) {
+	retryInterval = 10 * time.Millisecond
+
+	type get struct{ sessionID, lastEventID string }
+	var mu sync.Mutex
+	var gets []get
+	sessions := 0
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			mu.Lock()
+			sessions++
+			w.Header().Set(HeaderKeySessionID, fmt.Sprintf("session-%d", sessions))
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 0, "result": map[string]any{}})
+		case http.MethodGet:
+			mu.Lock()
+			gets = append(gets, get{r.Header.Get(HeaderKeySessionID), r.Header.Get(HeaderKeyLastEventID)})
+			n := len(gets)
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "id: ev-%d\nretry: 5\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/tools/list_changed\"}\n\n", n)
+			w.(http.Flusher).Flush()
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	trans, err := NewStreamableHTTP(server.URL, WithContinuousListening())
+	require.NoError(t, err)
+	defer trans.Close()
+	require.NoError(t, trans.Start(context.Background()))
+
+	initialize := func() {
+		_, err := trans.SendRequest(context.Background(), JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      NewRequestId(int64(0)),
+			Request: Request{Method: "initialize"},
+		})
+		require.NoError(t, err)
+	}
+	countGets := func(sessionID string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, g := range gets {
+			if g.sessionID == sessionID {
+				n++
+			}
+		}
+		return n
+	}
+
+	initialize()
+	require.Eventually(t, func() bool { return countGets("session-1") >= 2 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, trans.Reconnect(context.Background()))
+	initialize()
+	require.Eventually(t, func() bool { return countGets("session-2") >= 2 }, time.Second, 10*time.Millisecond)
+
+	// The new session's stream does not resume from the old session's events
+	mu.Lock()
+	defer mu.Unlock()
+	var first, second *get
+	for i := range gets {
+		if gets[i].sessionID != "session-2" {
+			continue
+		}
+		if first == nil {
+			first = &gets[i]
+		} else if second == nil {
+			second = &gets[i]
+		}
+	}
+	assert.Equal(t, "", first.lastEventID)
+	assert.NotEqual(t, "", second.lastEventID)
+}
+
+func TestEventStream_SendsStoredEvents(t *testing.T) {
+	store := NewInMemoryEventStore(0)
+	recorder := httptest.NewRecorder()
+	stream := NewEventStream(recorder, httptest.NewRequest(http.MethodPost, "/", nil), store, "s1")
+
+	require.NoError(t, stream.Send(context.Background(), map[string]any{"jsonrpc": "2.0", "method": "ping"}))
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 1\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"ping\"}\n\n", recorder.Body.String())
+
+	// Without a store, messages sent after Close are lost
+	plain := NewEventStream(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), nil, "s2")
+	plain.Close()
+	assert.ErrorIs(t, plain.Send(context.Background(), map[string]any{}), ErrEventStreamClosed)
+}
+
+func TestResumeEventStream(t *testing.T) {
+	store := NewInMemoryEventStore(0)
+	stream := NewEventStream(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), store, "s1")
+	require.NoError(t, stream.Send(context.Background(), map[string]any{"n": 1}))
+	stream.Close()
+	require.NoError(t, stream.Send(context.Background(), map[string]any{"n": 2}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(HeaderKeyLastEventID, "1")
+	recorder := httptest.NewRecorder()
+	resumed, err := ResumeEventStream(recorder, request, store)
+	require.NoError(t, err)
+	assert.Equal(t, "s1", resumed.ID())
+	require.NoError(t, resumed.Send(context.Background(), map[string]any{"n": 3}))
+	assert.Equal(t, "id: 2\nevent: message\ndata: {\"n\":2}\n\nid: 3\nevent: message\ndata: {\"n\":3}\n\n", recorder.Body.String())
+
+	request.Header.Set(HeaderKeyLastEventID, "unknown")
+	recorder = httptest.NewRecorder()
+	_, err = ResumeEventStream(recorder, request, store)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	assert.Empty(t, recorder.Header().Get("Content-Type"))
+}
@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// EventStream is the server side of a Streamable HTTP SSE stream. It writes
+// JSON-RPC messages as SSE events on an HTTP response. With an EventStore,
+// every message is stored and sent with its event ID, so that a client
+// reconnecting with a Last-Event-ID header receives the events it missed.
+type EventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	ctx     context.Context
+	store   EventStore
+	id      string
+	started bool
+	closed  bool
+}
+
+// NewEventStream starts an SSE response to r for the stream streamID. store
+// may be nil, in which case events carry no ID and cannot be replayed.
+func NewEventStream(w http.ResponseWriter, r *http.Request, store EventStore, streamID string) *EventStream {
+	return &EventStream{w: w, ctx: r.Context(), store: store, id: streamID}
+}
+
+// ResumeEventStream answers a GET request carrying a Last-Event-ID header. It
+// replays the events stored after that ID and returns the stream they belong
+// to, on which the server goes on sending. It returns ErrEventNotFound,
+// without writing a response, if the ID is unknown to store.
+func ResumeEventStream(w http.ResponseWriter, r *http.Request, store EventStore) (*EventStream, error) {
+	stream := NewEventStream(w, r, store, "")
+	streamID, err := store.ReplayEventsAfter(r.Context(), r.Header.Get(HeaderKeyLastEventID), stream.write)
+	if err != nil {
+		return nil, err
+	}
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.id = streamID
+	stream.start()
+	return stream, nil
+}
+
+// ID returns the ID of the stream.
+func (s *EventStream) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// Send stores message and writes it as the next event of the stream. Once
+// the stream is closed or its request is done, a stored message is kept for
+// replay only; without an event store, ErrEventStreamClosed is returned.
+func (s *EventStream) Send(ctx context.Context, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	var eventID string
+	if s.store != nil {
+		if eventID, err = s.store.StoreEvent(ctx, s.ID(), data); err != nil {
+			return fmt.Errorf("failed to store event: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	closed := s.closed || s.ctx.Err() != nil
+	s.mu.Unlock()
+	if closed {
+		if s.store != nil {
+			return nil
+		}
+		return ErrEventStreamClosed
+	}
+	return s.write(eventID, data)
+}
+
+// Close ends the stream on this response. The client reconnects with the
+// ID of the last event it received and is sent the events stored since.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// write writes a single event and flushes it to the client.
+func (s *EventStream) write(eventID string, data json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrEventStreamClosed
+	}
+
+	s.start()
+	if eventID != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", eventID); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// start writes the response header once.
+// Must be called with mu held.
+func (s *EventStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	s.w.WriteHeader(http.StatusOK)
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
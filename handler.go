@@ -2,9 +2,11 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 "github.com/tinywasm/sse"
@@ -16,6 +18,8 @@ type Config struct {
 	ServerName    string // MCP server name
 	ServerVersion string // MCP server version
 	AppName       string // Application name (used to generate MCP server ID)
+	IDEDryRun     bool   // Report IDE config changes (IDEConfigReport) without writing them
+	SocketPath    string // Also serve MCP on this Unix socket, shared by local tools (optional)
 }
 
 // TuiInterface defines what the MCP handler needs from the TUI
@@ -30,7 +34,8 @@ type Handler struct {
 	tui          TuiInterface
 	exitChan     chan bool
 	log          func(messages ...any) // Private logger, set via SetLog
-	ideStatus    string                // Summary of IDE configuration
+	ideReport    []IDEConfigStatus     // Per-file outcome of the last IDE configuration
+	projectPath  string                // Path of the last started project
 
 	// Callbacks
 	restartFunc func(context.Context, string) error
@@ -41,9 +46,10 @@ type Handler struct {
 	projectCancel context.CancelFunc
 	projectDone   chan struct{}
 
-	httpServer any // *http.Server or compatible
-	mu         sync.Mutex
-	running    bool
+	httpServer   any // *http.Server or compatible
+	socketServer *UnixSocketServer
+	mu           sync.Mutex
+	running      bool
 }
 
 // NewHandler creates a new MCP handler with minimal dependencies
@@ -172,11 +178,21 @@ func (h *Handler) Serve() {
 		Addr:    ":" + h.config.Port,
 		Handler: mux,
 	}
-	ideMsg := h.ideStatus
+	if h.config.SocketPath != "" {
+		h.socketServer = NewUnixSocketServer(s)
+	}
+	socketServer := h.socketServer
+	ideMsg := ""
+	if h.ideReport != nil {
+		ideMsg = ideReportSummary(h.ideReport, len(RegisteredIDEs()))
+	}
 	h.mu.Unlock()
 
 	// Consolidate startup messages into ONE log
 	startupMsg := fmt.Sprintf("Started on :%s/mcp", h.config.Port)
+	if socketServer != nil {
+		startupMsg = fmt.Sprintf("%s and %s", startupMsg, h.config.SocketPath)
+	}
 	if ideMsg != "" {
 		startupMsg = fmt.Sprintf("%s (%s)", startupMsg, ideMsg)
 	}
@@ -187,6 +203,13 @@ func (h *Handler) Serve() {
 			h.log("MCP HTTP server stopped:", err)
 		}
 	}()
+	if socketServer != nil {
+		go func() {
+			if err := socketServer.ListenAndServe(h.config.SocketPath); err != nil && !errors.Is(err, ErrUnixSocketServerClosed) {
+				h.log("MCP socket server stopped: " + err.Error())
+			}
+		}()
+	}
 
 	// Wait for exit signal (value or close)
 	<-h.exitChan
@@ -213,6 +236,12 @@ func (h *Handler) Stop() error {
 			h.log("Error shutting down MCP server:", err)
 		}
 	}
+	if h.socketServer != nil {
+		if err := h.socketServer.Shutdown(ctx); err != nil {
+			h.log("Error shutting down MCP socket server: " + err.Error())
+		}
+		h.socketServer = nil
+	}
 
 	h.running = false
 	h.httpServer = nil
@@ -227,9 +256,15 @@ func (h *Handler) Stop() error {
 
 // StartProject starts the project at the given path, managing lifecycle
 func (h *Handler) StartProject(path string) error {
+	if updated := h.configureProjectIDEs(path); len(updated) > 0 {
+		h.log("Project IDE configs updated: " + strings.Join(updated, ", "))
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	h.projectPath = path
+
 	// 1. Cancel previous project
 	if h.projectCancel != nil {
 		h.projectCancel()
@@ -1,20 +1,24 @@
 package mcp
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 )
 
-// IDEInfo represents a supported IDE and its MCP configuration format
+// IDEInfo represents a supported IDE and its MCP configuration format.
+// Register additional IDEs with RegisterIDE.
 type IDEInfo struct {
 	ID             string
 	Name           string
-	GetConfigDir   func() (string, error)
+	GetConfigDir   func() (string, error) // nil for IDEs with project-scoped configs only
 	ConfigFileName string
 
 	// IDE-specific JSON format configuration
@@ -23,91 +27,176 @@ type IDEInfo struct {
 	ExtraFields  map[string]any // Additional fields like "type", "autoStart"
 	HasInputs    bool           // VS Code has "inputs" array, Antigravity doesn't
 	SkipProfiles bool           // true = single config file, no profile scanning
+
+	// ProjectConfigPath is the project-scoped config file relative to the
+	// project root (e.g. ".vscode/mcp.json"), empty if the IDE has none.
+	// It is only written when the IDE is in use in the project.
+	ProjectConfigPath string
 }
 
-// ConfigureIDEs automatically configures supported IDEs with this MCP server
+// ConfigureIDEs automatically configures the registered IDEs with this MCP
+// server. Once a project has been started, the project-scoped configs of the
+// IDEs used in it are configured too. The outcome for each config file is
+// reported by IDEConfigReport; with Config.IDEDryRun nothing is written.
 func (h *Handler) ConfigureIDEs() {
-	ides := []IDEInfo{
-		{
-			ID:             "vsc",
-			Name:           "Visual Studio Code",
-			GetConfigDir:   getVSCodeConfigPath,
-			ConfigFileName: "json",
-			ServersKey:     "servers",
-			URLKey:         "url",
-			ExtraFields:    map[string]any{"type": "http", "autoStart": true},
-			HasInputs:      true,
-		},
-		{
-			ID:             "antigravity",
-			Name:           "Antigravity",
-			GetConfigDir:   getAntigravityConfigPath,
-			ConfigFileName: "mcp_config.json",
-			ServersKey:     "mcpServers",
-			URLKey:         "serverUrl",
-			ExtraFields:    nil,
-			HasInputs:      false,
-		},
-		{
-			ID:             "claude-code",
-			Name:           "Claude Code",
-			GetConfigDir:   getClaudeCodeConfigPath,
-			ConfigFileName: ".claude.json",
-			ServersKey:     "mcpServers",
-			URLKey:         "url",
-			ExtraFields:    map[string]any{"type": "http"},
-			HasInputs:      false,
-			SkipProfiles:   true,
-		},
-	}
-
-	updatedIDEs := []string{}
+	h.mu.Lock()
+	projectPath := h.projectPath
+	h.mu.Unlock()
+
+	report := []IDEConfigStatus{}
+	for _, ide := range RegisteredIDEs() {
+		for _, file := range ideConfigFiles(ide, projectPath, !h.config.IDEDryRun) {
+			report = append(report, h.configureIDEFile(ide, file))
+		}
+	}
+	h.recordIDEReport(report, false)
+}
 
+// UnconfigureIDEs removes this MCP server from the IDE config files, matching
+// entries by server ID and by URL. Besides the current configs of the
+// registered IDEs, every file configured before (including in other
+// projects) is cleaned up. With Config.IDEDryRun nothing is written.
+func (h *Handler) UnconfigureIDEs() {
+	h.mu.Lock()
+	projectPath := h.projectPath
+	h.mu.Unlock()
+
+	ides := RegisteredIDEs()
+	seen := make(map[string]bool)
+	report := []IDEConfigStatus{}
 	for _, ide := range ides {
-		basePath, err := ide.GetConfigDir()
-		if err != nil {
-			// Silently skip if we can't get the config dir (e.g., unsupported OS)
+		for _, file := range ideConfigFiles(ide, projectPath, false) {
+			if !seen[file.path] {
+				seen[file.path] = true
+				report = append(report, h.unconfigureIDEFile(ide, file))
+			}
+		}
+	}
+
+	for _, entry := range loadIDEManifest(h.config.AppName) {
+		if seen[entry.Path] {
 			continue
 		}
+		seen[entry.Path] = true
 
-		var configPaths []string
-		if ide.SkipProfiles {
-			configPaths = []string{filepath.Join(basePath, ide.ConfigFileName)}
-		} else {
-			// Create the directory if it doesn't exist
-			if _, err := os.Stat(basePath); os.IsNotExist(err) {
-				if err := os.MkdirAll(basePath, 0755); err != nil {
-					continue
-				}
+		ide := IDEInfo{ID: entry.IDE, Name: entry.Name, ServersKey: entry.ServersKey, URLKey: entry.URLKey}
+		for _, registered := range ides {
+			if registered.ID == entry.IDE && registered.ServersKey == entry.ServersKey {
+				ide = registered
 			}
+		}
+		report = append(report, h.unconfigureIDEFile(ide, ideConfigFile{path: entry.Path, profile: entry.Profile}))
+	}
+	h.recordIDEReport(report, false)
+}
 
-			configPaths, err = findMCPConfigPaths(basePath, ide.ConfigFileName)
-			if err != nil {
-				continue
-			}
+// configureProjectIDEs configures the project-scoped configs of the IDEs used
+// in the project and returns the names of the IDEs whose config was updated.
+func (h *Handler) configureProjectIDEs(projectPath string) []string {
+	report := []IDEConfigStatus{}
+	for _, ide := range RegisteredIDEs() {
+		if configPath, ok := projectConfigPath(ide, projectPath); ok {
+			report = append(report, h.configureIDEFile(ide, ideConfigFile{path: configPath, profile: ideProjectProfile}))
 		}
+	}
+	h.recordIDEReport(report, true)
+	return updatedIDENames(report)
+}
 
-		ideUpdated := false
-		for _, configPath := range configPaths {
-			updated, err := writeMCPConfig(configPath, h.config.AppName, h.config.Port, ide)
-			if err == nil && updated {
-				ideUpdated = true
-			}
+// configureIDEFile adds or updates our server entry in one config file.
+func (h *Handler) configureIDEFile(ide IDEInfo, file ideConfigFile) IDEConfigStatus {
+	status := newIDEConfigStatus(ide, file)
+	if err := validateAppName(h.config.AppName); err != nil {
+		status.State, status.Err = IDEConfigError, err
+		return status
+	}
+	serverID := strings.ToLower(h.config.AppName)
+	expectedURL := fmt.Sprintf("http://localhost:%s/mcp", h.config.Port)
+
+	return updateIDEConfig(status, h.config.IDEDryRun, IDEConfigConfigured, IDEConfigUpToDate,
+		func(data []byte, root int, rawConfig map[string]any) ([]byte, []IDEConfigChange, error) {
+			return configureMCPEntry(data, root, rawConfig, serverID, expectedURL, ide)
+		})
+}
+
+// unconfigureIDEFile removes our server entry from one config file.
+func (h *Handler) unconfigureIDEFile(ide IDEInfo, file ideConfigFile) IDEConfigStatus {
+	status := newIDEConfigStatus(ide, file)
+	if err := validateAppName(h.config.AppName); err != nil {
+		status.State, status.Err = IDEConfigError, err
+		return status
+	}
+	serverID := strings.ToLower(h.config.AppName)
+	expectedURL := fmt.Sprintf("http://localhost:%s/mcp", h.config.Port)
+
+	return updateIDEConfig(status, h.config.IDEDryRun, IDEConfigRemoved, IDEConfigNotConfigured,
+		func(data []byte, root int, rawConfig map[string]any) ([]byte, []IDEConfigChange, error) {
+			return unconfigureMCPEntry(data, root, rawConfig, serverID, expectedURL, ide)
+		})
+}
+
+func newIDEConfigStatus(ide IDEInfo, file ideConfigFile) IDEConfigStatus {
+	return IDEConfigStatus{IDE: ide.ID, Name: ide.Name, Path: file.path, Profile: file.profile, ide: ide}
+}
+
+// ideConfigFiles resolves the config files of an IDE: its user-level files,
+// one per profile unless the IDE has a single config file, and its
+// project-scoped file if the IDE is used in the project. The IDE config
+// directory is created if needed and create is set.
+func ideConfigFiles(ide IDEInfo, projectPath string, create bool) []ideConfigFile {
+	var files []ideConfigFile
+	for _, configPath := range ideConfigPaths(ide, create) {
+		files = append(files, ideConfigFile{path: configPath, profile: ideProfile(configPath)})
+	}
+	if configPath, ok := projectConfigPath(ide, projectPath); ok {
+		files = append(files, ideConfigFile{path: configPath, profile: ideProjectProfile})
+	}
+	return files
+}
+
+// ideConfigPaths resolves the user-level config files of an IDE, one per
+// profile unless the IDE has a single config file.
+func ideConfigPaths(ide IDEInfo, create bool) []string {
+	if ide.GetConfigDir == nil {
+		return nil
+	}
+	basePath, err := ide.GetConfigDir()
+	if err != nil {
+		// Silently skip if we can't get the config dir (e.g., unsupported OS)
+		return nil
+	}
+
+	if ide.SkipProfiles {
+		configPath := filepath.Join(basePath, ide.ConfigFileName)
+		// Skip IDEs that are not installed
+		if _, err := os.Stat(filepath.Dir(configPath)); err != nil {
+			return nil
 		}
-		if ideUpdated {
-			updatedIDEs = append(updatedIDEs, ide.Name)
+		return []string{configPath}
+	}
+
+	// Create the directory if it doesn't exist
+	if _, err := os.Stat(basePath); os.IsNotExist(err) && create {
+		if err := os.MkdirAll(basePath, 0755); err != nil {
+			return nil
 		}
 	}
 
-	totalIDEs := len(ides)
-	status := fmt.Sprintf("%d of %d IDEs updated", len(updatedIDEs), totalIDEs)
-	if len(updatedIDEs) > 0 {
-		status = fmt.Sprintf("%s: %s", status, strings.Join(updatedIDEs, ", "))
+	configPaths, err := findMCPConfigPaths(basePath, ide.ConfigFileName)
+	if err != nil {
+		return nil
 	}
+	return configPaths
+}
 
-	h.mu.Lock()
-	h.ideStatus = status
-	h.mu.Unlock()
+// ideProfile returns the profile name of a config file in an IDE "profiles"
+// directory, or an empty string for the default config.
+func ideProfile(configPath string) string {
+	profileDir := filepath.Dir(configPath)
+	if filepath.Base(filepath.Dir(profileDir)) == "profiles" {
+		return filepath.Base(profileDir)
+	}
+	return ""
 }
 
 // getVSCodeConfigPath returns the platform-specific VS Code User directory path.
@@ -203,63 +292,105 @@ func needsUpdate(existingEntry map[string]any, newEntry map[string]any, ide IDEI
 	return false
 }
 
-// writeMCPConfig is the unified config writer for all IDEs.
-// It reads existing config, preserves all servers, and adds/updates our entry only if needed.
-func writeMCPConfig(configPath string, appName string, mcpPort string, ide IDEInfo) (bool, error) {
-	// Validate appName first
-	if err := validateAppName(appName); err != nil {
-		return false, err
+// ideConfigEdit computes the edited text of a config file along with the
+// changes made, none if the file is already as wanted. rawConfig is the
+// parsed document and root the index of its opening brace in data.
+type ideConfigEdit func(data []byte, root int, rawConfig map[string]any) ([]byte, []IDEConfigChange, error)
+
+// updateIDEConfig is the unified config writer for all IDEs. The edit only
+// touches our server entry, preserving other servers, comments, formatting
+// and key order, and the file is written only if needed. Files that cannot
+// be parsed are never rewritten. Before the first modification of a file, a
+// timestamped backup of it is made. In dry-run mode the status reports the
+// changes without writing them.
+func updateIDEConfig(status IDEConfigStatus, dryRun bool, changed, unchanged IDEConfigState, edit ideConfigEdit) IDEConfigStatus {
+	data, err := os.ReadFile(status.Path)
+	if err != nil && !os.IsNotExist(err) {
+		status.State, status.Err = ideConfigErrorState(err), err
+		return status
+	}
+	existed := len(bytes.TrimSpace(data)) > 0
+	if !existed {
+		data = []byte("{}")
 	}
 
-	// Read existing config as raw JSON to preserve all fields
+	// Parse leniently (comments, trailing commas) to inspect the config
 	var rawConfig map[string]any
+	if err := json.Unmarshal(stripJSONC(data), &rawConfig); err != nil {
+		status.State, status.Err = IDEConfigParseError, fmt.Errorf("%w: %s: %v", ErrInvalidIDEConfig, status.Path, err)
+		return status
+	}
+	root, err := jsoncRoot(data)
+	if err != nil || rawConfig == nil {
+		status.State, status.Err = IDEConfigParseError, fmt.Errorf("%w: %s: root is not an object", ErrInvalidIDEConfig, status.Path)
+		return status
+	}
 
-	data, err := os.ReadFile(configPath)
+	updated, changes, err := edit(data, root, rawConfig)
 	if err != nil {
-		if os.IsNotExist(err) {
-			rawConfig = make(map[string]any)
-		} else if os.IsPermission(err) {
-			return false, nil // Silent failure
-		} else {
-			return false, err
-		}
-	} else {
-		if err := json.Unmarshal(data, &rawConfig); err != nil {
-			rawConfig = make(map[string]any)
-		}
+		status.State, status.Err = IDEConfigParseError, fmt.Errorf("%w: %s: %v", ErrInvalidIDEConfig, status.Path, err)
+		return status
+	}
+	if len(changes) == 0 {
+		status.State = unchanged
+		return status
+	}
+
+	status.State, status.Changes = changed, changes
+	before := ""
+	if existed {
+		before = string(data)
+	}
+	status.Diff = unifiedDiff(status.Path, before, string(updated))
+	if dryRun {
+		return status
 	}
 
-	// Get or create the servers map (e.g., "servers" or "mcpServers")
-	serversRaw, exists := rawConfig[ide.ServersKey]
-	var servers map[string]any
-	if exists {
-		servers, _ = serversRaw.(map[string]any)
+	if existed {
+		err = backupIDEConfig(status.Path, data)
 	}
-	if servers == nil {
-		servers = make(map[string]any)
+	if err == nil {
+		err = writeFileAtomic(status.Path, updated)
+	}
+	if err != nil {
+		status.State, status.Err = ideConfigErrorState(err), err
+		return status
+	}
+	status.Written = true
+	return status
+}
+
+func ideConfigErrorState(err error) IDEConfigState {
+	if os.IsPermission(err) {
+		return IDEConfigPermissionDenied
 	}
+	return IDEConfigError
+}
 
-	// Cleanup duplicate URL entries (e.g., old "tinywasm-mcp" and new "tinywasm" with same URL)
-	expectedURL := fmt.Sprintf("http://localhost:%s/mcp", mcpPort)
-	serverID := strings.ToLower(appName)
+// configureMCPEntry adds or updates our server entry, removing other entries
+// with our URL (e.g., old "tinywasm-mcp" and new "tinywasm" with same URL).
+func configureMCPEntry(data []byte, root int, rawConfig map[string]any, serverID, expectedURL string, ide IDEInfo) ([]byte, []IDEConfigChange, error) {
+	// Get the servers map (e.g., "servers" or "mcpServers")
+	serversRaw, hasServers := rawConfig[ide.ServersKey]
+	servers, isObject := serversRaw.(map[string]any)
+	if hasServers && !isObject {
+		return nil, nil, fmt.Errorf("%q is not an object", ide.ServersKey)
+	}
 
-	// Find all entries with our URL
-	duplicatesRemoved := false
-	for key, entry := range servers {
-		if serverEntry, ok := entry.(map[string]any); ok {
-			if url, _ := serverEntry[ide.URLKey].(string); url == expectedURL {
-				// Remove any entry with our URL that is not our serverID
-				if key != serverID {
-					delete(servers, key)
-					duplicatesRemoved = true
-				}
+	var changes []IDEConfigChange
+	var duplicates []string
+	for _, key := range sortedKeys(servers) {
+		if serverEntry, ok := servers[key].(map[string]any); ok {
+			if url, _ := serverEntry[ide.URLKey].(string); url == expectedURL && key != serverID {
+				duplicates = append(duplicates, key)
+				changes = append(changes, IDEConfigChange{Op: IDEConfigRemove, Key: ide.ServersKey + "." + key, Before: serverEntry})
 			}
 		}
 	}
 
 	// Build our server entry
 	serverEntry := map[string]any{
-		ide.URLKey: fmt.Sprintf("http://localhost:%s/mcp", mcpPort),
+		ide.URLKey: expectedURL,
 	}
 
 	// Add extra fields (e.g., "type": "http", "autoStart": true)
@@ -267,41 +398,157 @@ func writeMCPConfig(configPath string, appName string, mcpPort string, ide IDEIn
 		serverEntry[k] = v
 	}
 
-	// Check if entry already exists and is identical (skip if duplicates were cleaned)
-	if !duplicatesRemoved {
-		if existingEntry, hasEntry := servers[serverID]; hasEntry {
-			if existing, ok := existingEntry.(map[string]any); ok {
-				if !needsUpdate(existing, serverEntry, ide) {
-					// Config is identical, no need to write
-					return false, nil
-				}
-			}
+	// Check if entry already exists and is identical
+	entryKey := ide.ServersKey + "." + serverID
+	if existingEntry, hasEntry := servers[serverID]; !hasEntry {
+		changes = append(changes, IDEConfigChange{Op: IDEConfigAdd, Key: entryKey, After: serverEntry})
+	} else if existing, ok := existingEntry.(map[string]any); !ok || needsUpdate(existing, serverEntry, ide) {
+		changes = append(changes, IDEConfigChange{Op: IDEConfigUpdate, Key: entryKey, Before: existingEntry, After: serverEntry})
+	} else {
+		serverEntry = nil // Up to date
+	}
+
+	// Ensure inputs array exists for IDEs that need it
+	_, hasInputs := rawConfig["inputs"]
+	addInputs := ide.HasInputs && !hasInputs
+	if addInputs {
+		changes = append(changes, IDEConfigChange{Op: IDEConfigAdd, Key: "inputs", After: []any{}})
+	}
+
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+	updated, err := editMCPConfig(data, root, hasServers, duplicates, serverID, serverEntry, addInputs, ide)
+	return updated, changes, err
+}
+
+// unconfigureMCPEntry removes our server entry and any other entry with our URL.
+func unconfigureMCPEntry(data []byte, root int, rawConfig map[string]any, serverID, expectedURL string, ide IDEInfo) ([]byte, []IDEConfigChange, error) {
+	servers, _ := rawConfig[ide.ServersKey].(map[string]any)
+
+	var changes []IDEConfigChange
+	var removed []string
+	for _, key := range sortedKeys(servers) {
+		serverEntry, _ := servers[key].(map[string]any)
+		if url, _ := serverEntry[ide.URLKey].(string); key == serverID || url == expectedURL {
+			removed = append(removed, key)
+			changes = append(changes, IDEConfigChange{Op: IDEConfigRemove, Key: ide.ServersKey + "." + key, Before: servers[key]})
 		}
 	}
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
 
-	// Add/update our server entry
-	servers[serverID] = serverEntry
-	rawConfig[ide.ServersKey] = servers
+	member, _, err := jsoncLookup(data, root, ide.ServersKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range removed {
+		if data, err = jsoncDeleteMember(data, member.valueStart, key); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, changes, nil
+}
 
-	// Ensure inputs array exists for IDEs that need it
-	if ide.HasInputs {
-		if _, hasInputs := rawConfig["inputs"]; !hasInputs {
-			rawConfig["inputs"] = []any{}
+// editMCPConfig applies our changes to the config text: the servers object
+// is created if missing, duplicates are removed, our entry is set unless nil
+// and the inputs array is added for IDEs that need it.
+func editMCPConfig(data []byte, root int, hasServers bool, duplicates []string, serverID string, serverEntry map[string]any, addInputs bool, ide IDEInfo) ([]byte, error) {
+	var err error
+	if !hasServers {
+		if data, err = jsoncSetMember(data, root, ide.ServersKey, map[string]any{}); err != nil {
+			return nil, err
 		}
 	}
 
-	// Marshal with tabs
-	updatedData, err := json.MarshalIndent(rawConfig, "", "\t")
+	member, _, err := jsoncLookup(data, root, ide.ServersKey)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	for _, key := range duplicates {
+		if data, err = jsoncDeleteMember(data, member.valueStart, key); err != nil {
+			return nil, err
+		}
+	}
+	if serverEntry != nil {
+		if data, err = jsoncSetMember(data, member.valueStart, serverID, serverEntry); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := os.WriteFile(configPath, updatedData, 0644); err != nil {
-		if os.IsPermission(err) {
-			return false, nil
+	// Ensure inputs array exists for IDEs that need it
+	if addInputs {
+		if data, err = jsoncSetMember(data, root, "inputs", []any{}); err != nil {
+			return nil, err
 		}
-		return false, err
 	}
+	return data, nil
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	return true, nil
+// backupIDEConfig saves a timestamped copy of a config file next to it
+// (e.g. mcp.json.bak-20060102-150405) unless one was already made, so the
+// original user file can always be recovered.
+func backupIDEConfig(configPath string, data []byte) error {
+	dir, base := filepath.Split(configPath)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), base+".bak-") {
+			return nil
+		}
+	}
+	backupPath := fmt.Sprintf("%s.bak-%s", configPath, time.Now().Format("20060102-150405"))
+	return writeFileAtomic(backupPath, data)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never observe a partially written file.
+// The permissions of an existing file are kept and symlinks are followed.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
 }
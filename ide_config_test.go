@@ -0,0 +1,312 @@
+package mcp_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// setupIDEHome points the home directory to a temporary one and returns it.
+func setupIDEHome(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("home directory override relies on $HOME")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	return home
+}
+
+func backups(t *testing.T, configPath string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(configPath + ".bak-*")
+	require.NoError(t, err)
+	return matches
+}
+
+func TestConfigureIDEs_PreservesJSONC(t *testing.T) {
+	home := setupIDEHome(t)
+	configPath := filepath.Join(home, ".claude.json")
+	original := `{
+  // Managed by hand, keep this comment
+  "theme": "dark",
+  "mcpServers": {
+    /* production server */
+    "remote": {"type": "http", "url": "https://example.com/mcp"},
+    "old-name": {"type": "http", "url": "http://localhost:3030/mcp"}, // stale entry
+  },
+}
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0o600))
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	updated := string(data)
+	assert.Contains(t, updated, "// Managed by hand, keep this comment")
+	assert.Contains(t, updated, "/* production server */")
+	assert.Contains(t, updated, `"remote": {"type": "http", "url": "https://example.com/mcp"}`)
+	assert.NotContains(t, updated, "old-name")
+	assert.True(t, strings.Index(updated, `"theme"`) < strings.Index(updated, `"mcpServers"`))
+
+	servers, err := mcp.ParseServerConfigs(data)
+	require.NoError(t, err)
+	require.Len(t, servers, 2)
+	assert.Equal(t, "myapp", servers[0].Name)
+	assert.Equal(t, "http://localhost:3030/mcp", servers[0].URL)
+	assert.Equal(t, "remote", servers[1].Name)
+
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// The original document is backed up once
+	saved := backups(t, configPath)
+	require.Len(t, saved, 1)
+	backup, err := os.ReadFile(saved[0])
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup))
+
+	// Up-to-date config is neither rewritten nor backed up again
+	handler.ConfigureIDEs()
+	again, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, updated, string(again))
+	assert.Len(t, backups(t, configPath), 1)
+}
+
+func TestConfigureIDEs_KeepsUnparsableConfig(t *testing.T) {
+	home := setupIDEHome(t)
+	configPath := filepath.Join(home, ".claude.json")
+	broken := `{"mcpServers": {"remote": {"url": "https://example.com/mcp"}` // Truncated
+	require.NoError(t, os.WriteFile(configPath, []byte(broken), 0o644))
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, broken, string(data))
+	assert.Empty(t, backups(t, configPath))
+}
+
+func TestConfigureIDEs_CreatesConfig(t *testing.T) {
+	home := setupIDEHome(t)
+	configPath := filepath.Join(home, ".claude.json")
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mcpServers": {"myapp": {"type": "http", "url": "http://localhost:3030/mcp"}}}`, string(data))
+	assert.Empty(t, backups(t, configPath))
+}
+
+func TestRegisteredIDEs(t *testing.T) {
+	var ids []string
+	for _, ide := range mcp.RegisteredIDEs() {
+		ids = append(ids, ide.ID)
+	}
+	for _, id := range []string{"vsc", "antigravity", "claude-code", "cursor", "windsurf", "zed", "continue"} {
+		assert.Contains(t, ids, id)
+	}
+}
+
+func TestConfigureIDEs_CustomIDE(t *testing.T) {
+	setupIDEHome(t)
+	configDir := t.TempDir()
+
+	mcp.RegisterIDE(mcp.IDEInfo{
+		ID:             "acme",
+		Name:           "Acme Editor",
+		GetConfigDir:   func() (string, error) { return configDir, nil },
+		ConfigFileName: "tools.json",
+		ServersKey:     "toolServers",
+		URLKey:         "endpoint",
+		ExtraFields:    map[string]any{"transport": "http"},
+		SkipProfiles:   true,
+	})
+	defer mcp.UnregisterIDE("acme")
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	data, err := os.ReadFile(filepath.Join(configDir, "tools.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"toolServers": {"myapp": {"endpoint": "http://localhost:3030/mcp", "transport": "http"}}}`, string(data))
+
+	mcp.UnregisterIDE("acme")
+	for _, ide := range mcp.RegisteredIDEs() {
+		assert.NotEqual(t, "acme", ide.ID)
+	}
+}
+
+func TestStartProject_ConfiguresProjectIDEs(t *testing.T) {
+	setupIDEHome(t)
+	project := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(project, ".vscode"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(project, ".cursor"), 0o755))
+	cursorConfig := filepath.Join(project, ".cursor", "mcp.json")
+	require.NoError(t, os.WriteFile(cursorConfig, []byte(`{"mcpServers": {"db": {"command": "db-server"}}}`), 0o644))
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	require.NoError(t, handler.StartProject(project))
+
+	vscode, err := os.ReadFile(filepath.Join(project, ".vscode", "mcp.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"servers": {"myapp": {"type": "http", "url": "http://localhost:3030/mcp", "autoStart": true}},
+		"inputs": []
+	}`, string(vscode))
+
+	cursor, err := os.ReadFile(cursorConfig)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mcpServers": {
+		"db": {"command": "db-server"},
+		"myapp": {"url": "http://localhost:3030/mcp"}
+	}}`, string(cursor))
+
+	// IDEs not used in the project get no project config
+	_, err = os.Stat(filepath.Join(project, ".mcp.json"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(project, ".zed"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// ideStatus returns the report entry for configPath.
+func ideStatus(t *testing.T, handler *mcp.Handler, configPath string) mcp.IDEConfigStatus {
+	t.Helper()
+	for _, status := range handler.IDEConfigReport() {
+		if status.Path == configPath {
+			return status
+		}
+	}
+	t.Fatalf("no status reported for %s", configPath)
+	return mcp.IDEConfigStatus{}
+}
+
+func TestConfigureIDEs_DryRun(t *testing.T) {
+	home := setupIDEHome(t)
+	configPath := filepath.Join(home, ".claude.json")
+	original := "{\n  \"theme\": \"dark\"\n}\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0o644))
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030", IDEDryRun: true}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+	assert.Empty(t, backups(t, configPath))
+
+	status := ideStatus(t, handler, configPath)
+	assert.Equal(t, "claude-code", status.IDE)
+	assert.Equal(t, mcp.IDEConfigConfigured, status.State)
+	assert.False(t, status.Written)
+	require.Len(t, status.Changes, 1)
+	assert.Equal(t, mcp.IDEConfigAdd, status.Changes[0].Op)
+	assert.Equal(t, "mcpServers.myapp", status.Changes[0].Key)
+	assert.Contains(t, status.Diff, "--- "+configPath)
+	assert.Contains(t, status.Diff, `-  "theme": "dark"`)
+	assert.Contains(t, status.Diff, `+  "theme": "dark",`)
+	assert.Contains(t, status.Diff, `+      "url": "http://localhost:3030/mcp"`)
+}
+
+func TestConfigureIDEs_Report(t *testing.T) {
+	home := setupIDEHome(t)
+	claudeConfig := filepath.Join(home, ".claude.json")
+	require.NoError(t, os.WriteFile(claudeConfig, []byte(`{"mcpServers": `), 0o644))
+	cursorDir := filepath.Join(home, ".cursor")
+	require.NoError(t, os.Mkdir(cursorDir, 0o755))
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	status := ideStatus(t, handler, claudeConfig)
+	assert.Equal(t, mcp.IDEConfigParseError, status.State)
+	assert.ErrorIs(t, status.Err, mcp.ErrInvalidIDEConfig)
+
+	status = ideStatus(t, handler, filepath.Join(cursorDir, "mcp.json"))
+	assert.Equal(t, mcp.IDEConfigConfigured, status.State)
+	assert.True(t, status.Written)
+
+	handler.ConfigureIDEs()
+	status = ideStatus(t, handler, filepath.Join(cursorDir, "mcp.json"))
+	assert.Equal(t, mcp.IDEConfigUpToDate, status.State)
+	assert.False(t, status.Written)
+	assert.Empty(t, status.Diff)
+}
+
+func TestConfigureIDEs_PermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+	home := setupIDEHome(t)
+	configPath := filepath.Join(home, ".claude.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{}`), 0o000))
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+
+	assert.Equal(t, mcp.IDEConfigPermissionDenied, ideStatus(t, handler, configPath).State)
+}
+
+func TestUnconfigureIDEs(t *testing.T) {
+	home := setupIDEHome(t)
+	claudeConfig := filepath.Join(home, ".claude.json")
+	require.NoError(t, os.WriteFile(claudeConfig, []byte(`{
+  "mcpServers": {
+    "remote": {"type": "http", "url": "https://example.com/mcp"}
+  }
+}`), 0o644))
+	project := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(project, ".cursor"), 0o755))
+	projectConfig := filepath.Join(project, ".cursor", "mcp.json")
+
+	handler := mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.ConfigureIDEs()
+	require.NoError(t, handler.StartProject(project))
+	assert.Equal(t, mcp.IDEConfigConfigured, ideStatus(t, handler, projectConfig).State)
+
+	// An entry with our URL added by hand is matched too
+	require.NoError(t, os.WriteFile(claudeConfig, []byte(`{"mcpServers": {
+		"remote": {"type": "http", "url": "https://example.com/mcp"},
+		"myapp": {"type": "http", "url": "http://localhost:3030/mcp"},
+		"by-hand": {"url": "http://localhost:3030/mcp"}
+	}}`), 0o644))
+
+	// Simulate a later run in which the project is not open
+	handler = mcp.NewHandler(mcp.Config{AppName: "MyApp", Port: "3030"}, nil, nil, nil)
+	handler.UnconfigureIDEs()
+
+	status := ideStatus(t, handler, claudeConfig)
+	assert.Equal(t, mcp.IDEConfigRemoved, status.State)
+	assert.True(t, status.Written)
+	assert.Len(t, status.Changes, 2)
+	data, err := os.ReadFile(claudeConfig)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mcpServers": {"remote": {"type": "http", "url": "https://example.com/mcp"}}}`, string(data))
+
+	status = ideStatus(t, handler, projectConfig)
+	assert.Equal(t, mcp.IDEConfigRemoved, status.State)
+	data, err = os.ReadFile(projectConfig)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mcpServers": {}}`, string(data))
+
+	// Nothing is left to remove
+	handler.UnconfigureIDEs()
+	assert.Equal(t, mcp.IDEConfigNotConfigured, ideStatus(t, handler, claudeConfig).State)
+	for _, status := range handler.IDEConfigReport() {
+		assert.NotEqual(t, projectConfig, status.Path)
+	}
+}
@@ -0,0 +1,174 @@
+package mcp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+var (
+	ideRegistryMu sync.RWMutex
+	ideRegistry   = builtinIDEs()
+)
+
+// builtinIDEs returns the IDEs supported out of the box.
+func builtinIDEs() []IDEInfo {
+	return []IDEInfo{
+		{
+			ID:                "vsc",
+			Name:              "Visual Studio Code",
+			GetConfigDir:      getVSCodeConfigPath,
+			ConfigFileName:    "mcp.json",
+			ServersKey:        "servers",
+			URLKey:            "url",
+			ExtraFields:       map[string]any{"type": "http", "autoStart": true},
+			HasInputs:         true,
+			ProjectConfigPath: filepath.Join(".vscode", "mcp.json"),
+		},
+		{
+			ID:             "antigravity",
+			Name:           "Antigravity",
+			GetConfigDir:   getAntigravityConfigPath,
+			ConfigFileName: "mcp_config.json",
+			ServersKey:     "mcpServers",
+			URLKey:         "serverUrl",
+		},
+		{
+			ID:                "claude-code",
+			Name:              "Claude Code",
+			GetConfigDir:      getClaudeCodeConfigPath,
+			ConfigFileName:    ".claude.json",
+			ServersKey:        "mcpServers",
+			URLKey:            "url",
+			ExtraFields:       map[string]any{"type": "http"},
+			SkipProfiles:      true,
+			ProjectConfigPath: ".mcp.json",
+		},
+		{
+			ID:                "cursor",
+			Name:              "Cursor",
+			GetConfigDir:      homeSubdir(".cursor"),
+			ConfigFileName:    "mcp.json",
+			ServersKey:        "mcpServers",
+			URLKey:            "url",
+			SkipProfiles:      true,
+			ProjectConfigPath: filepath.Join(".cursor", "mcp.json"),
+		},
+		{
+			ID:             "windsurf",
+			Name:           "Windsurf",
+			GetConfigDir:   homeSubdir(".codeium", "windsurf"),
+			ConfigFileName: "mcp_config.json",
+			ServersKey:     "mcpServers",
+			URLKey:         "serverUrl",
+			SkipProfiles:   true,
+		},
+		{
+			ID:                "zed",
+			Name:              "Zed",
+			GetConfigDir:      getZedConfigPath,
+			ConfigFileName:    "settings.json",
+			ServersKey:        "context_servers",
+			URLKey:            "url",
+			SkipProfiles:      true,
+			ProjectConfigPath: filepath.Join(".zed", "settings.json"),
+		},
+		{
+			ID:                "continue",
+			Name:              "Continue",
+			GetConfigDir:      homeSubdir(".continue", "mcpServers"),
+			ConfigFileName:    "mcp.json",
+			ServersKey:        "mcpServers",
+			URLKey:            "url",
+			ExtraFields:       map[string]any{"type": "streamable-http"},
+			SkipProfiles:      true,
+			ProjectConfigPath: filepath.Join(".continue", "mcpServers", "mcp.json"),
+		},
+	}
+}
+
+// RegisterIDE adds an IDE to the registry used by ConfigureIDEs, replacing
+// the registered IDE with the same ID if any.
+func RegisterIDE(ide IDEInfo) {
+	ideRegistryMu.Lock()
+	defer ideRegistryMu.Unlock()
+
+	for i, registered := range ideRegistry {
+		if registered.ID == ide.ID {
+			ideRegistry[i] = ide
+			return
+		}
+	}
+	ideRegistry = append(ideRegistry, ide)
+}
+
+// UnregisterIDE removes the IDE with the given ID from the registry.
+func UnregisterIDE(id string) {
+	ideRegistryMu.Lock()
+	defer ideRegistryMu.Unlock()
+
+	for i, registered := range ideRegistry {
+		if registered.ID == id {
+			ideRegistry = append(ideRegistry[:i:i], ideRegistry[i+1:]...)
+			return
+		}
+	}
+}
+
+// RegisteredIDEs returns the IDEs configured by ConfigureIDEs, in registration order.
+func RegisteredIDEs() []IDEInfo {
+	ideRegistryMu.RLock()
+	defer ideRegistryMu.RUnlock()
+	return append([]IDEInfo(nil), ideRegistry...)
+}
+
+// projectConfigPath returns the project-scoped config file of an IDE, if the
+// IDE has one and is in use in the project: the config file or, unless it
+// lives in the project root, its directory already exists.
+func projectConfigPath(ide IDEInfo, projectPath string) (string, bool) {
+	if ide.ProjectConfigPath == "" || projectPath == "" {
+		return "", false
+	}
+	configPath := filepath.Join(projectPath, ide.ProjectConfigPath)
+	if _, err := os.Stat(configPath); err == nil {
+		return configPath, true
+	}
+	dir := filepath.Dir(configPath)
+	if filepath.Clean(dir) == filepath.Clean(projectPath) {
+		return "", false
+	}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return configPath, true
+	}
+	return "", false
+}
+
+// homeSubdir returns a GetConfigDir func resolving a directory under the home directory.
+func homeSubdir(elem ...string) func() (string, error) {
+	return func() (string, error) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(append([]string{homeDir}, elem...)...), nil
+	}
+}
+
+// getZedConfigPath returns the platform-specific Zed config directory path.
+func getZedConfigPath() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return "", errors.New("APPDATA environment variable not set")
+		}
+		return filepath.Join(appData, "Zed"), nil
+	case "linux":
+		if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+			return filepath.Join(configHome, "zed"), nil
+		}
+	}
+	return homeSubdir(".config", "zed")()
+}
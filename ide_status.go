@@ -0,0 +1,322 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IDEConfigState is the outcome of configuring or unconfiguring one IDE config file.
+type IDEConfigState string
+
+const (
+	IDEConfigConfigured       IDEConfigState = "configured"        // Our server entry was added or updated
+	IDEConfigUpToDate         IDEConfigState = "up-to-date"        // Our server entry was already current
+	IDEConfigRemoved          IDEConfigState = "removed"           // Our server entry was removed
+	IDEConfigNotConfigured    IDEConfigState = "not-configured"    // There was no server entry to remove
+	IDEConfigPermissionDenied IDEConfigState = "permission-denied" // The file could not be read or written
+	IDEConfigParseError       IDEConfigState = "parse-error"       // The file is not valid JSON(C) and was left untouched
+	IDEConfigError            IDEConfigState = "error"             // Any other failure
+)
+
+// ideProjectProfile is the profile reported for project-scoped config files.
+const ideProjectProfile = "project"
+
+// IDEConfigChangeOp is the kind of an IDEConfigChange.
+type IDEConfigChangeOp string
+
+const (
+	IDEConfigAdd    IDEConfigChangeOp = "add"
+	IDEConfigUpdate IDEConfigChangeOp = "update"
+	IDEConfigRemove IDEConfigChangeOp = "remove"
+)
+
+// IDEConfigChange describes one change to a config file.
+type IDEConfigChange struct {
+	Op     IDEConfigChangeOp
+	Key    string // Dotted path of the changed member, e.g. "servers.myapp"
+	Before any    // Previous value, nil for additions
+	After  any    // New value, nil for removals
+}
+
+// IDEConfigStatus reports what happened, or would happen in dry-run mode, to
+// one IDE config file.
+type IDEConfigStatus struct {
+	IDE     string // IDEInfo.ID
+	Name    string // IDEInfo.Name
+	Path    string
+	Profile string // IDE profile name, "project" for project-scoped configs, empty for the default config
+	State   IDEConfigState
+	Changes []IDEConfigChange
+	Diff    string // Unified diff of the file contents, empty if unchanged
+	Written bool   // Whether the file was modified on disk (never in dry-run mode)
+	Err     error
+
+	ide IDEInfo
+}
+
+// ideConfigFile is a config file of an IDE to configure.
+type ideConfigFile struct {
+	path    string
+	profile string
+}
+
+// IDEConfigReport returns the per-file report of the last ConfigureIDEs or
+// UnconfigureIDEs call, including project configs updated by StartProject.
+func (h *Handler) IDEConfigReport() []IDEConfigStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]IDEConfigStatus(nil), h.ideReport...)
+}
+
+// recordIDEReport stores the report, merging it into the current one by path
+// if merge is set, and keeps track of the files holding our server entry.
+func (h *Handler) recordIDEReport(report []IDEConfigStatus, merge bool) {
+	if !h.config.IDEDryRun {
+		if err := updateIDEManifest(h.config.AppName, report); err != nil {
+			h.log("Failed to record IDE config files: " + err.Error())
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !merge || h.ideReport == nil {
+		h.ideReport = report
+		return
+	}
+	for _, status := range report {
+		replaced := false
+		for i := range h.ideReport {
+			if h.ideReport[i].Path == status.Path {
+				h.ideReport[i] = status
+				replaced = true
+			}
+		}
+		if !replaced {
+			h.ideReport = append(h.ideReport, status)
+		}
+	}
+}
+
+// ideReportSummary summarizes a report for the startup log, e.g.
+// "2 of 7 IDEs updated: Visual Studio Code, Cursor".
+func ideReportSummary(report []IDEConfigStatus, totalIDEs int) string {
+	updatedIDEs := updatedIDENames(report)
+	summary := fmt.Sprintf("%d of %d IDEs updated", len(updatedIDEs), totalIDEs)
+	if len(updatedIDEs) > 0 {
+		summary = fmt.Sprintf("%s: %s", summary, strings.Join(updatedIDEs, ", "))
+	}
+
+	failed := 0
+	for _, status := range report {
+		switch status.State {
+		case IDEConfigPermissionDenied, IDEConfigParseError, IDEConfigError:
+			failed++
+		}
+	}
+	if failed > 0 {
+		summary = fmt.Sprintf("%s; %d config files failed", summary, failed)
+	}
+	return summary
+}
+
+// updatedIDENames returns the names of the IDEs with a configured or removed
+// entry in the report, in report order.
+func updatedIDENames(report []IDEConfigStatus) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, status := range report {
+		if (status.State == IDEConfigConfigured || status.State == IDEConfigRemoved) && !seen[status.IDE] {
+			seen[status.IDE] = true
+			names = append(names, status.Name)
+		}
+	}
+	return names
+}
+
+// ideManifestEntry records a config file holding our server entry, so that
+// UnconfigureIDEs can find it again, e.g. in a project opened earlier.
+type ideManifestEntry struct {
+	IDE        string `json:"ide"`
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Profile    string `json:"profile,omitempty"`
+	ServersKey string `json:"serversKey"`
+	URLKey     string `json:"urlKey"`
+}
+
+// ideManifestPath returns where the config files touched for appName are recorded.
+func ideManifestPath(appName string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "tinywasm", "mcp", strings.ToLower(appName)+"-ide-configs.json"), nil
+}
+
+// loadIDEManifest returns the config files recorded for appName.
+func loadIDEManifest(appName string) []ideManifestEntry {
+	manifestPath, err := ideManifestPath(appName)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil
+	}
+	var entries []ideManifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// updateIDEManifest records the files of the report that hold our server
+// entry and forgets those that no longer do.
+func updateIDEManifest(appName string, report []IDEConfigStatus) error {
+	entries := loadIDEManifest(appName)
+	changed := false
+
+	for _, status := range report {
+		index := -1
+		for i, entry := range entries {
+			if entry.Path == status.Path {
+				index = i
+				break
+			}
+		}
+
+		switch status.State {
+		case IDEConfigConfigured, IDEConfigUpToDate:
+			if index < 0 {
+				entries = append(entries, ideManifestEntry{
+					IDE:        status.IDE,
+					Name:       status.Name,
+					Path:       status.Path,
+					Profile:    status.Profile,
+					ServersKey: status.ide.ServersKey,
+					URLKey:     status.ide.URLKey,
+				})
+				changed = true
+			}
+		case IDEConfigRemoved, IDEConfigNotConfigured:
+			if index >= 0 {
+				entries = append(entries[:index], entries[index+1:]...)
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	manifestPath, err := ideManifestPath(appName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(manifestPath, data)
+}
+
+// unifiedDiff returns a unified diff between two versions of a file, as a
+// single hunk spanning the lines that differ.
+func unifiedDiff(path, before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if prefix == len(a) && prefix == len(b) {
+		return ""
+	}
+
+	const context = 3
+	start := max(prefix-context, 0)
+	keep := max(suffix-context, 0)
+	hunkA, hunkB := len(a)-keep-start, len(b)-keep-start
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n@@ -%s +%s @@\n", path, path, hunkRange(start, hunkA), hunkRange(start, hunkB))
+	for _, line := range a[start:prefix] {
+		sb.WriteString(" " + line + "\n")
+	}
+	diffLines(&sb, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, line := range a[len(a)-suffix : len(a)-keep] {
+		sb.WriteString(" " + line + "\n")
+	}
+	return sb.String()
+}
+
+// diffLines writes the line diff of a and b, based on their longest common
+// subsequence. Very large inputs are shown as a full replacement.
+func diffLines(sb *strings.Builder, a, b []string) {
+	if len(a)*len(b) > 1<<20 {
+		for _, line := range a {
+			sb.WriteString("-" + line + "\n")
+		}
+		for _, line := range b {
+			sb.WriteString("+" + line + "\n")
+		}
+		return
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		default:
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		}
+	}
+}
+
+// hunkRange formats a unified diff range of n lines starting at index start.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// splitLines splits text into lines, without line terminators.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -102,6 +102,9 @@ func (s *InProcessSession) RequestSampling(ctx context.Context, request CreateMe
 	if handler == nil {
 		return nil, fmt.Errorf("no sampling handler available")
 	}
+	if err := checkSamplingTools(s.GetClientCapabilities(), request); err != nil {
+		return nil, err
+	}
 
 	return handler.CreateMessage(ctx, request)
 }
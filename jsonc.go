@@ -0,0 +1,396 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// stripJSONC turns JSON with comments (JSONC, as used by VS Code config
+// files) into plain JSON: line and block comments are removed and trailing
+// commas before a closing bracket or brace are dropped. String contents are
+// left untouched.
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	pendingComma := -1 // Position in out of a comma that may be trailing
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+			continue
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++ // Skip the closing slash
+			out = append(out, ' ')
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+			continue
+		case (c == ']' || c == '}') && pendingComma >= 0:
+			out[pendingComma] = ' '
+		}
+
+		pendingComma = -1
+		if c == ',' {
+			pendingComma = len(out)
+		} else if c == '"' {
+			inString = true
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// errJSONCSyntax is returned when a JSONC document cannot be scanned for editing.
+var errJSONCSyntax = errors.New("malformed JSONC document")
+
+// jsoncMember locates one member of a JSONC object in the source text.
+type jsoncMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int // Index after the last byte of the value
+}
+
+// jsoncSkip returns the index of the first byte at or after i that is
+// neither whitespace nor part of a comment.
+func jsoncSkip(data []byte, i int) int {
+	for i < len(data) {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(data)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// jsoncStringEnd returns the index after the closing quote of the string starting at i.
+func jsoncStringEnd(data []byte, i int) (int, error) {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, errJSONCSyntax
+}
+
+// jsoncValueEnd returns the index after the value starting at i.
+func jsoncValueEnd(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, errJSONCSyntax
+	}
+	switch data[i] {
+	case '"':
+		return jsoncStringEnd(data, i)
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(data); {
+			j = jsoncSkip(data, j)
+			if j >= len(data) {
+				break
+			}
+			switch data[j] {
+			case '"':
+				end, err := jsoncStringEnd(data, j)
+				if err != nil {
+					return 0, err
+				}
+				j = end
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, nil
+				}
+			}
+			j++
+		}
+		return 0, errJSONCSyntax
+	default:
+		j := i
+		for j < len(data) && !bytes.ContainsAny(data[j:j+1], " \t\r\n,]}/") {
+			j++
+		}
+		if j == i {
+			return 0, errJSONCSyntax
+		}
+		return j, nil
+	}
+}
+
+// jsoncMembers lists the members of the object whose opening brace is at
+// start, along with the index of its closing brace.
+func jsoncMembers(data []byte, start int) ([]jsoncMember, int, error) {
+	if start >= len(data) || data[start] != '{' {
+		return nil, 0, errJSONCSyntax
+	}
+	var members []jsoncMember
+	i := start + 1
+	for {
+		i = jsoncSkip(data, i)
+		if i >= len(data) {
+			return nil, 0, errJSONCSyntax
+		}
+		switch data[i] {
+		case '}':
+			return members, i, nil
+		case ',':
+			i++
+			continue
+		case '"':
+		default:
+			return nil, 0, errJSONCSyntax
+		}
+
+		keyStart := i
+		keyEnd, err := jsoncStringEnd(data, keyStart)
+		if err != nil {
+			return nil, 0, err
+		}
+		var key string
+		if err := json.Unmarshal(data[keyStart:keyEnd], &key); err != nil {
+			return nil, 0, errJSONCSyntax
+		}
+		i = jsoncSkip(data, keyEnd)
+		if i >= len(data) || data[i] != ':' {
+			return nil, 0, errJSONCSyntax
+		}
+		valueStart := jsoncSkip(data, i+1)
+		valueEnd, err := jsoncValueEnd(data, valueStart)
+		if err != nil {
+			return nil, 0, err
+		}
+		members = append(members, jsoncMember{key: key, keyStart: keyStart, valueStart: valueStart, valueEnd: valueEnd})
+		i = valueEnd
+	}
+}
+
+// jsoncRoot returns the index of the opening brace of the root object.
+func jsoncRoot(data []byte) (int, error) {
+	i := jsoncSkip(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return 0, errJSONCSyntax
+	}
+	return i, nil
+}
+
+// jsoncLookup returns the member key of the object at start, if present.
+func jsoncLookup(data []byte, start int, key string) (jsoncMember, bool, error) {
+	members, _, err := jsoncMembers(data, start)
+	if err != nil {
+		return jsoncMember{}, false, err
+	}
+	for _, m := range members {
+		if m.key == key {
+			return m, true, nil
+		}
+	}
+	return jsoncMember{}, false, nil
+}
+
+// jsoncSetMember sets key to value in the object at start. An existing value
+// is replaced in place; otherwise the member is appended after the last one,
+// following the indentation of the document. Everything else, comments
+// included, is left untouched.
+func jsoncSetMember(data []byte, start int, key string, value any) ([]byte, error) {
+	members, end, err := jsoncMembers(data, start)
+	if err != nil {
+		return nil, err
+	}
+	unit := jsoncIndentUnit(data)
+	name, _ := json.Marshal(key)
+
+	for _, m := range members {
+		if m.key != key {
+			continue
+		}
+		encoded, err := jsoncEncode(value, m.keyStart, data, unit)
+		if err != nil {
+			return nil, err
+		}
+		return jsoncSplice(data, m.valueStart, m.valueEnd, encoded), nil
+	}
+
+	if len(members) == 0 {
+		parent := lineIndent(data, start)
+		child := parent + unit
+		encoded, err := jsonIndent(value, child, unit)
+		if err != nil {
+			return nil, err
+		}
+		inner := bytes.TrimRight(data[start+1:end], " \t\r\n")
+		text := string(inner) + "\n" + child + string(name) + ": " + encoded + "\n" + parent
+		return jsoncSplice(data, start+1, end, text), nil
+	}
+
+	last := members[len(members)-1]
+	encoded, err := jsoncEncode(value, last.keyStart, data, unit)
+	if err != nil {
+		return nil, err
+	}
+	if !startsLine(data, last.keyStart) {
+		// Single-line object: stay on the same line
+		return jsoncSplice(data, last.valueEnd, last.valueEnd, ", "+string(name)+": "+encoded), nil
+	}
+
+	member := "\n" + lineIndent(data, last.keyStart) + string(name) + ": " + encoded
+	if next := jsoncSkip(data, last.valueEnd); next < len(data) && data[next] == ',' {
+		// Keep the trailing comma style of the document
+		return jsoncSplice(data, next+1, next+1, member+","), nil
+	}
+	// Insert after a comment closing the line of the last member, if any
+	insertAt := last.valueEnd
+	if rest := bytes.TrimLeft(data[insertAt:], " \t"); bytes.HasPrefix(rest, []byte("//")) {
+		if nl := bytes.IndexByte(data[insertAt:], '\n'); nl >= 0 {
+			insertAt += nl
+		} else {
+			insertAt = len(data)
+		}
+	}
+	data = jsoncSplice(data, insertAt, insertAt, member)
+	return jsoncSplice(data, last.valueEnd, last.valueEnd, ","), nil
+}
+
+// jsoncDeleteMember removes key from the object at start along with its
+// separating comma. When the member occupied whole lines, they are removed.
+func jsoncDeleteMember(data []byte, start int, key string) ([]byte, error) {
+	members, _, err := jsoncMembers(data, start)
+	if err != nil {
+		return nil, err
+	}
+	idx := -1
+	for i, m := range members {
+		if m.key == key {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return data, nil
+	}
+
+	m := members[idx]
+	from, to := m.keyStart, m.valueEnd
+	if next := jsoncSkip(data, to); next < len(data) && data[next] == ',' {
+		to = next + 1
+	} else if idx > 0 {
+		if prev := jsoncSkip(data, members[idx-1].valueEnd); data[prev] == ',' {
+			from = prev
+		}
+	}
+	if from == m.keyStart && startsLine(data, from) {
+		from = bytes.LastIndexByte(data[:from], '\n') + 1
+		rest := to
+		for rest < len(data) && (data[rest] == ' ' || data[rest] == '\t' || data[rest] == '\r') {
+			rest++
+		}
+		if rest < len(data) && data[rest] == '\n' {
+			to = rest + 1
+		}
+	}
+	return jsoncSplice(data, from, to, ""), nil
+}
+
+// jsoncSplice replaces data[from:to] with text.
+func jsoncSplice(data []byte, from, to int, text string) []byte {
+	out := make([]byte, 0, len(data)-(to-from)+len(text))
+	out = append(out, data[:from]...)
+	out = append(out, text...)
+	return append(out, data[to:]...)
+}
+
+// jsoncEncode encodes value for a member whose key starts at keyStart:
+// indented like the member, or compact if the member shares its line.
+func jsoncEncode(value any, keyStart int, data []byte, unit string) (string, error) {
+	if !startsLine(data, keyStart) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	}
+	return jsonIndent(value, lineIndent(data, keyStart), unit)
+}
+
+// jsonIndent encodes value without HTML escaping, indenting continuation
+// lines with prefix.
+func jsonIndent(value any, prefix, indent string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, indent)
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func lineIndent(data []byte, pos int) string {
+	i := bytes.LastIndexByte(data[:pos], '\n') + 1
+	j := i
+	for j < len(data) && (data[j] == ' ' || data[j] == '\t') {
+		j++
+	}
+	return string(data[i:j])
+}
+
+// startsLine reports whether only whitespace precedes pos on its line.
+func startsLine(data []byte, pos int) bool {
+	i := bytes.LastIndexByte(data[:pos], '\n') + 1
+	return len(bytes.TrimLeft(data[i:pos], " \t")) == 0
+}
+
+// jsoncIndentUnit guesses the indentation unit of a document from its first
+// indented line, defaulting to a tab.
+func jsoncIndentUnit(data []byte) string {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) == len(line) || len(bytes.TrimSpace(trimmed)) == 0 {
+			continue
+		}
+		if line[0] == '\t' {
+			return "\t"
+		}
+		return string(line[:len(line)-len(trimmed)])
+	}
+	return "\t"
+}
@@ -0,0 +1,32 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func TestJSONRPCNotification_Params(t *testing.T) {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: string(mcp.MethodNotificationElicitationComplete),
+			Params: mcp.NotificationParams{AdditionalFields: map[string]any{"elicitationId": "abc"}},
+		},
+	}
+	data, err := json.Marshal(notification)
+	require.NoError(t, err)
+	expected := `{"jsonrpc":"2.0","method":"notifications/elicitation/complete","params":{"elicitationId":"abc"}}`
+	assert.JSONEq(t, expected, string(data))
+
+	// Decoding leaves an empty Meta, which is not sent back as "_meta": {}
+	var decoded mcp.JSONRPCNotification
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "abc", decoded.Params.AdditionalFields["elicitationId"])
+	data, err = json.Marshal(decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, expected, string(data))
+}
@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuthGrantType is the OAuth grant an OAuthHandler uses to obtain tokens.
+type OAuthGrantType string
+
+const (
+	// OAuthGrantAuthorizationCode obtains tokens through a user authorizing
+	// the client in a browser (with PKCE if enabled)
+	OAuthGrantAuthorizationCode OAuthGrantType = "authorization_code"
+	// OAuthGrantClientCredentials obtains tokens for the client itself,
+	// authenticated by its secret or assertion key
+	OAuthGrantClientCredentials OAuthGrantType = "client_credentials"
+	// OAuthGrantTokenExchange exchanges another token for an access token
+	// to the MCP server (RFC 8693)
+	OAuthGrantTokenExchange OAuthGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Token types of RFC 8693 token exchange.
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIDToken     = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// clientAssertionType is the client_assertion_type of private_key_jwt.
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// TokenExchangeConfig configures the OAuthGrantTokenExchange grant.
+type TokenExchangeConfig struct {
+	// SubjectToken returns the token to exchange, such as the OIDC token of
+	// a CI job. It is called on every exchange, so the token can rotate.
+	SubjectToken func(ctx context.Context) (string, error)
+	// SubjectTokenType defaults to TokenTypeAccessToken
+	SubjectTokenType string
+	// ActorToken returns the token of the party acting on behalf of the
+	// subject (optional)
+	ActorToken func(ctx context.Context) (string, error)
+	// ActorTokenType defaults to TokenTypeAccessToken
+	ActorTokenType string
+	// Resource is the URL of the server the token is requested for (optional)
+	Resource string
+	// Audience is the logical name of that server (optional)
+	Audience string
+	// RequestedTokenType is the type of token to issue (optional)
+	RequestedTokenType string
+}
+
+// unattended reports whether the handler obtains tokens without user
+// interaction.
+func (h *OAuthHandler) unattended() bool {
+	return h.config.GrantType == OAuthGrantClientCredentials || h.config.GrantType == OAuthGrantTokenExchange
+}
+
+// renewRejectedToken drops the stored token after the server rejected the
+// Authorization header value rejected, and returns the header value of a
+// new token. Grants that need the user return ErrOAuthAuthorizationRequired.
+func (h *OAuthHandler) renewRejectedToken(ctx context.Context, rejected string) (string, error) {
+	if !h.unattended() {
+		return "", ErrOAuthAuthorizationRequired
+	}
+
+	h.grantMu.Lock()
+	// Another request may have renewed the token already
+	token, err := h.config.TokenStore.GetToken(ctx)
+	if err == nil && authorizationHeader(token) == rejected {
+		err = h.config.TokenStore.SaveToken(ctx, &Token{})
+	} else if errors.Is(err, ErrNoToken) {
+		err = nil
+	}
+	h.grantMu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to drop rejected token: %w", err)
+	}
+	return h.GetAuthorizationHeader(ctx)
+}
+
+// doWithOAuth sends req, authorized by handler. If the server answers 401
+// and handler uses a grant without user interaction, the token is renewed
+// and the request sent once more, so a revoked token does not fail it.
+func doWithOAuth(client *http.Client, handler *OAuthHandler, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || handler == nil || !handler.unattended() {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil // The body cannot be sent again
+	}
+	authHeader, err := handler.renewRejectedToken(req.Context(), req.Header.Get("Authorization"))
+	if err != nil {
+		return resp, nil // The caller reports the 401
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	retry.Header.Set("Authorization", authHeader)
+	resp.Body.Close()
+	return client.Do(retry)
+}
+
+// requestUnattendedToken obtains a token with the client credentials or
+// token exchange grant and saves it.
+func (h *OAuthHandler) requestUnattendedToken(ctx context.Context) (*Token, error) {
+	h.grantMu.Lock()
+	defer h.grantMu.Unlock()
+
+	// Another request may have obtained a token while we were waiting
+	if token, err := h.config.TokenStore.GetToken(ctx); err == nil && !token.IsExpired() && token.AccessToken != "" {
+		return token, nil
+	}
+
+	metadata, err := h.getServerMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server metadata: %w", err)
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", string(h.config.GrantType))
+	if len(h.config.Scopes) > 0 {
+		data.Set("scope", strings.Join(h.config.Scopes, " "))
+	}
+	if h.config.GrantType == OAuthGrantTokenExchange {
+		if err := h.setTokenExchangeParams(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := h.setClientAuthentication(data, metadata.TokenEndpoint); err != nil {
+		return nil, err
+	}
+
+	token, err := h.postTokenRequest(ctx, metadata.TokenEndpoint, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.config.TokenStore.SaveToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to save token: %w", err)
+	}
+	return token, nil
+}
+
+// setTokenExchangeParams adds the RFC 8693 parameters to a token request.
+func (h *OAuthHandler) setTokenExchangeParams(ctx context.Context, data url.Values) error {
+	exchange := h.config.TokenExchange
+	if exchange == nil || exchange.SubjectToken == nil {
+		return errors.New("token exchange requires a subject token")
+	}
+	subjectToken, err := exchange.SubjectToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get subject token: %w", err)
+	}
+	data.Set("subject_token", subjectToken)
+	data.Set("subject_token_type", tokenTypeOrDefault(exchange.SubjectTokenType))
+
+	if exchange.ActorToken != nil {
+		actorToken, err := exchange.ActorToken(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get actor token: %w", err)
+		}
+		data.Set("actor_token", actorToken)
+		data.Set("actor_token_type", tokenTypeOrDefault(exchange.ActorTokenType))
+	}
+	if exchange.Resource != "" {
+		data.Set("resource", exchange.Resource)
+	}
+	if exchange.Audience != "" {
+		data.Set("audience", exchange.Audience)
+	}
+	if exchange.RequestedTokenType != "" {
+		data.Set("requested_token_type", exchange.RequestedTokenType)
+	}
+	return nil
+}
+
+func tokenTypeOrDefault(tokenType string) string {
+	if tokenType == "" {
+		return TokenTypeAccessToken
+	}
+	return tokenType
+}
+
+// setClientAuthentication identifies the client in a token request, with a
+// private_key_jwt assertion if the handler has a key, or its secret.
+func (h *OAuthHandler) setClientAuthentication(data url.Values, tokenEndpoint string) error {
+	data.Set("client_id", h.config.ClientID)
+	if h.config.ClientAssertionKey != nil {
+		assertion, err := h.clientAssertion(tokenEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to create client assertion: %w", err)
+		}
+		data.Set("client_assertion_type", clientAssertionType)
+		data.Set("client_assertion", assertion)
+		return nil
+	}
+	if h.config.ClientSecret != "" {
+		data.Set("client_secret", h.config.ClientSecret)
+	}
+	return nil
+}
+
+// clientAssertion signs a short-lived RS256 JWT identifying the client to
+// the token endpoint (RFC 7523 section 3).
+func (h *OAuthHandler) clientAssertion(tokenEndpoint string) (string, error) {
+	jti, err := GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if h.config.ClientAssertionKeyID != "" {
+		header["kid"] = h.config.ClientAssertionKeyID
+	}
+	claims := map[string]any{
+		"iss": h.config.ClientID,
+		"sub": h.config.ClientID,
+		"aud": tokenEndpoint,
+		"jti": jti,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, h.config.ClientAssertionKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// postTokenRequest sends a token request and decodes the issued token.
+func (h *OAuthHandler) postTokenRequest(ctx context.Context, tokenEndpoint string, data url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		tokenEndpoint,
+		strings.NewReader(data.Encode()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, extractOAuthError(body, resp.StatusCode, "token request failed")
+	}
+
+	// Some servers return HTTP 200 even for errors
+	var oauthErr OAuthError
+	if err := json.Unmarshal(body, &oauthErr); err == nil && oauthErr.ErrorCode != "" {
+		return nil, fmt.Errorf("token request failed: %w", oauthErr)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+	if token.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
@@ -0,0 +1,251 @@
+package mcp_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// newTokenServer serves authorization server metadata and a token endpoint
+// that issues "access-token" to requests accepted by check.
+func newTokenServer(t *testing.T, check func(form url.Values) bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mcp.AuthServerMetadata{
+			Issuer:        server.URL,
+			TokenEndpoint: server.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if err := r.ParseForm(); err != nil || !check(r.PostForm) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(mcp.OAuthError{ErrorCode: "invalid_client"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(mcp.Token{AccessToken: "access-token", TokenType: "Bearer", ExpiresIn: 3600})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestOAuthGrants_ClientCredentials(t *testing.T) {
+	tokenServer, requests := newTokenServer(t, func(form url.Values) bool {
+		return form.Get("grant_type") == "client_credentials" &&
+			form.Get("client_id") == "ci-agent" &&
+			form.Get("client_secret") == "ci-secret" &&
+			form.Get("scope") == "mcp.read mcp.write"
+	})
+	mcpServer := newProtectedMCPServer(t)
+
+	client, err := mcp.NewOAuthStreamableHttpClient(mcpServer.URL, mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		ClientSecret:          "ci-secret",
+		Scopes:                []string{"mcp.read", "mcp.write"},
+		AuthServerMetadataURL: tokenServer.URL + "/.well-known/oauth-authorization-server",
+		GrantType:             mcp.OAuthGrantClientCredentials,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+
+	// No user interaction is needed
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: "ci-agent", Version: "1.0.0"}
+	result, err := client.Initialize(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "protected-server", result.ServerInfo.Name)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestOAuthGrants_CachedToken(t *testing.T) {
+	tokenServer, requests := newTokenServer(t, func(form url.Values) bool { return true })
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		ClientSecret:          "ci-secret",
+		AuthServerMetadataURL: tokenServer.URL + "/.well-known/oauth-authorization-server",
+		GrantType:             mcp.OAuthGrantClientCredentials,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			header, err := handler.GetAuthorizationHeader(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "Bearer access-token", header)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestOAuthGrants_RevokedToken(t *testing.T) {
+	tokenServer, requests := newTokenServer(t, func(form url.Values) bool { return true })
+	mcpServer := newProtectedMCPServer(t)
+
+	// The cached token has been revoked by the authorization server
+	tokenStore := mcp.NewMemoryTokenStore()
+	require.NoError(t, tokenStore.SaveToken(context.Background(), &mcp.Token{
+		AccessToken: "revoked-token",
+		TokenType:   "Bearer",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}))
+	client, err := mcp.NewOAuthStreamableHttpClient(mcpServer.URL, mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		ClientSecret:          "ci-secret",
+		AuthServerMetadataURL: tokenServer.URL + "/.well-known/oauth-authorization-server",
+		GrantType:             mcp.OAuthGrantClientCredentials,
+		TokenStore:            tokenStore,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: "ci-agent", Version: "1.0.0"}
+	result, err := client.Initialize(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "protected-server", result.ServerInfo.Name)
+	assert.Equal(t, int32(1), requests.Load())
+
+	token, err := tokenStore.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", token.AccessToken)
+}
+
+func TestOAuthGrants_RejectedNewToken(t *testing.T) {
+	tokenServer, requests := newTokenServer(t, func(form url.Values) bool { return true })
+	mcpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer mcpServer.Close()
+
+	client, err := mcp.NewOAuthStreamableHttpClient(mcpServer.URL, mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		ClientSecret:          "ci-secret",
+		AuthServerMetadataURL: tokenServer.URL + "/.well-known/oauth-authorization-server",
+		GrantType:             mcp.OAuthGrantClientCredentials,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+
+	// The token is renewed once, then the request fails
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	_, err = client.Initialize(context.Background(), request)
+	assert.True(t, mcp.IsOAuthAuthorizationRequiredError(err), err)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestOAuthGrants_PrivateKeyJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksFile, map[string]*rsa.PrivateKey{"client-key": key})
+
+	var assertionValidator *mcp.JWTValidator
+	tokenServer, _ := newTokenServer(t, func(form url.Values) bool {
+		if form.Get("client_secret") != "" ||
+			form.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+			return false
+		}
+		info, err := assertionValidator.ValidateToken(context.Background(), form.Get("client_assertion"))
+		return err == nil && info.Subject == "ci-agent" && info.Issuer == "ci-agent"
+	})
+	assertionValidator, err = mcp.NewJWTValidator(mcp.JWTValidatorConfig{
+		Issuer:   "ci-agent",
+		Audience: tokenServer.URL + "/token",
+		JWKSFile: jwksFile,
+	})
+	require.NoError(t, err)
+
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		ClientAssertionKey:    key,
+		ClientAssertionKeyID:  "client-key",
+		AuthServerMetadataURL: tokenServer.URL + "/.well-known/oauth-authorization-server",
+		GrantType:             mcp.OAuthGrantClientCredentials,
+	})
+	header, err := handler.GetAuthorizationHeader(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-token", header)
+}
+
+func TestOAuthGrants_TokenExchange(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, func(form url.Values) bool {
+		return form.Get("grant_type") == "urn:ietf:params:oauth:grant-type:token-exchange" &&
+			form.Get("subject_token") == "ci-oidc-token" &&
+			form.Get("subject_token_type") == mcp.TokenTypeIDToken &&
+			form.Get("audience") == "mcp-server" &&
+			form.Get("resource") == "https://mcp.example.com/mcp" &&
+			!form.Has("actor_token")
+	})
+
+	var subjectTokenCalls atomic.Int32
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		AuthServerMetadataURL: tokenServer.URL + "/.well-known/oauth-authorization-server",
+		GrantType:             mcp.OAuthGrantTokenExchange,
+		TokenExchange: &mcp.TokenExchangeConfig{
+			SubjectToken: func(ctx context.Context) (string, error) {
+				subjectTokenCalls.Add(1)
+				return "ci-oidc-token", nil
+			},
+			SubjectTokenType: mcp.TokenTypeIDToken,
+			Audience:         "mcp-server",
+			Resource:         "https://mcp.example.com/mcp",
+		},
+	})
+	header, err := handler.GetAuthorizationHeader(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-token", header)
+	assert.Equal(t, int32(1), subjectTokenCalls.Load())
+}
+
+func TestOAuthGrants_Errors(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, func(form url.Values) bool { return false })
+	metadataURL := tokenServer.URL + "/.well-known/oauth-authorization-server"
+
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		ClientSecret:          "wrong-secret",
+		AuthServerMetadataURL: metadataURL,
+		GrantType:             mcp.OAuthGrantClientCredentials,
+	})
+	_, err := handler.GetAuthorizationHeader(context.Background())
+	var oauthErr mcp.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_client", oauthErr.ErrorCode)
+	assert.False(t, mcp.IsOAuthAuthorizationRequiredError(err))
+
+	handler = mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "ci-agent",
+		AuthServerMetadataURL: metadataURL,
+		GrantType:             mcp.OAuthGrantTokenExchange,
+	})
+	_, err = handler.GetAuthorizationHeader(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subject token")
+}
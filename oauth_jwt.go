@@ -0,0 +1,300 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTValidatorConfig configures a JWTValidator. At least one of HMACSecret
+// and JWKSFile must be set.
+type JWTValidatorConfig struct {
+	// Issuer is the required "iss" claim (optional)
+	Issuer string
+	// Audience is the required "aud" claim, usually the resource URL (optional)
+	Audience string
+	// HMACSecret verifies HS256 tokens
+	HMACSecret []byte
+	// JWKSFile is a local JSON Web Key Set verifying RS256 tokens. It is
+	// read again when a token is signed with an unknown key ID and the file
+	// has changed, so keys can be rotated without a restart.
+	JWKSFile string
+	// Leeway is the clock skew tolerated when checking "exp" and "nbf"
+	Leeway time.Duration
+}
+
+// JWTValidator is a TokenValidator for JWT access tokens signed with HS256
+// or RS256.
+type JWTValidator struct {
+	config JWTValidatorConfig
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PublicKey // By key ID
+	keysTime time.Time                 // Modification time of the loaded JWKS file
+}
+
+// NewJWTValidator creates a JWTValidator, loading the JWKS file if any.
+func NewJWTValidator(config JWTValidatorConfig) (*JWTValidator, error) {
+	if len(config.HMACSecret) == 0 && config.JWKSFile == "" {
+		return nil, errors.New("JWT validator requires an HMAC secret or a JWKS file")
+	}
+	v := &JWTValidator{config: config}
+	if config.JWKSFile != "" {
+		if err := v.loadKeys(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// ValidateToken verifies the signature and claims of token.
+func (v *JWTValidator) ValidateToken(ctx context.Context, token string) (*AuthInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed JWT", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT claims", ErrInvalidToken)
+	}
+	return v.checkClaims(token, claims)
+}
+
+// verifySignature checks the signature of signed with the key matching alg.
+// Each algorithm only accepts its own kind of key, so that a token cannot
+// be signed with a public key used as an HMAC secret.
+func (v *JWTValidator) verifySignature(alg, kid, signed string, signature []byte) error {
+	switch alg {
+	case "HS256":
+		if len(v.config.HMACSecret) == 0 {
+			return fmt.Errorf("%w: unexpected signing algorithm %q", ErrInvalidToken, alg)
+		}
+		mac := hmac.New(sha256.New, v.config.HMACSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+		}
+		return nil
+	case "RS256":
+		if v.config.JWKSFile == "" {
+			return fmt.Errorf("%w: unexpected signing algorithm %q", ErrInvalidToken, alg)
+		}
+		key, err := v.publicKey(kid)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+			return fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported signing algorithm %q", ErrInvalidToken, alg)
+	}
+}
+
+// checkClaims validates the registered claims and builds the AuthInfo.
+func (v *JWTValidator) checkClaims(token string, claims map[string]any) (*AuthInfo, error) {
+	now := time.Now()
+	info := &AuthInfo{Token: token, Claims: claims}
+
+	exp, hasExp, err := numericClaim(claims, "exp")
+	if err != nil {
+		return nil, err
+	}
+	if hasExp {
+		info.ExpiresAt = exp
+		if now.After(exp.Add(v.config.Leeway)) {
+			return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+		}
+	}
+	nbf, hasNbf, err := numericClaim(claims, "nbf")
+	if err != nil {
+		return nil, err
+	}
+	if hasNbf && now.Add(v.config.Leeway).Before(nbf) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	info.Issuer, _ = claims["iss"].(string)
+	if v.config.Issuer != "" && info.Issuer != v.config.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, info.Issuer)
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		info.Audience = []string{aud}
+	case []any:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				info.Audience = append(info.Audience, s)
+			}
+		}
+	}
+	if v.config.Audience != "" && !slices.Contains(info.Audience, v.config.Audience) {
+		return nil, fmt.Errorf("%w: token not issued for this resource", ErrInvalidToken)
+	}
+
+	info.Subject, _ = claims["sub"].(string)
+	if clientID, ok := claims["client_id"].(string); ok {
+		info.ClientID = clientID
+	} else {
+		info.ClientID, _ = claims["azp"].(string)
+	}
+
+	// "scope" is a space-separated string (RFC 9068), some servers use a "scp" array
+	if scope, ok := claims["scope"].(string); ok {
+		info.Scopes = strings.Fields(scope)
+	} else if scp, ok := claims["scp"].([]any); ok {
+		for _, s := range scp {
+			if scope, ok := s.(string); ok {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+	return info, nil
+}
+
+// publicKey returns the RSA key with the given ID, reloading the JWKS file
+// if it changed. Tokens without a key ID need a JWKS file with a single key.
+func (v *JWTValidator) publicKey(kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key := v.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if info, err := os.Stat(v.config.JWKSFile); err == nil && !info.ModTime().Equal(v.keysTime) {
+		// Keep the current keys if the file is being rewritten
+		if err := v.loadKeysLocked(); err == nil {
+			if key := v.lookupKey(kid); key != nil {
+				return key, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+func (v *JWTValidator) lookupKey(kid string) *rsa.PublicKey {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key
+		}
+	}
+	return v.keys[kid]
+}
+
+func (v *JWTValidator) loadKeys() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.loadKeysLocked()
+}
+
+// loadKeysLocked reads the RSA signing keys of the JWKS file.
+func (v *JWTValidator) loadKeysLocked() error {
+	info, err := os.Stat(v.config.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	data, err := os.ReadFile(v.config.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != "RS256") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return fmt.Errorf("invalid RSA key %q in JWKS file", jwk.Kid)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS file contains no RSA signing keys")
+	}
+	v.keys = keys
+	v.keysTime = info.ModTime()
+	return nil
+}
+
+// decodeJWTPart decodes a base64url-encoded JSON part of a JWT.
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// numericClaim returns a NumericDate claim as a time.
+func numericClaim(claims map[string]any, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: invalid %q claim", ErrInvalidToken, name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: invalid %q claim", ErrInvalidToken, name)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true, nil
+}
+
+var _ TokenValidator = (*JWTValidator)(nil)
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLoopbackCallbackPath is the path of the redirect URI served by
+// AuthorizeWithLoopback.
+const DefaultLoopbackCallbackPath = "/callback"
+
+// LoopbackAuthConfig configures AuthorizeWithLoopback.
+type LoopbackAuthConfig struct {
+	// OpenURL hands the authorization URL to the user, usually by opening
+	// it in a browser (required)
+	OpenURL func(ctx context.Context, authURL string) error
+	// ClientName is used for dynamic client registration when the handler
+	// has no client ID. Defaults to "mcp-client".
+	ClientName string
+	// CallbackPath overrides DefaultLoopbackCallbackPath when the redirect
+	// URI is on an ephemeral port
+	CallbackPath string
+}
+
+// AuthorizeWithLoopback runs the OAuth authorization code flow of handler
+// and saves the token to its token store.
+//
+// The redirect URI points at a temporary listener on 127.0.0.1. If the
+// handler's RedirectURI is an http loopback URL with an explicit port, as
+// clients registered ahead of time need, it is kept and served; otherwise
+// this flow uses a URL on an ephemeral port, leaving the handler's
+// RedirectURI unchanged. Clients without an ID are registered dynamically
+// with that redirect URI.
+func AuthorizeWithLoopback(ctx context.Context, handler *OAuthHandler, config LoopbackAuthConfig) error {
+	if handler == nil {
+		return errors.New("OAuth handler is nil")
+	}
+	if config.OpenURL == nil {
+		return errors.New("loopback authorization requires an OpenURL function")
+	}
+	if config.ClientName == "" {
+		config.ClientName = "mcp-client"
+	}
+
+	// Discover the authorization server first: without a base URL, the
+	// handler would derive it from the redirect URI we are about to change
+	if _, err := handler.GetServerMetadata(ctx); err != nil {
+		return fmt.Errorf("failed to get server metadata: %w", err)
+	}
+
+	redirectURL := loopbackRedirectURL(handler.config.RedirectURI)
+	address := "127.0.0.1:0"
+	if redirectURL != nil {
+		address = net.JoinHostPort("127.0.0.1", redirectURL.Port())
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to start loopback listener: %w", err)
+	}
+	defer listener.Close()
+	if redirectURL == nil {
+		path := config.CallbackPath
+		if path == "" {
+			path = DefaultLoopbackCallbackPath
+		}
+		redirectURL = &url.URL{Scheme: "http", Host: listener.Addr().String(), Path: path}
+	}
+	redirectURI := redirectURL.String()
+	path := redirectURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	if handler.GetClientID() == "" {
+		if err := handler.registerClient(ctx, config.ClientName, redirectURI); err != nil {
+			return fmt.Errorf("failed to register client: %w", err)
+		}
+	}
+
+	codeVerifier, err := GenerateCodeVerifier()
+	if err != nil {
+		return fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	state, err := GenerateState()
+	if err != nil {
+		return fmt.Errorf("failed to generate state: %w", err)
+	}
+	authURL, err := handler.authorizationURL(ctx, state, GenerateCodeChallenge(codeVerifier), redirectURI)
+	if err != nil {
+		return fmt.Errorf("failed to get authorization URL: %w", err)
+	}
+
+	// Only the first callback with the state of this flow counts, so that
+	// stray requests can neither end the flow nor replace its outcome
+	result := make(chan error, 1)
+	var handled atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != handler.GetExpectedState() {
+			http.Error(w, "Authorization failed: "+ErrInvalidState.Error(), http.StatusBadRequest)
+			return
+		}
+		if !handled.CompareAndSwap(false, true) {
+			http.Error(w, "authorization already completed", http.StatusGone)
+			return
+		}
+		err := completeLoopbackAuthorization(ctx, handler, query, codeVerifier, redirectURI)
+		if err != nil {
+			http.Error(w, "Authorization failed: "+err.Error(), http.StatusBadRequest)
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte("Authorization complete. You can close this window."))
+		}
+		result <- err
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := config.OpenURL(ctx, authURL); err != nil {
+		return fmt.Errorf("failed to open authorization URL: %w", err)
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// completeLoopbackAuthorization exchanges the code of the redirect to the
+// loopback listener for a token. The state of the redirect was checked.
+func completeLoopbackAuthorization(ctx context.Context, handler *OAuthHandler, query url.Values, codeVerifier, redirectURI string) error {
+	if errorCode := query.Get("error"); errorCode != "" {
+		return OAuthError{
+			ErrorCode:        errorCode,
+			ErrorDescription: query.Get("error_description"),
+			ErrorURI:         query.Get("error_uri"),
+		}
+	}
+	code := query.Get("code")
+	if code == "" {
+		return errors.New("authorization response has no code")
+	}
+	return handler.processAuthorizationResponse(ctx, code, query.Get("state"), codeVerifier, redirectURI)
+}
+
+// loopbackRedirectURL returns redirectURI if the loopback listener can
+// serve it: an http URL on 127.0.0.1 or localhost with an explicit port.
+func loopbackRedirectURL(redirectURI string) *url.URL {
+	u, err := url.Parse(redirectURI)
+	if err != nil || u.Scheme != "http" || u.Port() == "" ||
+		(u.Hostname() != "127.0.0.1" && u.Hostname() != "localhost") {
+		return nil
+	}
+	return u
+}
+
+// CallWithLoopbackAuth calls call and, if it fails because the server
+// requires OAuth authorization, runs AuthorizeWithLoopback with the handler
+// of the error and calls it again:
+//
+//	result, err := mcp.CallWithLoopbackAuth(ctx, config, func(ctx context.Context) (*mcp.InitializeResult, error) {
+//		return client.Initialize(ctx, request)
+//	})
+func CallWithLoopbackAuth[T any](ctx context.Context, config LoopbackAuthConfig, call func(ctx context.Context) (T, error)) (T, error) {
+	result, err := call(ctx)
+	if err == nil || !IsOAuthAuthorizationRequiredError(err) {
+		return result, err
+	}
+	handler := GetOAuthHandler(err)
+	if handler == nil {
+		return result, err
+	}
+	if authErr := AuthorizeWithLoopback(ctx, handler, config); authErr != nil {
+		var zero T
+		return zero, fmt.Errorf("%w (%w)", err, authErr)
+	}
+	return call(ctx)
+}
@@ -0,0 +1,252 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// fakeAuthServer is an authorization server that approves every request
+// and registers clients dynamically.
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	redirectURIs  []string // Registered redirect URIs
+	codeChallenge string   // Of the last authorization request
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	t.Helper()
+	as := &fakeAuthServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mcp.AuthServerMetadata{
+			Issuer:                as.URL,
+			AuthorizationEndpoint: as.URL + "/authorize",
+			TokenEndpoint:         as.URL + "/token",
+			RegistrationEndpoint:  as.URL + "/register",
+		})
+	})
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		as.mu.Lock()
+		as.redirectURIs = request.RedirectURIs
+		as.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{"client_id": "registered-client"})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		as.mu.Lock()
+		as.codeChallenge = query.Get("code_challenge")
+		as.mu.Unlock()
+		redirect := query.Get("redirect_uri") + "?code=auth-code&state=" + url.QueryEscape(query.Get("state"))
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		as.mu.Lock()
+		challenge := as.codeChallenge
+		as.mu.Unlock()
+		if r.FormValue("code") != "auth-code" || mcp.GenerateCodeChallenge(r.FormValue("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(mcp.OAuthError{ErrorCode: "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(mcp.Token{AccessToken: "access-token", TokenType: "Bearer", ExpiresIn: 3600})
+	})
+	as.Server = httptest.NewServer(mux)
+	t.Cleanup(as.Close)
+	return as
+}
+
+// newProtectedMCPServer answers initialize requests bearing "access-token".
+func newProtectedMCPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request mcp.JSONRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result": map[string]any{
+				"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+				"serverInfo":      map[string]any{"name": "protected-server", "version": "1.0.0"},
+				"capabilities":    map[string]any{},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// followURL plays the browser: it follows the authorization URL to the
+// loopback listener.
+func followURL(ctx context.Context, authURL string) error {
+	go func() {
+		resp, err := http.Get(authURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	return nil
+}
+
+func TestLoopbackAuth_CallWithLoopbackAuth(t *testing.T) {
+	as := newFakeAuthServer(t)
+	mcpServer := newProtectedMCPServer(t)
+
+	tokenStore := mcp.NewMemoryTokenStore()
+	client, err := mcp.NewOAuthStreamableHttpClient(mcpServer.URL, mcp.OAuthConfig{
+		AuthServerMetadataURL: as.URL + "/.well-known/oauth-authorization-server",
+		TokenStore:            tokenStore,
+		PKCEEnabled:           true,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var opened string
+	config := mcp.LoopbackAuthConfig{OpenURL: func(ctx context.Context, authURL string) error {
+		opened = authURL
+		return followURL(ctx, authURL)
+	}}
+	result, err := mcp.CallWithLoopbackAuth(ctx, config, func(ctx context.Context) (*mcp.InitializeResult, error) {
+		request := mcp.InitializeRequest{}
+		request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+		request.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+		return client.Initialize(ctx, request)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "protected-server", result.ServerInfo.Name)
+
+	token, err := tokenStore.GetToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", token.AccessToken)
+
+	// The client was registered with the loopback redirect URI it was sent to
+	authURL, err := url.Parse(opened)
+	require.NoError(t, err)
+	redirectURI := authURL.Query().Get("redirect_uri")
+	assert.True(t, strings.HasPrefix(redirectURI, "http://127.0.0.1:"), redirectURI)
+	assert.True(t, strings.HasSuffix(redirectURI, mcp.DefaultLoopbackCallbackPath), redirectURI)
+	as.mu.Lock()
+	assert.Equal(t, []string{redirectURI}, as.redirectURIs)
+	as.mu.Unlock()
+	assert.Equal(t, "registered-client", authURL.Query().Get("client_id"))
+}
+
+func TestLoopbackAuth_InvalidState(t *testing.T) {
+	as := newFakeAuthServer(t)
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "client",
+		AuthServerMetadataURL: as.URL + "/.well-known/oauth-authorization-server",
+		PKCEEnabled:           true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var forged []int
+	err := mcp.AuthorizeWithLoopback(ctx, handler, mcp.LoopbackAuthConfig{OpenURL: func(ctx context.Context, authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		// Stray requests are rejected without ending the flow
+		redirectURI := u.Query().Get("redirect_uri")
+		for _, query := range []string{"?code=auth-code&state=forged", "?error=access_denied", "?code=auth-code"} {
+			resp, err := http.Get(redirectURI + query)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			forged = append(forged, resp.StatusCode)
+		}
+		return followURL(ctx, authURL)
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusBadRequest}, forged)
+}
+
+func TestLoopbackAuth_AuthorizationDenied(t *testing.T) {
+	as := newFakeAuthServer(t)
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "client",
+		AuthServerMetadataURL: as.URL + "/.well-known/oauth-authorization-server",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := mcp.AuthorizeWithLoopback(ctx, handler, mcp.LoopbackAuthConfig{OpenURL: func(ctx context.Context, authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		go func() {
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?error=access_denied&state=" + u.Query().Get("state"))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}})
+	var oauthErr mcp.OAuthError
+	require.True(t, errors.As(err, &oauthErr))
+	assert.Equal(t, "access_denied", oauthErr.ErrorCode)
+}
+
+func TestLoopbackAuth_Cancelled(t *testing.T) {
+	as := newFakeAuthServer(t)
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "client",
+		AuthServerMetadataURL: as.URL + "/.well-known/oauth-authorization-server",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := mcp.AuthorizeWithLoopback(ctx, handler, mcp.LoopbackAuthConfig{OpenURL: func(ctx context.Context, authURL string) error {
+		return nil // The user never completes the flow
+	}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLoopbackAuth_KeepsHandlerRedirectURI(t *testing.T) {
+	as := newFakeAuthServer(t)
+	handler := mcp.NewOAuthHandler(mcp.OAuthConfig{
+		ClientID:              "client",
+		AuthServerMetadataURL: as.URL + "/.well-known/oauth-authorization-server",
+		PKCEEnabled:           true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, mcp.AuthorizeWithLoopback(ctx, handler, mcp.LoopbackAuthConfig{OpenURL: followURL}))
+
+	// The ephemeral loopback URI was only used by that flow
+	authURL, err := handler.GetAuthorizationURL(ctx, "state", "challenge")
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "", u.Query().Get("redirect_uri"))
+}
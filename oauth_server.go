@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultProtectedResourceMetadataPath is the well-known path of OAuth
+// protected resource metadata (RFC 9728). ResourceServer serves it followed
+// by the path of the resource, if any.
+const DefaultProtectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// AuthInfo describes the validated access token of a request.
+type AuthInfo struct {
+	Token     string         // The raw bearer token
+	Subject   string         // The resource owner, "sub" claim
+	ClientID  string         // The client the token was issued to, "client_id" or "azp" claim
+	Issuer    string         // The authorization server, "iss" claim
+	Audience  []string       // The resources the token is meant for, "aud" claim
+	Scopes    []string       // The granted scopes
+	ExpiresAt time.Time      // Zero if the token does not expire
+	Claims    map[string]any // All claims of the token, if it has any
+}
+
+// HasScope reports whether scope was granted.
+func (a *AuthInfo) HasScope(scope string) bool {
+	return slices.Contains(a.Scopes, scope)
+}
+
+type authInfoKey struct{}
+
+// WithAuthInfo returns a copy of ctx carrying info.
+func WithAuthInfo(ctx context.Context, info *AuthInfo) context.Context {
+	return context.WithValue(ctx, authInfoKey{}, info)
+}
+
+// AuthInfoFromContext returns the validated access token of the request
+// being handled, if it was authenticated by a ResourceServer.
+func AuthInfoFromContext(ctx context.Context) (*AuthInfo, bool) {
+	info, ok := ctx.Value(authInfoKey{}).(*AuthInfo)
+	return info, ok && info != nil
+}
+
+// TokenValidator validates bearer tokens presented to a ResourceServer.
+//
+// Implementations return an error wrapping ErrInvalidToken for tokens that
+// are malformed, expired, or not meant for this server; other errors, and a
+// nil AuthInfo without an error, are treated as internal failures.
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*AuthInfo, error)
+}
+
+// TokenValidatorFunc adapts a function to the TokenValidator interface.
+type TokenValidatorFunc func(ctx context.Context, token string) (*AuthInfo, error)
+
+// ValidateToken calls f(ctx, token).
+func (f TokenValidatorFunc) ValidateToken(ctx context.Context, token string) (*AuthInfo, error) {
+	return f(ctx, token)
+}
+
+// ResourceServerConfig configures a ResourceServer.
+type ResourceServerConfig struct {
+	// Resource is the canonical URL of the MCP server, e.g. "https://mcp.example.com/mcp"
+	Resource string
+	// AuthorizationServers are the issuers clients get tokens from
+	AuthorizationServers []string
+	// ResourceName is a human-readable name of the server (optional)
+	ResourceName string
+	// ScopesSupported are the scopes advertised in the metadata (optional)
+	ScopesSupported []string
+	// Validator validates the bearer tokens of requests
+	Validator TokenValidator
+	// MetadataPath overrides the default path of the metadata: the path of
+	// Resource inserted after DefaultProtectedResourceMetadataPath, as in
+	// "/.well-known/oauth-protected-resource/mcp" (RFC 9728, section 3.1)
+	MetadataPath string
+}
+
+// ResourceServer turns an HTTP MCP server into an OAuth 2.1 resource server:
+// it publishes the protected resource metadata clients use to discover the
+// authorization server, and rejects requests without a valid bearer token.
+type ResourceServer struct {
+	config      ResourceServerConfig
+	metadataURL string
+}
+
+// NewResourceServer creates a ResourceServer.
+func NewResourceServer(config ResourceServerConfig) (*ResourceServer, error) {
+	if config.Validator == nil {
+		return nil, errors.New("resource server requires a token validator")
+	}
+	resourceURL, err := url.Parse(config.Resource)
+	if err != nil || resourceURL.Scheme == "" || resourceURL.Host == "" {
+		return nil, fmt.Errorf("invalid resource URL: %q", config.Resource)
+	}
+	if config.MetadataPath == "" {
+		config.MetadataPath = DefaultProtectedResourceMetadataPath + strings.TrimSuffix(resourceURL.Path, "/")
+	}
+	metadataURL := url.URL{Scheme: resourceURL.Scheme, Host: resourceURL.Host, Path: config.MetadataPath}
+	return &ResourceServer{config: config, metadataURL: metadataURL.String()}, nil
+}
+
+// MetadataPath returns the path to serve MetadataHandler on.
+func (rs *ResourceServer) MetadataPath() string {
+	return rs.config.MetadataPath
+}
+
+// MetadataURL returns the URL of the protected resource metadata, as
+// advertised in WWW-Authenticate headers.
+func (rs *ResourceServer) MetadataURL() string {
+	return rs.metadataURL
+}
+
+// Metadata returns the protected resource metadata.
+func (rs *ResourceServer) Metadata() OAuthProtectedResource {
+	return OAuthProtectedResource{
+		AuthorizationServers:   rs.config.AuthorizationServers,
+		Resource:               rs.config.Resource,
+		ResourceName:           rs.config.ResourceName,
+		ScopesSupported:        rs.config.ScopesSupported,
+		BearerMethodsSupported: []string{"header"},
+	}
+}
+
+// MetadataHandler serves the protected resource metadata.
+func (rs *ResourceServer) MetadataHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_ = json.NewEncoder(w).Encode(rs.Metadata())
+	})
+}
+
+// Middleware authenticates requests to next. Requests without a valid bearer
+// token get a 401 response whose WWW-Authenticate header points at the
+// metadata; the AuthInfo of accepted requests is available to handlers via
+// AuthInfoFromContext.
+func (rs *ResourceServer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			rs.unauthorized(w, "", "")
+			return
+		}
+		info, err := rs.config.Validator.ValidateToken(r.Context(), token)
+		if errors.Is(err, ErrInvalidToken) {
+			rs.unauthorized(w, "invalid_token", err.Error())
+			return
+		}
+		if err != nil || info == nil {
+			http.Error(w, "failed to validate token", http.StatusInternalServerError)
+			return
+		}
+		if info.Token == "" {
+			info.Token = token
+		}
+		next.ServeHTTP(w, r.WithContext(WithAuthInfo(r.Context(), info)))
+	})
+}
+
+// Handler serves the metadata and protects next, for servers where the MCP
+// endpoint is the only route.
+func (rs *ResourceServer) Handler(next http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(rs.config.MetadataPath, rs.MetadataHandler())
+	mux.Handle("/", rs.Middleware(next))
+	return mux
+}
+
+// unauthorized writes a 401 response challenging the client for a token.
+func (rs *ResourceServer) unauthorized(w http.ResponseWriter, errorCode, description string) {
+	challenge := fmt.Sprintf("Bearer resource_metadata=%q", rs.metadataURL)
+	if errorCode != "" {
+		challenge += fmt.Sprintf(", error=%q", errorCode)
+	}
+	if description != "" {
+		challenge += fmt.Sprintf(", error_description=%q", strings.ReplaceAll(description, `"`, "'"))
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
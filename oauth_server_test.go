@@ -0,0 +1,369 @@
+package mcp_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+var testHMACSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signJWT builds a JWT with the given header and claims, signed by sign.
+func signJWT(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+	headerJSON, err := json.Marshal(header)
+	require.NoError(t, err)
+	claimsJSON, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(t *testing.T, claims map[string]any) string {
+	return signJWT(t, map[string]any{"alg": "HS256", "typ": "JWT"}, claims, func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, testHMACSecret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	})
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	return signJWT(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid}, claims, func(signed []byte) []byte {
+		hash := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		require.NoError(t, err)
+		return signature
+	})
+}
+
+// writeJWKS writes the public keys to a JWKS file.
+func writeJWKS(t *testing.T, path string, keys map[string]*rsa.PrivateKey) {
+	t.Helper()
+	jwks := map[string][]map[string]string{"keys": {}}
+	for kid, key := range keys {
+		jwks["keys"] = append(jwks["keys"], map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":       "https://auth.example.com",
+		"aud":       "https://mcp.example.com/mcp",
+		"sub":       "alice",
+		"client_id": "my-agent",
+		"scope":     "tools:read tools:call",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWTValidator_HS256(t *testing.T) {
+	validator, err := mcp.NewJWTValidator(mcp.JWTValidatorConfig{
+		Issuer:     "https://auth.example.com",
+		Audience:   "https://mcp.example.com/mcp",
+		HMACSecret: testHMACSecret,
+	})
+	require.NoError(t, err)
+
+	info, err := validator.ValidateToken(context.Background(), hs256(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.Subject)
+	assert.Equal(t, "my-agent", info.ClientID)
+	assert.Equal(t, []string{"tools:read", "tools:call"}, info.Scopes)
+	assert.True(t, info.HasScope("tools:call"))
+	assert.False(t, info.ExpiresAt.IsZero())
+
+	tests := []struct {
+		name   string
+		token  func() string
+		reason string
+	}{
+		{
+			name: "expired",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return hs256(t, claims)
+			},
+			reason: "token expired",
+		},
+		{
+			name: "not valid yet",
+			token: func() string {
+				claims := validClaims()
+				claims["nbf"] = time.Now().Add(time.Hour).Unix()
+				return hs256(t, claims)
+			},
+			reason: "not valid yet",
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := validClaims()
+				claims["aud"] = []string{"https://other.example.com"}
+				return hs256(t, claims)
+			},
+			reason: "not issued for this resource",
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := validClaims()
+				claims["iss"] = "https://evil.example.com"
+				return hs256(t, claims)
+			},
+			reason: "unexpected issuer",
+		},
+		{
+			name: "tampered claims",
+			token: func() string {
+				parts := strings.Split(hs256(t, validClaims()), ".")
+				claims := validClaims()
+				claims["sub"] = "mallory"
+				claimsJSON, _ := json.Marshal(claims)
+				return parts[0] + "." + base64.RawURLEncoding.EncodeToString(claimsJSON) + "." + parts[2]
+			},
+			reason: "invalid signature",
+		},
+		{
+			name: "alg none",
+			token: func() string {
+				return signJWT(t, map[string]any{"alg": "none"}, validClaims(), func([]byte) []byte { return nil })
+			},
+			reason: "unsupported signing algorithm",
+		},
+		{
+			name: "RS256 without JWKS",
+			token: func() string {
+				return signJWT(t, map[string]any{"alg": "RS256"}, validClaims(), func([]byte) []byte { return []byte("x") })
+			},
+			reason: "unexpected signing algorithm",
+		},
+		{
+			name:   "malformed",
+			token:  func() string { return "not-a-jwt" },
+			reason: "malformed JWT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validator.ValidateToken(context.Background(), tt.token())
+			require.ErrorIs(t, err, mcp.ErrInvalidToken)
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+}
+
+func TestJWTValidator_RS256(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksFile, map[string]*rsa.PrivateKey{"key-1": key1})
+	validator, err := mcp.NewJWTValidator(mcp.JWTValidatorConfig{JWKSFile: jwksFile})
+	require.NoError(t, err)
+
+	info, err := validator.ValidateToken(context.Background(), rs256(t, key1, "key-1", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.Subject)
+
+	// Signed with the wrong key
+	_, err = validator.ValidateToken(context.Background(), rs256(t, key2, "key-1", validClaims()))
+	assert.ErrorIs(t, err, mcp.ErrInvalidToken)
+
+	// Unknown until the JWKS file is updated
+	token := rs256(t, key2, "key-2", validClaims())
+	_, err = validator.ValidateToken(context.Background(), token)
+	assert.ErrorIs(t, err, mcp.ErrInvalidToken)
+
+	writeJWKS(t, jwksFile, map[string]*rsa.PrivateKey{"key-1": key1, "key-2": key2})
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(jwksFile, modTime, modTime))
+	_, err = validator.ValidateToken(context.Background(), token)
+	assert.NoError(t, err)
+
+	// An HS256 token signed with the public key must not be accepted
+	forged := signJWT(t, map[string]any{"alg": "HS256", "kid": "key-1"}, validClaims(), func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, key1.N.Bytes())
+		mac.Write(signed)
+		return mac.Sum(nil)
+	})
+	_, err = validator.ValidateToken(context.Background(), forged)
+	assert.ErrorIs(t, err, mcp.ErrInvalidToken)
+}
+
+func newTestResourceServer(t *testing.T) *mcp.ResourceServer {
+	t.Helper()
+	validator, err := mcp.NewJWTValidator(mcp.JWTValidatorConfig{
+		Audience:   "https://mcp.example.com/mcp",
+		HMACSecret: testHMACSecret,
+	})
+	require.NoError(t, err)
+	rs, err := mcp.NewResourceServer(mcp.ResourceServerConfig{
+		Resource:             "https://mcp.example.com/mcp",
+		AuthorizationServers: []string{"https://auth.example.com"},
+		ScopesSupported:      []string{"tools:read", "tools:call"},
+		Validator:            validator,
+	})
+	require.NoError(t, err)
+	return rs
+}
+
+func TestResourceServer_Metadata(t *testing.T) {
+	rs := newTestResourceServer(t)
+	httpServer := httptest.NewServer(rs.Handler(http.NotFoundHandler()))
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/.well-known/oauth-protected-resource/mcp")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var metadata mcp.OAuthProtectedResource
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&metadata))
+	assert.Equal(t, "https://mcp.example.com/mcp", metadata.Resource)
+	assert.Equal(t, []string{"https://auth.example.com"}, metadata.AuthorizationServers)
+	assert.Equal(t, []string{"tools:read", "tools:call"}, metadata.ScopesSupported)
+	assert.Equal(t, "https://mcp.example.com/.well-known/oauth-protected-resource/mcp", rs.MetadataURL())
+}
+
+func TestResourceServer_MetadataPath(t *testing.T) {
+	validator := mcp.TokenValidatorFunc(func(ctx context.Context, token string) (*mcp.AuthInfo, error) {
+		return nil, mcp.ErrInvalidToken
+	})
+	tests := []struct {
+		resource     string
+		metadataPath string
+	}{
+		{"https://mcp.example.com", "/.well-known/oauth-protected-resource"},
+		{"https://mcp.example.com/", "/.well-known/oauth-protected-resource"},
+		{"https://mcp.example.com/mcp", "/.well-known/oauth-protected-resource/mcp"},
+		{"https://mcp.example.com/tenant/mcp/", "/.well-known/oauth-protected-resource/tenant/mcp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			rs, err := mcp.NewResourceServer(mcp.ResourceServerConfig{Resource: tt.resource, Validator: validator})
+			require.NoError(t, err)
+			assert.Equal(t, tt.metadataPath, rs.MetadataPath())
+			assert.Equal(t, "https://mcp.example.com"+tt.metadataPath, rs.MetadataURL())
+		})
+	}
+}
+
+func TestResourceServer_Middleware(t *testing.T) {
+	rs := newTestResourceServer(t)
+	httpServer := httptest.NewServer(rs.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, ok := mcp.AuthInfoFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no auth info", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(info.Subject))
+	})))
+	defer httpServer.Close()
+
+	get := func(authorization string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, httpServer.URL, nil)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := get("")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, `Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp"`, resp.Header.Get("WWW-Authenticate"))
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	resp = get("Bearer " + hs256(t, claims))
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `error="invalid_token"`)
+
+	resp = get("Bearer " + hs256(t, validClaims()))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestResourceServer_AuthInfoInToolHandler(t *testing.T) {
+	mcpServer := mcp.NewMCPServer("protected", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("whoami"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		info, ok := mcp.AuthInfoFromContext(ctx)
+		if !ok {
+			return mcp.NewToolResultError("not authenticated"), nil
+		}
+		return mcp.NewToolResultText(info.Subject), nil
+	})
+
+	rs := newTestResourceServer(t)
+	httpServer := httptest.NewServer(rs.Handler(mcp.NewWebSocketServer(mcpServer)))
+	defer httpServer.Close()
+
+	unauthenticated, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	assert.ErrorIs(t, unauthenticated.Start(context.Background()), mcp.ErrWebSocketHandshake)
+
+	client, err := mcp.NewWebSocketMCPClient(webSocketURL(httpServer),
+		mcp.WithWebSocketHeaders(map[string]string{"Authorization": "Bearer " + hs256(t, validClaims())}))
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "whoami"
+	result, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "alice", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestResourceServer_ValidatorWithoutAuthInfo(t *testing.T) {
+	rs, err := mcp.NewResourceServer(mcp.ResourceServerConfig{
+		Resource:             "https://mcp.example.com/mcp",
+		AuthorizationServers: []string{"https://auth.example.com"},
+		Validator: mcp.TokenValidatorFunc(func(ctx context.Context, token string) (*mcp.AuthInfo, error) {
+			return nil, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	rs.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request without auth info reached the handler")
+	})).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
@@ -58,6 +58,9 @@ type Prompt struct {
 	Arguments []PromptArgument `json:"arguments,omitempty"`
 	// Icons provides visual identifiers for the prompt
 	Icons []Icon `json:"icons,omitempty"`
+	// RequiredScopes are the OAuth scopes callers need. It is only used by
+	// the server and never sent to clients.
+	RequiredScopes []string `json:"-"`
 }
 
 // GetName returns the name of the prompt.
@@ -138,6 +141,13 @@ func WithPromptDescription(description string) PromptOption {
 	}
 }
 
+// WithPromptRequiredScopes sets the OAuth scopes needed to list and get the prompt.
+func WithPromptRequiredScopes(scopes ...string) PromptOption {
+	return func(p *Prompt) {
+		p.RequiredScopes = scopes
+	}
+}
+
 // WithPromptIcons adds icons to the Prompt.
 // Icons provide visual identifiers for the prompt.
 func WithPromptIcons(icons ...Icon) PromptOption {
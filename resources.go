@@ -143,3 +143,18 @@ func WithTemplateIcons(icons ...Icon) ResourceTemplateOption {
 		rt.Icons = icons
 	}
 }
+
+// WithResourceRequiredScopes sets the OAuth scopes needed to list and read the Resource.
+func WithResourceRequiredScopes(scopes ...string) ResourceOption {
+	return func(r *Resource) {
+		r.RequiredScopes = scopes
+	}
+}
+
+// WithTemplateRequiredScopes sets the OAuth scopes needed to list the
+// ResourceTemplate and read the resources matching it.
+func WithTemplateRequiredScopes(scopes ...string) ResourceTemplateOption {
+	return func(rt *ResourceTemplate) {
+		rt.RequiredScopes = scopes
+	}
+}
@@ -0,0 +1,200 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// DefaultSamplingLoopIterations is the number of sampling requests
+// SampleWithTools sends at most.
+const DefaultSamplingLoopIterations = 10
+
+// SamplingLoopOption configures SampleWithTools.
+type SamplingLoopOption func(*samplingLoop)
+
+type samplingLoop struct {
+	toolNames     []string
+	maxIterations int
+}
+
+// WithSamplingLoopTools offers only the named tools to the model. By
+// default, the tools the client would get from tools/list are offered.
+func WithSamplingLoopTools(names ...string) SamplingLoopOption {
+	return func(l *samplingLoop) {
+		l.toolNames = names
+	}
+}
+
+// WithSamplingLoopMaxIterations limits the number of sampling requests.
+// Defaults to DefaultSamplingLoopIterations.
+func WithSamplingLoopMaxIterations(n int) SamplingLoopOption {
+	return func(l *samplingLoop) {
+		l.maxIterations = n
+	}
+}
+
+// SamplingLoopResult is the outcome of SampleWithTools.
+type SamplingLoopResult struct {
+	// Result is the model's final response, which uses no tools.
+	Result *CreateMessageResult
+	// Messages is the whole conversation: the messages of the request, the
+	// tool uses and tool results, and the final response.
+	Messages []SamplingMessage
+}
+
+// SampleWithTools asks the client of the current request for a completion
+// in which the model may use the server's own tools. While the model
+// answers with tool uses, the tools are called through the server, with its
+// scopes and middlewares, and their results are sent back in a new sampling
+// request. The loop ends when the model answers without using tools.
+//
+// Unless request.Tools is set, the tools are those the client would get
+// from tools/list, or those named with WithSamplingLoopTools. The last
+// request the iteration limit allows sets ToolChoiceNone so that the model
+// answers; if it still uses tools, ErrSamplingLoopLimit is returned.
+//
+// It must be called from a request handler, such as a tool handler, of a
+// session whose client declared the sampling tools capability.
+func (s *MCPServer) SampleWithTools(ctx context.Context, request CreateMessageRequest, options ...SamplingLoopOption) (*SamplingLoopResult, error) {
+	loop := samplingLoop{maxIterations: DefaultSamplingLoopIterations}
+	for _, opt := range options {
+		opt(&loop)
+	}
+
+	session, ok := ClientSessionFromContext(ctx).(SessionWithSampling)
+	if !ok {
+		return nil, ErrSessionDoesNotSupportSampling
+	}
+	if info, ok := session.(SessionWithClientInfo); ok && !supportsSamplingTools(info.GetClientCapabilities()) {
+		return nil, ErrSamplingToolsNotSupported
+	}
+
+	if len(request.Tools) == 0 {
+		tools, err := s.samplingTools(ctx, loop.toolNames)
+		if err != nil {
+			return nil, err
+		}
+		request.Tools = tools
+	}
+	messages := slices.Clone(request.Messages)
+
+	for i := 0; i < loop.maxIterations; i++ {
+		current := request
+		current.Messages = messages
+		if i == loop.maxIterations-1 {
+			current.ToolChoice = &ToolChoice{Mode: ToolChoiceNone}
+		}
+		result, err := session.RequestSampling(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, result.SamplingMessage)
+
+		uses := toolUses(result.Content)
+		if len(uses) == 0 {
+			return &SamplingLoopResult{Result: result, Messages: messages}, nil
+		}
+		results := make([]Content, len(uses))
+		for j, use := range uses {
+			results[j] = s.callSamplingTool(ctx, use, request.Tools)
+		}
+		messages = append(messages, SamplingMessage{Role: RoleUser, Content: results})
+	}
+	return nil, fmt.Errorf("%w after %d sampling requests", ErrSamplingLoopLimit, loop.maxIterations)
+}
+
+// samplingTools returns the tools listed to the client, or the named ones.
+// Tools requiring task augmentation are left out since their results are
+// not available within a sampling request.
+func (s *MCPServer) samplingTools(ctx context.Context, names []string) ([]Tool, error) {
+	var tools []Tool
+	var request ListToolsRequest
+	for {
+		result, reqErr := s.handleListTools(ctx, nil, request)
+		if reqErr != nil {
+			return nil, reqErr.err
+		}
+		for _, tool := range result.Tools {
+			if tool.Execution == nil || tool.Execution.TaskSupport != TaskSupportRequired {
+				tools = append(tools, tool)
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		request.Params.Cursor = result.NextCursor
+	}
+	if len(names) == 0 {
+		return tools, nil
+	}
+
+	selected := make([]Tool, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(tools, func(tool Tool) bool { return tool.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("tool '%s' not found: %w", name, ErrToolNotFound)
+		}
+		selected = append(selected, tools[i])
+	}
+	return selected, nil
+}
+
+// supportsSamplingTools reports whether a client declared the sampling
+// tools capability.
+func supportsSamplingTools(capabilities ClientCapabilities) bool {
+	return capabilities.Sampling != nil && capabilities.Sampling.Tools != nil
+}
+
+// checkSamplingTools returns ErrSamplingToolsNotSupported if request offers
+// tools, or a tool choice, to a client without the sampling tools
+// capability.
+func checkSamplingTools(capabilities ClientCapabilities, request CreateMessageRequest) error {
+	if len(request.Tools) == 0 && request.ToolChoice == nil {
+		return nil
+	}
+	if !supportsSamplingTools(capabilities) {
+		return ErrSamplingToolsNotSupported
+	}
+	return nil
+}
+
+// callSamplingTool calls the tool of a tool use. Failures, including uses
+// of tools that were not offered, are reported to the model as error
+// results so that it can recover.
+func (s *MCPServer) callSamplingTool(ctx context.Context, use ToolUseContent, offered []Tool) ToolResultContent {
+	if !slices.ContainsFunc(offered, func(tool Tool) bool { return tool.Name == use.Name }) {
+		return NewToolResultContent(use.ID, NewToolResultError(fmt.Sprintf("tool '%s' is not available", use.Name)))
+	}
+
+	request := CallToolRequest{}
+	request.Method = string(MethodToolsCall)
+	request.Params.Name = use.Name
+	request.Params.Arguments = use.Input
+	result, reqErr := s.handleToolCall(ctx, use.ID, request)
+	if reqErr != nil {
+		return NewToolResultContent(use.ID, NewToolResultError(reqErr.err.Error()))
+	}
+	callResult, ok := result.(*CallToolResult)
+	if !ok {
+		return NewToolResultContent(use.ID, NewToolResultError(fmt.Sprintf("tool '%s' returned no result", use.Name)))
+	}
+	return NewToolResultContent(use.ID, callResult)
+}
+
+// toolUses returns the tool uses in the content of a sampling result.
+func toolUses(content any) []ToolUseContent {
+	switch c := content.(type) {
+	case ToolUseContent:
+		return []ToolUseContent{c}
+	case []Content:
+		var uses []ToolUseContent
+		for _, item := range c {
+			if use, ok := item.(ToolUseContent); ok {
+				uses = append(uses, use)
+			}
+		}
+		return uses
+	}
+	return nil
+}
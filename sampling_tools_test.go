@@ -0,0 +1,226 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func TestToolUseContent_JSON(t *testing.T) {
+	use := mcp.NewToolUseContent("call-1", "get_weather", map[string]any{"city": "Paris"})
+	data, err := json.Marshal(use)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"tool_use","id":"call-1","name":"get_weather","input":{"city":"Paris"}}`, string(data))
+
+	content, err := mcp.UnmarshalContent(data)
+	require.NoError(t, err)
+	assert.Equal(t, use, content)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	content, err = mcp.ParseContent(raw)
+	require.NoError(t, err)
+	assert.Equal(t, use, content)
+}
+
+func TestToolResultContent_JSON(t *testing.T) {
+	result := mcp.NewToolResultContent("call-1", mcp.NewToolResultError("city not found"))
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"tool_result","toolUseId":"call-1","content":[{"type":"text","text":"city not found"}],"isError":true}`, string(data))
+
+	content, err := mcp.UnmarshalContent(data)
+	require.NoError(t, err)
+	assert.Equal(t, result, content)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	content, err = mcp.ParseContent(raw)
+	require.NoError(t, err)
+	assert.Equal(t, result, content)
+}
+
+// serveSamplingTools serves an "ask" tool over WebSocket that runs
+// SampleWithTools, and returns a client sampling with sampler.
+func serveSamplingTools(
+	t *testing.T,
+	sampler samplingHandlerFunc,
+	clientOptions []mcp.ClientOption,
+	loopOptions ...mcp.SamplingLoopOption,
+) (*mcp.Client, chan *mcp.SamplingLoopResult, chan error) {
+	t.Helper()
+	server := mcp.NewMCPServer("sampling-tools-server", "1.0.0")
+	server.AddTool(
+		mcp.NewTool("get_weather", mcp.WithString("city", mcp.Required())),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			city, err := request.RequireString("city")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Sunny in %s", city)), nil
+		},
+	)
+	results := make(chan *mcp.SamplingLoopResult, 1)
+	errs := make(chan error, 1)
+	server.AddTool(mcp.NewTool("ask"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := server.SampleWithTools(ctx, mcp.CreateMessageRequest{CreateMessageParams: mcp.CreateMessageParams{
+			Messages:  []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent("What is the weather in Paris?")}},
+			MaxTokens: 100,
+		}}, loopOptions...)
+		results <- result
+		errs <- err
+		return mcp.NewToolResultText("done"), nil
+	})
+	httpServer := httptest.NewServer(mcp.NewWebSocketServer(server))
+	t.Cleanup(httpServer.Close)
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	client := mcp.NewClient(transport, append([]mcp.ClientOption{mcp.WithSamplingHandler(sampler)}, clientOptions...)...)
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "ask"
+	_, err = client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	return client, results, errs
+}
+
+func TestSampleWithTools(t *testing.T) {
+	var mu sync.Mutex
+	var requests []mcp.CreateMessageParams
+	sampler := func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		mu.Lock()
+		requests = append(requests, request.CreateMessageParams)
+		mu.Unlock()
+		if len(request.Messages) == 1 {
+			return &mcp.CreateMessageResult{
+				SamplingMessage: mcp.SamplingMessage{
+					Role: mcp.RoleAssistant,
+					Content: []mcp.Content{
+						mcp.NewToolUseContent("call-1", "get_weather", map[string]any{"city": "Paris"}),
+						mcp.NewToolUseContent("call-2", "ask", nil),
+					},
+				},
+				Model:      "test-model",
+				StopReason: mcp.StopReasonToolUse,
+			}, nil
+		}
+		return &mcp.CreateMessageResult{
+			SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: mcp.NewTextContent("It is sunny in Paris.")},
+			Model:           "test-model",
+			StopReason:      mcp.StopReasonEndTurn,
+		}, nil
+	}
+
+	_, results, errs := serveSamplingTools(t, sampler,
+		[]mcp.ClientOption{mcp.WithSamplingTools()},
+		mcp.WithSamplingLoopTools("get_weather"),
+	)
+	require.NoError(t, <-errs)
+	result := <-results
+	assert.Equal(t, mcp.StopReasonEndTurn, result.Result.StopReason)
+	assert.Equal(t, "It is sunny in Paris.", result.Result.Content.(mcp.TextContent).Text)
+	require.Len(t, result.Messages, 4)
+
+	require.Len(t, requests, 2)
+	require.Len(t, requests[0].Tools, 1)
+	assert.Equal(t, "get_weather", requests[0].Tools[0].Name)
+
+	// The tool results are sent back in a user message
+	toolResults := requests[1].Messages[2]
+	assert.Equal(t, mcp.RoleUser, toolResults.Role)
+	content := toolResults.Content.([]mcp.Content)
+	require.Len(t, content, 2)
+	weather := content[0].(mcp.ToolResultContent)
+	assert.Equal(t, "call-1", weather.ToolUseID)
+	assert.False(t, weather.IsError)
+	assert.Equal(t, "Sunny in Paris", weather.Content[0].(mcp.TextContent).Text)
+	// The model cannot use tools it was not offered
+	ask := content[1].(mcp.ToolResultContent)
+	assert.Equal(t, "call-2", ask.ToolUseID)
+	assert.True(t, ask.IsError)
+}
+
+func TestSampleWithTools_IterationLimit(t *testing.T) {
+	var mu sync.Mutex
+	var choices []*mcp.ToolChoice
+	sampler := func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		mu.Lock()
+		choices = append(choices, request.ToolChoice)
+		mu.Unlock()
+		return &mcp.CreateMessageResult{
+			SamplingMessage: mcp.SamplingMessage{
+				Role:    mcp.RoleAssistant,
+				Content: mcp.NewToolUseContent("call", "get_weather", map[string]any{"city": "Paris"}),
+			},
+			Model:      "test-model",
+			StopReason: mcp.StopReasonToolUse,
+		}, nil
+	}
+
+	_, _, errs := serveSamplingTools(t, sampler,
+		[]mcp.ClientOption{mcp.WithSamplingTools()},
+		mcp.WithSamplingLoopMaxIterations(2),
+	)
+	assert.ErrorIs(t, <-errs, mcp.ErrSamplingLoopLimit)
+	require.Len(t, choices, 2)
+	assert.Nil(t, choices[0])
+	assert.Equal(t, mcp.ToolChoiceNone, choices[1].Mode)
+}
+
+func TestSampleWithTools_NotSupported(t *testing.T) {
+	sampler := func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		t.Error("client without sampling tools was sampled")
+		return nil, nil
+	}
+	_, _, errs := serveSamplingTools(t, sampler, nil)
+	assert.ErrorIs(t, <-errs, mcp.ErrSamplingToolsNotSupported)
+
+	server := mcp.NewMCPServer("server", "1.0.0")
+	_, err := server.SampleWithTools(context.Background(), mcp.CreateMessageRequest{})
+	assert.ErrorIs(t, err, mcp.ErrSessionDoesNotSupportSampling)
+}
+
+func TestRequestSampling_ToolsNotSupported(t *testing.T) {
+	server := mcp.NewMCPServer("sampling-tools-server", "1.0.0")
+	errs := make(chan error, 1)
+	server.AddTool(mcp.NewTool("ask"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session := mcp.ClientSessionFromContext(ctx).(mcp.SessionWithSampling)
+		_, err := session.RequestSampling(ctx, mcp.CreateMessageRequest{CreateMessageParams: mcp.CreateMessageParams{
+			Messages:  []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent("What is the weather in Paris?")}},
+			MaxTokens: 100,
+			Tools:     []mcp.Tool{mcp.NewTool("get_weather")},
+		}})
+		errs <- err
+		return mcp.NewToolResultText("done"), nil
+	})
+	httpServer := httptest.NewServer(mcp.NewWebSocketServer(server))
+	defer httpServer.Close()
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	sampler := samplingHandlerFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		t.Error("client without sampling tools was sent tools")
+		return nil, nil
+	})
+	client := mcp.NewClient(transport, mcp.WithSamplingHandler(sampler))
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "ask"
+	_, err = client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	assert.ErrorIs(t, <-errs, mcp.ErrSamplingToolsNotSupported)
+}
@@ -0,0 +1,219 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// validateJSONSchema checks value, decoded from JSON, against the subset of
+// JSON Schema used by tool and elicitation schemas: "type", "enum",
+// "properties", "required", "additionalProperties", "items", the limits
+// "minimum", "maximum", "minLength", "maxLength", "minItems" and
+// "maxItems", and the "email", "uri", "date" and "date-time" formats.
+// Other keywords are ignored rather than rejected. Errors name the invalid part
+// of value starting with root, e.g. "arguments.city".
+func validateJSONSchema(root string, value any, schema map[string]any) error {
+	return validateSchemaValue(root, value, schema)
+}
+
+// schemaToMap returns the JSON form of a schema value, such as a
+// ToolInputSchema or a map built by hand with []string values.
+func schemaToMap(schema any) (map[string]any, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func validateSchemaValue(path string, value any, schema map[string]any) error {
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		if !slices.ContainsFunc(types, func(t string) bool { return matchesSchemaType(value, t) }) {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		if !slices.ContainsFunc(enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+
+	if keyword, limit, ok := violatedSchemaLimit(value, schema); ok {
+		data, _ := json.Marshal(value)
+		return fmt.Errorf("%s: %s violates %s %v", path, data, keyword, limit)
+	}
+	if v, ok := value.(string); ok {
+		if format, _ := schema["format"].(string); !validSchemaFormat(v, format) {
+			return fmt.Errorf("%s: %q is not a valid %s", path, v, format)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if name, ok := name.(string); ok {
+					if _, present := v[name]; !present {
+						return fmt.Errorf("%s: missing required property %q", path, name)
+					}
+				}
+			}
+		}
+		// Sort the names so that the first error is deterministic
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertySchema, ok := properties[name].(map[string]any)
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+					propertySchema = additional
+				} else {
+					continue
+				}
+			}
+			if err := validateSchemaValue(path+"."+name, v[name], propertySchema); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validateSchemaValue(fmt.Sprintf("%s[%d]", path, i), item, items); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// violatedSchemaLimit returns the first of the "minLength", "maxLength",
+// "minimum", "maximum", "minItems" and "maxItems" keywords of schema that
+// value violates, with its limit.
+func violatedSchemaLimit(value any, schema map[string]any) (string, float64, bool) {
+	var size float64
+	var keywords [2]string
+	switch v := value.(type) {
+	case string:
+		size, keywords = float64(utf8.RuneCountInString(v)), [2]string{"minLength", "maxLength"}
+	case float64:
+		size, keywords = v, [2]string{"minimum", "maximum"}
+	case int64:
+		size, keywords = float64(v), [2]string{"minimum", "maximum"}
+	case []any:
+		size, keywords = float64(len(v)), [2]string{"minItems", "maxItems"}
+	default:
+		return "", 0, false
+	}
+	if limit, ok := schema[keywords[0]].(float64); ok && size < limit {
+		return keywords[0], limit, true
+	}
+	if limit, ok := schema[keywords[1]].(float64); ok && size > limit {
+		return keywords[1], limit, true
+	}
+	return "", 0, false
+}
+
+// validSchemaFormat reports whether value has format, which is one of
+// "email", "uri", "date" and "date-time". Other formats are not checked.
+func validSchemaFormat(value, format string) bool {
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	}
+	return true
+}
+
+// schemaTypes returns the types allowed by a "type" keyword, which is a
+// string or an array of strings.
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, s := range t {
+			if s, ok := s.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesSchemaType(value any, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	}
+	// Unknown types are not checked
+	return true
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
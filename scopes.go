@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// GrantedScopesFunc returns the OAuth scopes granted to the caller of a request.
+type GrantedScopesFunc func(ctx context.Context) []string
+
+// WithGrantedScopesFunc sets how the server determines the scopes granted to
+// callers when enforcing the RequiredScopes of tools, prompts and resources.
+// By default, they are the scopes of the token validated by a ResourceServer.
+func WithGrantedScopesFunc(fn GrantedScopesFunc) ServerOption {
+	return func(s *MCPServer) {
+		s.grantedScopes = fn
+	}
+}
+
+// authInfoScopes is the default GrantedScopesFunc.
+func authInfoScopes(ctx context.Context) []string {
+	if info, ok := AuthInfoFromContext(ctx); ok {
+		return info.Scopes
+	}
+	return nil
+}
+
+// missingScopes returns the required scopes not granted to the caller.
+func (s *MCPServer) missingScopes(ctx context.Context, required []string) []string {
+	if len(required) == 0 {
+		return nil
+	}
+	granted := s.grantedScopes
+	if granted == nil {
+		granted = authInfoScopes
+	}
+	grantedScopes := granted(ctx)
+
+	var missing []string
+	for _, scope := range required {
+		if !slices.Contains(grantedScopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// filterByScopes keeps the items whose required scopes were all granted.
+func filterByScopes[T any](ctx context.Context, s *MCPServer, items []T, requiredScopes func(T) []string) []T {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if len(s.missingScopes(ctx, requiredScopes(item))) == 0 {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// scopeToolFilter is a ToolFilterFunc hiding the tools the caller lacks
+// scopes for. It runs before the filters registered with WithToolFilter.
+func (s *MCPServer) scopeToolFilter(ctx context.Context, tools []Tool) []Tool {
+	return filterByScopes(ctx, s, tools, func(tool Tool) []string { return tool.RequiredScopes })
+}
+
+// checkScopes returns an INSUFFICIENT_SCOPE error if the caller lacks any
+// of the scopes required by the named tool, prompt or resource.
+func (s *MCPServer) checkScopes(ctx context.Context, id any, kind, name string, required []string) *requestError {
+	missing := s.missingScopes(ctx, required)
+	if len(missing) == 0 {
+		return nil
+	}
+	return &requestError{
+		id:   id,
+		code: INSUFFICIENT_SCOPE,
+		err: fmt.Errorf(
+			"%s '%s' requires scopes %s: %w",
+			kind, name, strings.Join(missing, " "), ErrInsufficientScope,
+		),
+		data: insufficientScopeData{RequiredScopes: required, MissingScopes: missing},
+	}
+}
+
+// insufficientScopeData is the data of INSUFFICIENT_SCOPE errors.
+type insufficientScopeData struct {
+	RequiredScopes []string `json:"requiredScopes"`
+	MissingScopes  []string `json:"missingScopes"`
+}
+
+// newInsufficientScopeError decodes the data of an INSUFFICIENT_SCOPE error
+// received by a client.
+func newInsufficientScopeError(message string, data any) *InsufficientScopeError {
+	var scopes insufficientScopeData
+	if raw, err := json.Marshal(data); err == nil {
+		_ = json.Unmarshal(raw, &scopes)
+	}
+	return &InsufficientScopeError{
+		Message:        message,
+		RequiredScopes: scopes.RequiredScopes,
+		MissingScopes:  scopes.MissingScopes,
+	}
+}
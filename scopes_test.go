@@ -0,0 +1,208 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// newScopedServer creates a server with one public and one scoped tool,
+// prompt, resource and resource template.
+func newScopedServer(options ...mcp.ServerOption) *mcp.MCPServer {
+	server := mcp.NewMCPServer("scoped-server", "1.0.0", options...)
+
+	toolHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.Params.Name), nil
+	}
+	server.AddTool(mcp.NewTool("public-tool"), toolHandler)
+	server.AddTool(mcp.NewTool("admin-tool", mcp.WithRequiredScopes("admin", "write")), toolHandler)
+
+	promptHandler := func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return mcp.NewGetPromptResult(request.Params.Name, []mcp.PromptMessage{}), nil
+	}
+	server.AddPrompt(mcp.NewPrompt("public-prompt"), promptHandler)
+	server.AddPrompt(mcp.NewPrompt("admin-prompt", mcp.WithPromptRequiredScopes("admin")), promptHandler)
+
+	resourceHandler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, Text: "content"}}, nil
+	}
+	server.AddResource(mcp.NewResource("file:///public", "public-resource"), resourceHandler)
+	server.AddResource(mcp.NewResource("file:///secret", "secret-resource", mcp.WithResourceRequiredScopes("read:secrets")), resourceHandler)
+	server.AddResourceTemplate(
+		mcp.NewResourceTemplate("secrets://{name}", "secret-template", mcp.WithTemplateRequiredScopes("read:secrets")),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return resourceHandler(ctx, request)
+		},
+	)
+	return server
+}
+
+func withScopes(scopes ...string) context.Context {
+	return mcp.WithAuthInfo(context.Background(), &mcp.AuthInfo{Subject: "user", Scopes: scopes})
+}
+
+func startScopedClient(t *testing.T, server *mcp.MCPServer) *mcp.Client {
+	t.Helper()
+	client, err := mcp.NewInProcessClient(server)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+	return client
+}
+
+func TestScopes_FilterLists(t *testing.T) {
+	client := startScopedClient(t, newScopedServer())
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		tools     []string
+		prompts   []string
+		resources []string
+		templates []string
+	}{
+		{
+			name:      "no token",
+			ctx:       context.Background(),
+			tools:     []string{"public-tool"},
+			prompts:   []string{"public-prompt"},
+			resources: []string{"public-resource"},
+			templates: []string{},
+		},
+		{
+			name:      "some scopes",
+			ctx:       withScopes("admin", "read:secrets"),
+			tools:     []string{"public-tool"},
+			prompts:   []string{"admin-prompt", "public-prompt"},
+			resources: []string{"public-resource", "secret-resource"},
+			templates: []string{"secret-template"},
+		},
+		{
+			name:      "all scopes",
+			ctx:       withScopes("admin", "write", "read:secrets"),
+			tools:     []string{"admin-tool", "public-tool"},
+			prompts:   []string{"admin-prompt", "public-prompt"},
+			resources: []string{"public-resource", "secret-resource"},
+			templates: []string{"secret-template"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools, err := client.ListTools(tt.ctx, mcp.ListToolsRequest{})
+			require.NoError(t, err)
+			toolNames := []string{}
+			for _, tool := range tools.Tools {
+				toolNames = append(toolNames, tool.Name)
+			}
+			assert.Equal(t, tt.tools, toolNames)
+
+			prompts, err := client.ListPrompts(tt.ctx, mcp.ListPromptsRequest{})
+			require.NoError(t, err)
+			promptNames := []string{}
+			for _, prompt := range prompts.Prompts {
+				promptNames = append(promptNames, prompt.Name)
+			}
+			assert.Equal(t, tt.prompts, promptNames)
+
+			resources, err := client.ListResources(tt.ctx, mcp.ListResourcesRequest{})
+			require.NoError(t, err)
+			resourceNames := []string{}
+			for _, resource := range resources.Resources {
+				resourceNames = append(resourceNames, resource.Name)
+			}
+			assert.Equal(t, tt.resources, resourceNames)
+
+			templates, err := client.ListResourceTemplates(tt.ctx, mcp.ListResourceTemplatesRequest{})
+			require.NoError(t, err)
+			templateNames := []string{}
+			for _, template := range templates.ResourceTemplates {
+				templateNames = append(templateNames, template.Name)
+			}
+			assert.Equal(t, tt.templates, templateNames)
+		})
+	}
+}
+
+func TestScopes_RejectCalls(t *testing.T) {
+	client := startScopedClient(t, newScopedServer())
+	ctx := withScopes("admin")
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = "admin-tool"
+	_, err := client.CallTool(ctx, callRequest)
+	var scopeErr *mcp.InsufficientScopeError
+	require.ErrorAs(t, err, &scopeErr)
+	assert.ErrorIs(t, err, mcp.ErrInsufficientScope)
+	assert.Equal(t, []string{"admin", "write"}, scopeErr.RequiredScopes)
+	assert.Equal(t, []string{"write"}, scopeErr.MissingScopes)
+
+	result, err := client.CallTool(withScopes("admin", "write"), callRequest)
+	require.NoError(t, err)
+	assert.Equal(t, "admin-tool", result.Content[0].(mcp.TextContent).Text)
+
+	promptRequest := mcp.GetPromptRequest{}
+	promptRequest.Params.Name = "admin-prompt"
+	_, err = client.GetPrompt(context.Background(), promptRequest)
+	require.ErrorAs(t, err, &scopeErr)
+	assert.Equal(t, []string{"admin"}, scopeErr.MissingScopes)
+	_, err = client.GetPrompt(ctx, promptRequest)
+	assert.NoError(t, err)
+
+	for _, uri := range []string{"file:///secret", "secrets://key"} {
+		readRequest := mcp.ReadResourceRequest{}
+		readRequest.Params.URI = uri
+		_, err = client.ReadResource(ctx, readRequest)
+		require.ErrorAs(t, err, &scopeErr, uri)
+		assert.Equal(t, []string{"read:secrets"}, scopeErr.MissingScopes)
+		_, err = client.ReadResource(withScopes("read:secrets"), readRequest)
+		assert.NoError(t, err, uri)
+	}
+}
+
+func TestScopes_ErrorData(t *testing.T) {
+	server := newScopedServer()
+	response := server.HandleMessage(withScopes("write"), []byte(`{
+		"jsonrpc": "2.0",
+		"id": 1,
+		"method": "tools/call",
+		"params": {"name": "admin-tool"}
+	}`))
+
+	errorResponse, _ := response.(mcp.JSONRPCError)
+	require.IsType(t, mcp.JSONRPCError{}, response)
+	assert.Equal(t, mcp.INSUFFICIENT_SCOPE, errorResponse.Error.Code)
+
+	data, err := json.Marshal(errorResponse.Error.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"requiredScopes": ["admin", "write"], "missingScopes": ["admin"]}`, string(data))
+}
+
+func TestScopes_GrantedScopesFunc(t *testing.T) {
+	type rolesKey struct{}
+	server := newScopedServer(mcp.WithGrantedScopesFunc(func(ctx context.Context) []string {
+		roles, _ := ctx.Value(rolesKey{}).([]string)
+		return roles
+	}))
+	client := startScopedClient(t, server)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "admin-tool"
+	_, err := client.CallTool(withScopes("admin", "write"), request)
+	assert.True(t, errors.Is(err, mcp.ErrInsufficientScope))
+
+	ctx := context.WithValue(context.Background(), rolesKey{}, []string{"admin", "write"})
+	_, err = client.CallTool(ctx, request)
+	assert.NoError(t, err)
+}
+
+func TestScopes_NotSerialized(t *testing.T) {
+	data, err := json.Marshal(mcp.NewTool("scoped-tool", mcp.WithRequiredScopes("admin")))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "admin")
+}
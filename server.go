@@ -49,6 +49,9 @@ type taskEntry struct {
 	cancelFunc context.CancelFunc // Function to cancel the task
 	done       chan struct{}      // Channel to signal task completion
 	completed  bool               // Whether the task has been completed (guards done channel closure)
+	priority   int                // Scheduling priority while waiting in the task queue
+	queued     bool               // Whether the task is waiting in the task queue
+	start      func()             // Starts execution once the task leaves the queue
 }
 
 // ServerOption is a function that configures an MCPServer.
@@ -151,6 +154,7 @@ type requestError struct {
 	id   any
 	code int
 	err  error
+	data any
 }
 
 func (e *requestError) Error() string {
@@ -161,7 +165,7 @@ func (e *requestError) ToJSONRPCError() JSONRPCError {
 	return JSONRPCError{
 		JSONRPC: JSONRPC_VERSION,
 		ID:      NewRequestId(e.id),
-		Error:   NewJSONRPCErrorDetails(e.code, e.err.Error(), nil),
+		Error:   NewJSONRPCErrorDetails(e.code, e.err.Error(), e.data),
 	}
 }
 
@@ -198,6 +202,7 @@ type MCPServer struct {
 	toolHandlerMiddlewares     []ToolHandlerMiddleware
 	resourceHandlerMiddlewares []ResourceHandlerMiddleware
 	toolFilters                []ToolFilterFunc
+	grantedScopes              GrantedScopesFunc
 	notificationHandlers       map[string]NotificationHandlerFunc
 	promptCompletionProvider   PromptCompletionProvider
 	resourceCompletionProvider ResourceCompletionProvider
@@ -210,6 +215,8 @@ type MCPServer struct {
 	expiredTasks               map[string]time.Time // Tracks recently expired task IDs with expiration timestamp
 	maxConcurrentTasks         *int                 // Optional limit on concurrent running tasks
 	activeTasks                int                  // Current count of running (non-terminal) tasks
+	taskNotificationInterval   time.Duration        // Minimum interval between TaskHandle notifications per task
+	taskQueue                  *taskQueue           // Optional queue for tasks exceeding maxConcurrentTasks
 }
 
 // WithPaginationLimit sets the pagination limit for the 
@@ -378,6 +385,30 @@ func WithMaxConcurrentTasks(limit int) ServerOption {
 	}
 }
 
+// WithTaskQueue makes task-augmented calls that exceed the WithMaxConcurrentTasks
+// limit wait in a queue instead of failing. Queued tasks are reported as
+// "working" with a "queued, position N" status message and start as soon as
+// running tasks finish. Tasks with a higher priority (see WithTaskPriority and
+// TaskPriorityMetaKey) start first; tasks with the same priority are taken
+// from the waiting sessions in turn so that one client cannot starve others.
+// maxQueued bounds the number of waiting tasks; once it is reached new tasks
+// fail as without a queue. A value of 0 means the queue is unbounded.
+func WithTaskQueue(maxQueued int) ServerOption {
+	return func(s *MCPServer) {
+		s.taskQueue = newTaskQueue(maxQueued)
+	}
+}
+
+// WithTaskNotificationInterval sets the minimum interval between status or
+// progress notifications sent through a TaskHandle for the same task.
+// Faster updates are coalesced. A value of 0 disables rate limiting.
+// Defaults to DefaultTaskNotificationInterval.
+func WithTaskNotificationInterval(interval time.Duration) ServerOption {
+	return func(s *MCPServer) {
+		s.taskNotificationInterval = interval
+	}
+}
+
 // WithPromptCapabilities configures prompt-related server capabilities
 func WithPromptCapabilities(listChanged bool) ServerOption {
 	return func(s *MCPServer) {
@@ -464,6 +495,7 @@ func NewMCPServer(
 		notificationHandlers:       make(map[string]NotificationHandlerFunc),
 		tasks:                      make(map[string]*taskEntry),
 		expiredTasks:               make(map[string]time.Time),
+		taskNotificationInterval:   DefaultTaskNotificationInterval,
 		promptCompletionProvider:   &DefaultPromptCompletionProvider{},
 		resourceCompletionProvider: &DefaultResourceCompletionProvider{},
 		capabilities: serverCapabilities{
@@ -1040,6 +1072,7 @@ func (s *MCPServer) handleListResources(
 	resourcesList := slices.SortedFunc(maps.Values(resourceMap), func(a, b Resource) int {
 		return cmp.Compare(a.Name, b.Name)
 	})
+	resourcesList = filterByScopes(ctx, s, resourcesList, func(r Resource) []string { return r.RequiredScopes })
 
 	// Apply pagination
 	resourcesToReturn, nextCursor, err := listByPagination(
@@ -1105,6 +1138,7 @@ func (s *MCPServer) handleListResourceTemplates(
 	sort.Slice(templates, func(i, j int) bool {
 		return templates[i].Name < templates[j].Name
 	})
+	templates = filterByScopes(ctx, s, templates, func(t ResourceTemplate) []string { return t.RequiredScopes })
 	templatesToReturn, nextCursor, err := listByPagination(
 		ctx,
 		s,
@@ -1136,6 +1170,7 @@ func (s *MCPServer) handleReadResource(
 
 	// First check session-specific resources
 	var handler ResourceHandlerFunc
+	var requiredScopes []string
 	var ok bool
 
 	session := ClientSessionFromContext(ctx)
@@ -1145,6 +1180,7 @@ func (s *MCPServer) handleReadResource(
 				resource, sessionOk := sessionResources[request.Params.URI]
 				if sessionOk {
 					handler = resource.Handler
+					requiredScopes = resource.Resource.RequiredScopes
 					ok = true
 				}
 			}
@@ -1156,6 +1192,7 @@ func (s *MCPServer) handleReadResource(
 		globalResource, rok := s.resources[request.Params.URI]
 		if rok {
 			handler = globalResource.handler
+			requiredScopes = globalResource.resource.RequiredScopes
 			ok = true
 		}
 	}
@@ -1163,6 +1200,9 @@ func (s *MCPServer) handleReadResource(
 	// First try direct resource handlers
 	if ok {
 		s.resourcesMu.RUnlock()
+		if err := s.checkScopes(ctx, id, "resource", request.Params.URI, requiredScopes); err != nil {
+			return nil, err
+		}
 
 		finalHandler := handler
 		s.resourceMiddlewareMu.RLock()
@@ -1198,6 +1238,7 @@ func (s *MCPServer) handleReadResource(
 				}
 				if matchesTemplate(request.Params.URI, serverTemplate.Template.URITemplate) {
 					matchedHandler = serverTemplate.Handler
+					requiredScopes = serverTemplate.Template.RequiredScopes
 					matched = true
 					matchedVars := serverTemplate.Template.URITemplate.Match(request.Params.URI)
 					// Convert matched variables to a map
@@ -1220,6 +1261,7 @@ func (s *MCPServer) handleReadResource(
 			}
 			if matchesTemplate(request.Params.URI, template.URITemplate) {
 				matchedHandler = entry.handler
+				requiredScopes = template.RequiredScopes
 				matched = true
 				matchedVars := template.URITemplate.Match(request.Params.URI)
 				// Convert matched variables to a map
@@ -1234,6 +1276,9 @@ func (s *MCPServer) handleReadResource(
 	s.resourcesMu.RUnlock()
 
 	if matched {
+		if err := s.checkScopes(ctx, id, "resource", request.Params.URI, requiredScopes); err != nil {
+			return nil, err
+		}
 		// If a match is found, then we have a final handler and can
 		// apply middlewares.
 		s.resourceMiddlewareMu.RLock()
@@ -1287,6 +1332,7 @@ func (s *MCPServer) handleListPrompts(
 	sort.Slice(prompts, func(i, j int) bool {
 		return prompts[i].Name < prompts[j].Name
 	})
+	prompts = filterByScopes(ctx, s, prompts, func(p Prompt) []string { return p.RequiredScopes })
 	promptsToReturn, nextCursor, err := listByPagination(
 		ctx,
 		s,
@@ -1316,6 +1362,7 @@ func (s *MCPServer) handleGetPrompt(
 ) (*GetPromptResult, *requestError) {
 	s.promptsMu.RLock()
 	handler, ok := s.promptHandlers[request.Params.Name]
+	prompt := s.prompts[request.Params.Name]
 	s.promptsMu.RUnlock()
 
 	if !ok {
@@ -1325,6 +1372,9 @@ func (s *MCPServer) handleGetPrompt(
 			err:  fmt.Errorf("prompt '%s' not found: %w", request.Params.Name, ErrPromptNotFound),
 		}
 	}
+	if err := s.checkScopes(ctx, id, "prompt", request.Params.Name, prompt.RequiredScopes); err != nil {
+		return nil, err
+	}
 
 	result, err := handler(ctx, request)
 	if err != nil {
@@ -1402,7 +1452,8 @@ func (s *MCPServer) handleListTools(
 		}
 	}
 
-	// Apply tool filters if any are defined
+	// Hide the tools the caller lacks scopes for, then apply tool filters if any are defined
+	tools = s.scopeToolFilter(ctx, tools)
 	s.toolFiltersMu.RLock()
 	if len(s.toolFilters) > 0 {
 		for _, filter := range s.toolFilters {
@@ -1484,6 +1535,10 @@ func (s *MCPServer) handleToolCall(
 		}
 	}
 
+	if err := s.checkScopes(ctx, id, "tool", request.Params.Name, tool.Tool.RequiredScopes); err != nil {
+		return nil, err
+	}
+
 	// Validate task support requirements
 	if tool.Tool.Execution != nil && tool.Tool.Execution.TaskSupport == TaskSupportRequired {
 		if request.Params.Task == nil {
@@ -1583,8 +1638,14 @@ func (s *MCPServer) handleTaskAugmentedToolCall(
 		ttl = request.Params.Task.TTL
 	}
 
+	// Determine the queue priority from the request _meta or the tool
+	priority := taskPriority(regularTool.Tool, request)
+	if hasTaskHandler {
+		priority = taskPriority(toolToUse.Tool, request)
+	}
+
 	// Create task entry (pollInterval is nil - server doesn't set a default)
-	entry, err := s.createTask(ctx, taskID, request.Params.Name, ttl, nil)
+	entry, err := s.createPrioritizedTask(ctx, taskID, request.Params.Name, ttl, nil, priority)
 	if err != nil {
 		return nil, &requestError{
 			id:   id,
@@ -1593,14 +1654,16 @@ func (s *MCPServer) handleTaskAugmentedToolCall(
 		}
 	}
 
-	// Execute tool asynchronously
+	// Execute tool asynchronously, possibly after waiting in the task queue
 	// For regular tools being used as tasks, we need different execution logic
-	if hasTaskHandler {
-		go s.executeTaskTool(ctx, entry, toolToUse, request)
-	} else {
-		// Execute regular tool wrapped as a task
-		go s.executeRegularToolAsTask(ctx, entry, regularTool, request)
-	}
+	s.runOrQueueTask(entry, func() {
+		if hasTaskHandler {
+			go s.executeTaskTool(ctx, entry, toolToUse, request)
+		} else {
+			// Execute regular tool wrapped as a task
+			go s.executeRegularToolAsTask(ctx, entry, regularTool, request)
+		}
+	})
 
 	// Return CreateTaskResult immediately with task as top-level field
 	// Make a copy of the task to avoid data races with background goroutine
@@ -1631,6 +1694,9 @@ func (s *MCPServer) executeTaskTool(
 	entry.cancelFunc = cancel
 	s.tasksMu.Unlock()
 
+	// Expose the task to the handler via TaskFromContext
+	taskCtx = s.withTaskHandle(taskCtx, entry, request)
+
 	// Execute the task tool handler
 	result, err := taskTool.Handler(taskCtx, request)
 
@@ -1658,8 +1724,8 @@ func (s *MCPServer) executeTaskTool(
 					entry.completed = true
 					close(entry.done)
 
-					// Decrement active tasks counter
-					s.activeTasks--
+					// Release the execution slot
+					s.releaseTaskSlot(entry)
 
 					s.sendTaskStatusNotification(entry.task)
 
@@ -1710,6 +1776,9 @@ func (s *MCPServer) executeRegularToolAsTask(
 	entry.cancelFunc = cancel
 	s.tasksMu.Unlock()
 
+	// Expose the task to the handler via TaskFromContext
+	taskCtx = s.withTaskHandle(taskCtx, entry, request)
+
 	// Execute the regular tool handler
 	result, err := regularTool.Handler(taskCtx, request)
 
@@ -1737,8 +1806,8 @@ func (s *MCPServer) executeRegularToolAsTask(
 					entry.completed = true
 					close(entry.done)
 
-					// Decrement active tasks counter
-					s.activeTasks--
+					// Release the execution slot
+					s.releaseTaskSlot(entry)
 
 					s.sendTaskStatusNotification(entry.task)
 
@@ -2032,6 +2101,13 @@ func (s *MCPServer) handleComplete(
 // createTask creates a new task entry and returns it.
 // Returns an error if the max concurrent tasks limit is exceeded.
 func (s *MCPServer) createTask(ctx context.Context, taskID string, toolName string, ttl *int64, pollInterval *int64) (*taskEntry, error) {
+	return s.createPrioritizedTask(ctx, taskID, toolName, ttl, pollInterval, 0)
+}
+
+// createPrioritizedTask creates a new task entry with the given queue priority.
+// If the max concurrent tasks limit is reached and a task queue is configured,
+// the task is queued instead of failing; callers start it via runOrQueueTask.
+func (s *MCPServer) createPrioritizedTask(ctx context.Context, taskID string, toolName string, ttl *int64, pollInterval *int64, priority int) (*taskEntry, error) {
 	// Build task entry first (no lock needed)
 	opts := []TaskOption{}
 	if ttl != nil {
@@ -2049,21 +2125,27 @@ func (s *MCPServer) createTask(ctx context.Context, taskID string, toolName stri
 		toolName:  toolName,
 		createdAt: createdAt,
 		done:      make(chan struct{}),
+		priority:  priority,
 	}
 
 	// Single critical section for check + increment + insert
 	s.tasksMu.Lock()
 	defer s.tasksMu.Unlock()
 
-	// Check concurrent task limit
-	if s.maxConcurrentTasks != nil && *s.maxConcurrentTasks > 0 {
-		if s.activeTasks >= *s.maxConcurrentTasks {
+	// Check concurrent task limit, queueing the task if possible
+	if !s.hasTaskSlot() {
+		if s.taskQueue == nil || s.taskQueue.full() {
 			return nil, fmt.Errorf("max concurrent tasks limit reached (%d)", *s.maxConcurrentTasks)
 		}
+		entry.queued = true
 	}
 
-	// Increment active task counter and insert task atomically
-	s.activeTasks++
+	// Reserve a slot or a queue position and insert task atomically
+	if entry.queued {
+		s.taskQueue.push(entry)
+	} else {
+		s.activeTasks++
+	}
 	s.tasks[taskID] = entry
 
 	// Fire task created hook
@@ -2078,6 +2160,10 @@ func (s *MCPServer) createTask(ctx context.Context, taskID string, toolName stri
 		s.taskHooks.taskCreated(ctx, metrics)
 	}
 
+	if entry.queued {
+		s.queueChanged()
+	}
+
 	// Start TTL cleanup if specified
 	if ttl != nil && *ttl > 0 {
 		go s.scheduleTaskCleanup(taskID, *ttl)
@@ -2187,8 +2273,8 @@ func (s *MCPServer) completeTask(entry *taskEntry, result any, err error) {
 	entry.completed = true
 	close(entry.done)
 
-	// Decrement active tasks counter
-	s.activeTasks--
+	// Release the execution slot or queue position
+	s.releaseTaskSlot(entry)
 
 	// Send task status notification
 	s.sendTaskStatusNotification(entry.task)
@@ -2247,8 +2333,8 @@ func (s *MCPServer) cancelTask(ctx context.Context, taskID string) error {
 	entry.completed = true
 	close(entry.done)
 
-	// Decrement active tasks counter
-	s.activeTasks--
+	// Release the execution slot or queue position
+	s.releaseTaskSlot(entry)
 
 	// Send task status notification
 	s.sendTaskStatusNotification(entry.task)
@@ -2276,6 +2362,31 @@ func (s *MCPServer) scheduleTaskCleanup(taskID string, ttlMs int64) {
 	time.Sleep(time.Duration(ttlMs) * time.Millisecond)
 
 	s.tasksMu.Lock()
+	if entry, ok := s.tasks[taskID]; ok && entry.queued {
+		// An expired task that never started gives up its queue position
+		// and fails, so that its client stops waiting for it
+		expiredAt := time.Now()
+		entry.task.Status = TaskStatusFailed
+		entry.task.StatusMessage = "Task expired before it started"
+		entry.task.LastUpdatedAt = expiredAt.UTC().Format(time.RFC3339)
+		entry.completed = true
+		close(entry.done)
+		s.releaseTaskSlot(entry)
+		s.sendTaskStatusNotification(entry.task)
+
+		if s.taskHooks != nil {
+			s.taskHooks.taskFailed(context.Background(), TaskMetrics{
+				TaskID:        entry.task.TaskId,
+				ToolName:      entry.toolName,
+				Status:        entry.task.Status,
+				StatusMessage: entry.task.StatusMessage,
+				CreatedAt:     entry.createdAt,
+				CompletedAt:   &expiredAt,
+				Duration:      expiredAt.Sub(entry.createdAt),
+				SessionID:     entry.sessionID,
+			})
+		}
+	}
 	delete(s.tasks, taskID)
 	// Record that this task expired for better error messages
 	// Keep the tombstone for 5 minutes to allow clients to distinguish
@@ -0,0 +1,222 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultTaskNotificationInterval is the minimum time between two status or
+// progress notifications emitted through a TaskHandle for the same task.
+const DefaultTaskNotificationInterval = 250 * time.Millisecond
+
+// taskHandleKey is the context key for storing the TaskHandle of a running task
+type taskHandleKey struct{}
+
+// TaskHandle gives code running inside a task-augmented tool call access to the
+// task it is executing for. It is obtained with TaskFromContext.
+//
+// Status and progress notifications sent through the handle are rate limited:
+// updates arriving faster than the server's task notification interval are
+// coalesced and only the most recent state is delivered.
+type TaskHandle struct {
+	server        *MCPServer
+	entry         *taskEntry
+	ctx           context.Context
+	progressToken ProgressToken
+
+	mu             sync.Mutex
+	status         taskNotifyThrottle
+	progress       taskNotifyThrottle
+	progressParams map[string]any // Latest progress update waiting to be sent
+}
+
+// taskNotifyThrottle tracks when a notification kind was last sent for a task
+// and whether a trailing send is already scheduled.
+type taskNotifyThrottle struct {
+	last    time.Time
+	pending *time.Timer
+}
+
+// TaskFromContext retrieves the TaskHandle of the task being executed.
+// It returns nil if ctx does not belong to a task-augmented tool call.
+func TaskFromContext(ctx context.Context) *TaskHandle {
+	if handle, ok := ctx.Value(taskHandleKey{}).(*TaskHandle); ok {
+		return handle
+	}
+	return nil
+}
+
+// withTaskHandle attaches a TaskHandle for entry to the task execution context.
+func (s *MCPServer) withTaskHandle(ctx context.Context, entry *taskEntry, request CallToolRequest) context.Context {
+	handle := &TaskHandle{
+		server: s,
+		entry:  entry,
+	}
+	if request.Params.Meta != nil {
+		handle.progressToken = request.Params.Meta.ProgressToken
+	}
+	ctx = context.WithValue(ctx, taskHandleKey{}, handle)
+	handle.ctx = ctx
+	return ctx
+}
+
+// TaskID returns the ID of the task.
+func (h *TaskHandle) TaskID() string {
+	return h.entry.task.TaskId
+}
+
+// Task returns a snapshot of the current task state.
+func (h *TaskHandle) Task() Task {
+	h.server.tasksMu.RLock()
+	defer h.server.tasksMu.RUnlock()
+	return h.entry.task
+}
+
+// Done returns a channel that is closed once the task reaches a terminal
+// status, including when it is cancelled via tasks/cancel.
+func (h *TaskHandle) Done() <-chan struct{} {
+	return h.entry.done
+}
+
+// SetStatusMessage updates the human-readable status message of the task,
+// fires the OnTaskStatusChanged hooks and notifies clients with
+// notifications/tasks/status.
+// It returns ErrTaskTerminated if the task has already finished.
+func (h *TaskHandle) SetStatusMessage(message string) error {
+	s := h.server
+	now := time.Now()
+
+	s.tasksMu.Lock()
+	if h.entry.completed {
+		s.tasksMu.Unlock()
+		return ErrTaskTerminated
+	}
+	h.entry.task.StatusMessage = message
+	h.entry.task.LastUpdatedAt = now.UTC().Format(time.RFC3339)
+	metrics := TaskMetrics{
+		TaskID:        h.entry.task.TaskId,
+		ToolName:      h.entry.toolName,
+		Status:        h.entry.task.Status,
+		StatusMessage: message,
+		CreatedAt:     h.entry.createdAt,
+		SessionID:     h.entry.sessionID,
+	}
+	s.tasksMu.Unlock()
+
+	s.taskHooks.taskStatusChanged(h.ctx, metrics)
+
+	h.throttle(&h.status, h.sendStatus)
+	return nil
+}
+
+// ReportProgress sends a notifications/progress message for the task to the
+// session that created it. Progress is only reported when the original
+// tools/call request carried a progress token. A non-nil message also becomes
+// the task's status message.
+// It returns ErrTaskTerminated if the task has already finished.
+func (h *TaskHandle) ReportProgress(progress float64, total *float64, message *string) error {
+	if message != nil {
+		if err := h.SetStatusMessage(*message); err != nil {
+			return err
+		}
+	} else {
+		h.server.tasksMu.RLock()
+		completed := h.entry.completed
+		h.server.tasksMu.RUnlock()
+		if completed {
+			return ErrTaskTerminated
+		}
+	}
+
+	if h.progressToken == nil {
+		return nil
+	}
+
+	params := map[string]any{
+		"progressToken": h.progressToken,
+		"progress":      progress,
+		"_meta": map[string]any{
+			RelatedTaskMetaKey: RelatedTaskMeta(h.TaskID()),
+		},
+	}
+	if total != nil {
+		params["total"] = *total
+	}
+	if message != nil {
+		params["message"] = *message
+	}
+
+	h.mu.Lock()
+	h.progressParams = params
+	h.mu.Unlock()
+
+	h.throttle(&h.progress, h.sendProgress)
+	return nil
+}
+
+// throttle runs send immediately when the previous send of the same kind is
+// older than the server's task notification interval. Otherwise it schedules a
+// single trailing send, which picks up whatever state is current at that time.
+func (h *TaskHandle) throttle(t *taskNotifyThrottle, send func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	interval := h.server.taskNotificationInterval
+	elapsed := time.Since(t.last)
+	if interval <= 0 || elapsed >= interval {
+		if t.pending != nil {
+			t.pending.Stop()
+			t.pending = nil
+		}
+		t.last = time.Now()
+		send()
+		return
+	}
+
+	// A trailing send is already scheduled and will deliver the latest state
+	if t.pending != nil {
+		return
+	}
+	t.pending = time.AfterFunc(interval-elapsed, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		t.pending = nil
+		t.last = time.Now()
+		send()
+	})
+}
+
+// sendStatus broadcasts the current task state unless the task has already
+// finished, in which case the terminal notification has been sent instead.
+// Must be called with h.mu held.
+func (h *TaskHandle) sendStatus() {
+	h.server.tasksMu.RLock()
+	if h.entry.completed {
+		h.server.tasksMu.RUnlock()
+		return
+	}
+	task := h.entry.task
+	h.server.tasksMu.RUnlock()
+
+	h.server.sendTaskStatusNotification(task)
+}
+
+// sendProgress delivers the latest progress update to the owning session.
+// Must be called with h.mu held.
+func (h *TaskHandle) sendProgress() {
+	params := h.progressParams
+	h.progressParams = nil
+	if params == nil || h.entry.sessionID == "" {
+		return
+	}
+
+	h.server.tasksMu.RLock()
+	completed := h.entry.completed
+	h.server.tasksMu.RUnlock()
+	if completed {
+		return
+	}
+
+	_ = h.server.SendNotificationToSpecificClient(h.entry.sessionID, "notifications/progress", params)
+}
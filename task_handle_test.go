@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func TestTaskFromContext_NotInTask(t *testing.T) {
+	assert.Nil(t, TaskFromContext(context.Background()))
+}
+
+func TestTaskHandle_SetStatusMessage(t *testing.T) {
+	var mu sync.Mutex
+	var statusMessages []string
+
+	hooks := &TaskHooks{}
+	hooks.AddOnTaskStatusChanged(func(ctx context.Context, metrics TaskMetrics) {
+		mu.Lock()
+		defer mu.Unlock()
+		statusMessages = append(statusMessages, metrics.StatusMessage)
+	})
+
+	server := NewMCPServer("test", "1.0.0",
+		WithTaskCapabilities(true, true, true),
+		WithTaskHooks(hooks),
+		WithTaskNotificationInterval(0),
+	)
+
+	ctx := context.Background()
+	notifyChan := make(chan JSONRPCNotification, 10)
+	session := fakeSess{sessionID: "handle-session", notifyChan: notifyChan}
+	require.NoError(t, server.RegisterSession(ctx, session))
+	sessionCtx := server.WithContext(ctx, session)
+
+	entry, err := server.createTask(sessionCtx, "handle-task", "test-tool", nil, nil)
+	require.NoError(t, err)
+
+	taskCtx := server.withTaskHandle(sessionCtx, entry, CallToolRequest{})
+	handle := TaskFromContext(taskCtx)
+	require.NotNil(t, handle)
+	assert.Equal(t, "handle-task", handle.TaskID())
+
+	require.NoError(t, handle.SetStatusMessage("halfway there"))
+	assert.Equal(t, "halfway there", handle.Task().StatusMessage)
+
+	select {
+	case notification := <-notifyChan:
+		assert.Equal(t, MethodNotificationTasksStatus, notification.Method)
+		assert.Equal(t, "halfway there", notification.Notification.Params.AdditionalFields["statusMessage"])
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for task status notification")
+	}
+
+	mu.Lock()
+	assert.Contains(t, statusMessages, "halfway there")
+	mu.Unlock()
+
+	server.completeTask(entry, &CallToolResult{}, nil)
+
+	select {
+	case <-handle.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done channel was not closed on completion")
+	}
+
+	assert.ErrorIs(t, handle.SetStatusMessage("too late"), ErrTaskTerminated)
+}
+
+func TestTaskHandle_ReportProgress(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0",
+		WithTaskCapabilities(true, true, true),
+		WithTaskNotificationInterval(0),
+	)
+
+	ctx := context.Background()
+	notifyChan := make(chan JSONRPCNotification, 10)
+	session := fakeSess{sessionID: "progress-session", notifyChan: notifyChan}
+	require.NoError(t, server.RegisterSession(ctx, session))
+	sessionCtx := server.WithContext(ctx, session)
+
+	entry, err := server.createTask(sessionCtx, "progress-task", "test-tool", nil, nil)
+	require.NoError(t, err)
+
+	request := CallToolRequest{
+		Params: CallToolParams{
+			Meta: &Meta{ProgressToken: "token-1"},
+		},
+	}
+	handle := TaskFromContext(server.withTaskHandle(sessionCtx, entry, request))
+	require.NotNil(t, handle)
+
+	total := 10.0
+	require.NoError(t, handle.ReportProgress(3, &total, nil))
+
+	select {
+	case notification := <-notifyChan:
+		assert.Equal(t, "notifications/progress", notification.Method)
+		assert.Equal(t, "token-1", notification.Notification.Params.AdditionalFields["progressToken"])
+		assert.Equal(t, 3.0, notification.Notification.Params.AdditionalFields["progress"])
+		assert.Equal(t, 10.0, notification.Notification.Params.AdditionalFields["total"])
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for progress notification")
+	}
+}
+
+func TestTaskHandle_RateLimitsStatusNotifications(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0",
+		WithTaskCapabilities(true, true, true),
+		WithTaskNotificationInterval(100*time.Millisecond),
+	)
+
+	ctx := context.Background()
+	notifyChan := make(chan JSONRPCNotification, 100)
+	session := fakeSess{sessionID: "rate-session", notifyChan: notifyChan}
+	require.NoError(t, server.RegisterSession(ctx, session))
+	sessionCtx := server.WithContext(ctx, session)
+
+	entry, err := server.createTask(sessionCtx, "rate-task", "test-tool", nil, nil)
+	require.NoError(t, err)
+	handle := TaskFromContext(server.withTaskHandle(sessionCtx, entry, CallToolRequest{}))
+
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		require.NoError(t, handle.SetStatusMessage(msg))
+	}
+
+	// The first update is sent immediately, the rest are coalesced into a
+	// single trailing notification carrying the latest message.
+	var received []string
+	deadline := time.After(500 * time.Millisecond)
+loop:
+	for {
+		select {
+		case notification := <-notifyChan:
+			received = append(received, notification.Notification.Params.AdditionalFields["statusMessage"].(string))
+		case <-deadline:
+			break loop
+		}
+	}
+
+	assert.Equal(t, []string{"one", "four"}, received)
+}
+
+func TestTaskHandle_AvailableInTaskTool(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0",
+		WithTaskCapabilities(true, true, true),
+	)
+
+	handleCh := make(chan *TaskHandle, 1)
+	server.AddTaskTool(
+		NewTool("with_handle", WithTaskSupport(TaskSupportRequired)),
+		func(ctx context.Context, request CallToolRequest) (*CreateTaskResult, error) {
+			handleCh <- TaskFromContext(ctx)
+			return &CreateTaskResult{}, nil
+		},
+	)
+
+	result, reqErr := server.handleToolCall(context.Background(), 1, CallToolRequest{
+		Params: CallToolParams{
+			Name: "with_handle",
+			Task: &TaskParams{},
+		},
+	})
+	require.Nil(t, reqErr)
+	createResult, ok := result.(*CreateTaskResult)
+	require.True(t, ok)
+
+	select {
+	case handle := <-handleCh:
+		require.NotNil(t, handle)
+		assert.Equal(t, createResult.Task.TaskId, handle.TaskID())
+	case <-time.After(time.Second):
+		t.Fatal("task tool was not executed")
+	}
+}
+
+// fakeSess is a test helper for simulating client sessions
+type fakeSess struct {
+	sessionID  string
+	notifyChan chan JSONRPCNotification
+}
+
+func (f fakeSess) SessionID() string {
+	return f.sessionID
+}
+
+func (f fakeSess) NotificationChannel() chan<- JSONRPCNotification {
+	return f.notifyChan
+}
+
+func (f fakeSess) Initialize() {
+}
+
+func (f fakeSess) Initialized() bool {
+	return true
+}
+
+var _ ClientSession = fakeSess{}
@@ -40,6 +40,18 @@ type OnTaskCancelledHookFunc func(ctx context.Context, metrics TaskMetrics)
 // Use this for general monitoring or when you need to track all state changes.
 type OnTaskStatusChangedHookFunc func(ctx context.Context, metrics TaskMetrics)
 
+// TaskQueueMetrics describes the state of the task queue.
+// It is passed to OnTaskQueueChanged hooks whenever tasks enter or leave the queue.
+type TaskQueueMetrics struct {
+	Depth          int            // Number of tasks waiting for an execution slot
+	Running        int            // Number of tasks currently running
+	DepthBySession map[string]int // Number of queued tasks per session
+}
+
+// OnTaskQueueChangedHookFunc is called whenever the task queue grows or shrinks.
+// Use this to track queue depth and detect sessions that flood the server with tasks.
+type OnTaskQueueChangedHookFunc func(ctx context.Context, metrics TaskQueueMetrics)
+
 // TaskHooks contains lifecycle hooks for task execution.
 // These hooks enable observability and monitoring of task-augmented tools.
 type TaskHooks struct {
@@ -48,6 +60,7 @@ type TaskHooks struct {
 	OnTaskFailed        []OnTaskFailedHookFunc
 	OnTaskCancelled     []OnTaskCancelledHookFunc
 	OnTaskStatusChanged []OnTaskStatusChangedHookFunc
+	OnTaskQueueChanged  []OnTaskQueueChangedHookFunc
 }
 
 // AddOnTaskCreated registers a hook for task creation events.
@@ -75,6 +88,11 @@ func (h *TaskHooks) AddOnTaskStatusChanged(hook OnTaskStatusChangedHookFunc) {
 	h.OnTaskStatusChanged = append(h.OnTaskStatusChanged, hook)
 }
 
+// AddOnTaskQueueChanged registers a hook for task queue depth changes.
+func (h *TaskHooks) AddOnTaskQueueChanged(hook OnTaskQueueChangedHookFunc) {
+	h.OnTaskQueueChanged = append(h.OnTaskQueueChanged, hook)
+}
+
 // taskCreated calls all registered task creation hooks.
 func (h *TaskHooks) taskCreated(ctx context.Context, metrics TaskMetrics) {
 	if h == nil {
@@ -132,3 +150,13 @@ func (h *TaskHooks) taskStatusChanged(ctx context.Context, metrics TaskMetrics)
 		hook(ctx, metrics)
 	}
 }
+
+// taskQueueChanged calls all registered queue change hooks.
+func (h *TaskHooks) taskQueueChanged(ctx context.Context, metrics TaskQueueMetrics) {
+	if h == nil {
+		return
+	}
+	for _, hook := range h.OnTaskQueueChanged {
+		hook(ctx, metrics)
+	}
+}
@@ -0,0 +1,273 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TaskPriorityMetaKey is the _meta key a client can set on a task-augmented
+// tools/call request to override the tool's task priority. The value must be
+// a number; higher values are scheduled first.
+const TaskPriorityMetaKey = "io.github.tinywasm/task-priority"
+
+// taskQueue holds task-augmented calls waiting for a free execution slot when
+// the server's concurrent task limit is reached.
+//
+// Tasks are ordered by priority. Among tasks with the same priority, sessions
+// are served round-robin so that a single client submitting many tasks
+// cannot starve the others. Within a session, tasks run in submission order.
+type taskQueue struct {
+	maxQueued int
+	sessions  map[string][]*taskEntry // Pending tasks per session, highest priority first
+	rotation  []string                // Sessions with pending tasks, in round-robin order
+	size      int
+}
+
+func newTaskQueue(maxQueued int) *taskQueue {
+	return &taskQueue{
+		maxQueued: maxQueued,
+		sessions:  make(map[string][]*taskEntry),
+	}
+}
+
+// full reports whether the queue cannot accept another task.
+func (q *taskQueue) full() bool {
+	return q.maxQueued > 0 && q.size >= q.maxQueued
+}
+
+// push adds a task to the queue of its session.
+func (q *taskQueue) push(entry *taskEntry) {
+	pending := q.sessions[entry.sessionID]
+	if len(pending) == 0 {
+		q.rotation = append(q.rotation, entry.sessionID)
+	}
+
+	// Insert after every task with the same or a higher priority
+	i := len(pending)
+	for i > 0 && pending[i-1].priority < entry.priority {
+		i--
+	}
+	pending = append(pending, nil)
+	copy(pending[i+1:], pending[i:])
+	pending[i] = entry
+
+	q.sessions[entry.sessionID] = pending
+	q.size++
+}
+
+// remove drops a task from the queue, for example when it is cancelled
+// before it started. It reports whether the task was queued.
+func (q *taskQueue) remove(entry *taskEntry) bool {
+	pending := q.sessions[entry.sessionID]
+	for i, queued := range pending {
+		if queued == entry {
+			q.sessions[entry.sessionID] = append(pending[:i], pending[i+1:]...)
+			q.size--
+			q.dropEmptySession(entry.sessionID)
+			return true
+		}
+	}
+	return false
+}
+
+// pop removes and returns the next task to run, or nil if the queue is empty.
+func (q *taskQueue) pop() *taskEntry {
+	idx := q.nextSession()
+	if idx < 0 {
+		return nil
+	}
+
+	sessionID := q.rotation[idx]
+	pending := q.sessions[sessionID]
+	entry := pending[0]
+	q.sessions[sessionID] = pending[1:]
+	q.size--
+
+	// Move the served session to the back of the rotation
+	q.rotation = append(append(q.rotation[:idx:idx], q.rotation[idx+1:]...), sessionID)
+	q.dropEmptySession(sessionID)
+
+	return entry
+}
+
+// nextSession returns the rotation index of the session whose head task runs
+// next: the highest priority head, with ties going to the session that has
+// waited longest for its turn.
+func (q *taskQueue) nextSession() int {
+	best := -1
+	bestPriority := math.MinInt
+	for i, sessionID := range q.rotation {
+		head := q.sessions[sessionID][0]
+		if head.priority > bestPriority {
+			best = i
+			bestPriority = head.priority
+		}
+	}
+	return best
+}
+
+// dropEmptySession removes a session without pending tasks from the rotation.
+func (q *taskQueue) dropEmptySession(sessionID string) {
+	if len(q.sessions[sessionID]) > 0 {
+		return
+	}
+	delete(q.sessions, sessionID)
+	for i, id := range q.rotation {
+		if id == sessionID {
+			q.rotation = append(q.rotation[:i], q.rotation[i+1:]...)
+			return
+		}
+	}
+}
+
+// order returns the queued tasks in the order they would be started.
+func (q *taskQueue) order() []*taskEntry {
+	sim := &taskQueue{
+		sessions: make(map[string][]*taskEntry, len(q.sessions)),
+		rotation: append([]string(nil), q.rotation...),
+		size:     q.size,
+	}
+	for sessionID, pending := range q.sessions {
+		sim.sessions[sessionID] = append([]*taskEntry(nil), pending...)
+	}
+
+	ordered := make([]*taskEntry, 0, q.size)
+	for entry := sim.pop(); entry != nil; entry = sim.pop() {
+		ordered = append(ordered, entry)
+	}
+	return ordered
+}
+
+// depthBySession returns the number of queued tasks per session.
+func (q *taskQueue) depthBySession() map[string]int {
+	depths := make(map[string]int, len(q.sessions))
+	for sessionID, pending := range q.sessions {
+		depths[sessionID] = len(pending)
+	}
+	return depths
+}
+
+// taskPriority returns the scheduling priority of a task-augmented call: the
+// value of TaskPriorityMetaKey in the request _meta if present, otherwise the
+// tool's configured task priority.
+func taskPriority(tool Tool, request CallToolRequest) int {
+	if request.Params.Meta != nil {
+		switch p := request.Params.Meta.AdditionalFields[TaskPriorityMetaKey].(type) {
+		case float64:
+			return int(p)
+		case int:
+			return p
+		}
+	}
+	return tool.TaskPriority
+}
+
+// hasTaskSlot reports whether another task may start running right away.
+// Must be called with tasksMu held.
+func (s *MCPServer) hasTaskSlot() bool {
+	return s.maxConcurrentTasks == nil || *s.maxConcurrentTasks <= 0 ||
+		s.activeTasks < *s.maxConcurrentTasks
+}
+
+// runOrQueueTask starts a freshly created task, or records how to start it
+// once the task leaves the queue.
+func (s *MCPServer) runOrQueueTask(entry *taskEntry, start func()) {
+	s.tasksMu.Lock()
+	if entry.queued {
+		entry.start = start
+		s.tasksMu.Unlock()
+		return
+	}
+	// A task cancelled or expired while queued must never start
+	cancelled := entry.completed
+	s.tasksMu.Unlock()
+
+	if !cancelled {
+		start()
+	}
+}
+
+// releaseTaskSlot is called when a task reaches a terminal status. It frees
+// the execution slot of a running task and starts queued tasks, or removes a
+// task from the queue if it never started.
+// Must be called with tasksMu held.
+func (s *MCPServer) releaseTaskSlot(entry *taskEntry) {
+	if entry.queued {
+		if s.taskQueue != nil && s.taskQueue.remove(entry) {
+			entry.queued = false
+			s.queueChanged()
+		}
+		return
+	}
+
+	s.activeTasks--
+	s.dispatchQueuedTasks()
+}
+
+// dispatchQueuedTasks starts queued tasks while execution slots are free.
+// Must be called with tasksMu held.
+func (s *MCPServer) dispatchQueuedTasks() {
+	if s.taskQueue == nil || s.taskQueue.size == 0 {
+		return
+	}
+
+	dispatched := false
+	for s.hasTaskSlot() {
+		entry := s.taskQueue.pop()
+		if entry == nil {
+			break
+		}
+		dispatched = true
+
+		entry.queued = false
+		s.activeTasks++
+		entry.task.StatusMessage = ""
+		entry.task.LastUpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		s.sendTaskStatusNotification(entry.task)
+
+		if s.taskHooks != nil {
+			s.taskHooks.taskStatusChanged(context.Background(), TaskMetrics{
+				TaskID:    entry.task.TaskId,
+				ToolName:  entry.toolName,
+				Status:    entry.task.Status,
+				CreatedAt: entry.createdAt,
+				SessionID: entry.sessionID,
+			})
+		}
+
+		// The start function is nil if the task was dispatched before its
+		// creator registered it; runOrQueueTask then starts it directly.
+		if entry.start != nil {
+			entry.start()
+		}
+	}
+
+	if dispatched {
+		s.queueChanged()
+	}
+}
+
+// queueChanged refreshes the "queued, position N" status message of every
+// queued task and reports the queue depth to the task hooks.
+// Must be called with tasksMu held.
+func (s *MCPServer) queueChanged() {
+	for i, entry := range s.taskQueue.order() {
+		message := fmt.Sprintf("queued, position %d", i+1)
+		if entry.task.StatusMessage == message {
+			continue
+		}
+		entry.task.StatusMessage = message
+		entry.task.LastUpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		s.sendTaskStatusNotification(entry.task)
+	}
+
+	if s.taskHooks != nil {
+		s.taskHooks.taskQueueChanged(context.Background(), TaskQueueMetrics{
+			Depth:          s.taskQueue.size,
+			Running:        s.activeTasks,
+			DepthBySession: s.taskQueue.depthBySession(),
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// newQueueTestServer returns a server allowing one running task, with a
+// "blocking" task tool whose executions wait on release and record their order.
+func newQueueTestServer(t *testing.T, opts ...ServerOption) (*MCPServer, chan struct{}, func() []string) {
+	t.Helper()
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var started []string
+
+	opts = append([]ServerOption{
+		WithTaskCapabilities(true, true, true),
+		WithMaxConcurrentTasks(1),
+	}, opts...)
+	server := NewMCPServer("queue-server", "1.0.0", opts...)
+	server.AddTool(
+		NewTool("blocking", WithTaskSupport(TaskSupportOptional)),
+		func(ctx context.Context, request CallToolRequest) (*CallToolResult, error) {
+			mu.Lock()
+			started = append(started, request.GetString("label", ""))
+			mu.Unlock()
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return NewToolResultText("ok"), nil
+		},
+	)
+
+	return server, release, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), started...)
+	}
+}
+
+func callQueued(t *testing.T, server *MCPServer, sessionID, label string, meta *Meta) Task {
+	t.Helper()
+
+	session := fakeSess{sessionID: sessionID, notifyChan: make(chan JSONRPCNotification, 100)}
+	ctx := server.WithContext(context.Background(), session)
+	result, reqErr := server.handleToolCall(ctx, 1, CallToolRequest{
+		Params: CallToolParams{
+			Name:      "blocking",
+			Arguments: map[string]any{"label": label},
+			Meta:      meta,
+			Task:      &TaskParams{},
+		},
+	})
+	require.Nil(t, reqErr)
+	createResult, ok := result.(*CreateTaskResult)
+	require.True(t, ok)
+	return createResult.Task
+}
+
+func TestTaskQueue_RejectsWithoutQueue(t *testing.T) {
+	server, release, _ := newQueueTestServer(t)
+	defer close(release)
+
+	callQueued(t, server, "s1", "first", nil)
+
+	session := fakeSess{sessionID: "s1", notifyChan: make(chan JSONRPCNotification, 10)}
+	_, reqErr := server.handleToolCall(server.WithContext(context.Background(), session), 2, CallToolRequest{
+		Params: CallToolParams{Name: "blocking", Task: &TaskParams{}},
+	})
+	require.NotNil(t, reqErr)
+}
+
+func TestTaskQueue_QueuesAndReportsPosition(t *testing.T) {
+	server, release, started := newQueueTestServer(t, WithTaskQueue(0))
+
+	first := callQueued(t, server, "s1", "first", nil)
+	second := callQueued(t, server, "s1", "second", nil)
+	third := callQueued(t, server, "s1", "third", nil)
+
+	assert.Equal(t, TaskStatusWorking, second.Status)
+	assert.Equal(t, "queued, position 1", second.StatusMessage)
+	assert.Equal(t, "queued, position 2", third.StatusMessage)
+	assert.Empty(t, first.StatusMessage)
+
+	// Each release lets exactly one task finish and the next one start
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+	}
+
+	require.Eventually(t, func() bool {
+		task, _, err := server.getTask(context.Background(), third.TaskId)
+		return err == nil && task.Status == TaskStatusCompleted
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"first", "second", "third"}, started())
+}
+
+func TestTaskQueue_BoundedQueueRejects(t *testing.T) {
+	server, release, _ := newQueueTestServer(t, WithTaskQueue(1))
+	defer close(release)
+
+	callQueued(t, server, "s1", "running", nil)
+	callQueued(t, server, "s1", "queued", nil)
+
+	session := fakeSess{sessionID: "s1", notifyChan: make(chan JSONRPCNotification, 10)}
+	_, reqErr := server.handleToolCall(server.WithContext(context.Background(), session), 3, CallToolRequest{
+		Params: CallToolParams{Name: "blocking", Task: &TaskParams{}},
+	})
+	require.NotNil(t, reqErr)
+}
+
+func TestTaskQueue_FairAcrossSessionsAndPriority(t *testing.T) {
+	server, release, started := newQueueTestServer(t, WithTaskQueue(0))
+
+	callQueued(t, server, "flood", "running", nil)
+	callQueued(t, server, "flood", "flood-1", nil)
+	callQueued(t, server, "flood", "flood-2", nil)
+	callQueued(t, server, "flood", "flood-3", nil)
+	callQueued(t, server, "other", "other-1", nil)
+	callQueued(t, server, "other", "urgent", &Meta{
+		AdditionalFields: map[string]any{TaskPriorityMetaKey: float64(10)},
+	})
+
+	for i := 0; i < 6; i++ {
+		release <- struct{}{}
+	}
+
+	require.Eventually(t, func() bool {
+		return len(started()) == 6
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"running", "urgent", "flood-1", "other-1", "flood-2", "flood-3"}, started())
+}
+
+func TestTaskQueue_CancelQueuedTask(t *testing.T) {
+	var mu sync.Mutex
+	var depths []int
+	hooks := &TaskHooks{}
+	hooks.AddOnTaskQueueChanged(func(ctx context.Context, metrics TaskQueueMetrics) {
+		mu.Lock()
+		defer mu.Unlock()
+		depths = append(depths, metrics.Depth)
+	})
+
+	server, release, started := newQueueTestServer(t, WithTaskQueue(0), WithTaskHooks(hooks))
+
+	callQueued(t, server, "s1", "running", nil)
+	queued := callQueued(t, server, "s1", "cancelled", nil)
+
+	session := fakeSess{sessionID: "s1", notifyChan: make(chan JSONRPCNotification, 10)}
+	require.NoError(t, server.cancelTask(server.WithContext(context.Background(), session), queued.TaskId))
+
+	release <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, []string{"running"}, started())
+	mu.Lock()
+	assert.Equal(t, []int{1, 0}, depths)
+	mu.Unlock()
+}
+
+func TestTaskQueue_ExpiredWhileQueued(t *testing.T) {
+	server, release, started := newQueueTestServer(t, WithTaskQueue(0))
+	defer close(release)
+
+	callQueued(t, server, "s1", "running", nil)
+
+	notifyChan := make(chan JSONRPCNotification, 10)
+	session := fakeSess{sessionID: "s1", notifyChan: notifyChan}
+	require.NoError(t, server.RegisterSession(context.Background(), session))
+	ttl := int64(50)
+	result, reqErr := server.handleToolCall(server.WithContext(context.Background(), session), 2, CallToolRequest{
+		Params: CallToolParams{Name: "blocking", Task: &TaskParams{TTL: &ttl}},
+	})
+	require.Nil(t, reqErr)
+	queued := result.(*CreateTaskResult).Task
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case notification := <-notifyChan:
+			fields := notification.Params.AdditionalFields
+			if fields["taskId"] != queued.TaskId || fields["status"] != TaskStatusFailed {
+				continue
+			}
+			assert.Equal(t, "Task expired before it started", fields["statusMessage"])
+			assert.Equal(t, []string{"running"}, started())
+			return
+		case <-deadline:
+			t.Fatal("no terminal status notification for the expired task")
+		}
+	}
+}
+
+func TestWithTaskPriority_NotSentToClients(t *testing.T) {
+	tool := NewTool("prioritized", WithTaskPriority(5))
+	assert.Equal(t, 5, tool.TaskPriority)
+	assert.Nil(t, tool.Execution)
+
+	data, err := json.Marshal(tool)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "execution")
+}
@@ -0,0 +1,647 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Terminal reads answers line by line and writes prompts, usually on
+// os.Stdin and os.Stderr. Handlers created with the same Terminal share its
+// input without either buffering lines meant for the other, and ask about
+// one request at a time, so that their prompts do not interleave when the
+// server sends requests concurrently.
+//
+// A single goroutine reads the input and hands each line to the caller that
+// prompted last. While the terminal is locked, only callers that prompted
+// since it was locked get lines, so a shell waiting at its prompt does not
+// take the answers meant for a handler.
+type Terminal struct {
+	mu sync.Mutex // Held for the duration of a request
+	r  *bufio.Reader
+	w  io.Writer
+
+	readOnce sync.Once
+	lineMu   sync.Mutex
+	locked   bool
+	waiters  []*lineWaiter // Callers waiting for a line, latest last
+	pending  []string      // Lines read before anyone asked for them
+	readErr  error         // Error that ended the input
+}
+
+// lineWaiter is a caller of ReadLine waiting for a line.
+type lineWaiter struct {
+	prompt string
+	locked bool        // Prompted while the terminal was locked
+	line   chan string // Buffered; closed when the input ends
+}
+
+// NewTerminal creates a terminal reading from r and writing to w.
+func NewTerminal(r io.Reader, w io.Writer) *Terminal {
+	return &Terminal{r: bufio.NewReader(r), w: w}
+}
+
+// Lock makes the caller the only one asking questions until Unlock. The
+// terminal handlers hold it while they handle a request.
+func (t *Terminal) Lock() {
+	t.mu.Lock()
+	t.lineMu.Lock()
+	t.locked = true
+	t.lineMu.Unlock()
+}
+
+// Unlock releases the terminal for the next request.
+func (t *Terminal) Unlock() {
+	t.lineMu.Lock()
+	t.locked = false
+	t.dispatch()
+	t.lineMu.Unlock()
+	t.mu.Unlock()
+}
+
+// Write writes p to the terminal's output.
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.w.Write(p)
+}
+
+// Printf writes a formatted message to the terminal's output.
+func (t *Terminal) Printf(format string, args ...any) {
+	fmt.Fprintf(t.w, format, args...)
+}
+
+// ReadLine prints prompt and returns the next line, trimmed of spaces. It
+// returns early with ctx's error when ctx is done; the line is then left to
+// the next caller.
+func (t *Terminal) ReadLine(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	t.readOnce.Do(func() { go t.readLines() })
+
+	t.lineMu.Lock()
+	t.Printf("%s", prompt)
+	if len(t.pending) > 0 {
+		line := t.pending[0]
+		t.pending = t.pending[1:]
+		t.lineMu.Unlock()
+		return line, nil
+	}
+	if t.readErr != nil {
+		t.lineMu.Unlock()
+		return "", t.readErr
+	}
+	waiter := &lineWaiter{prompt: prompt, locked: t.locked, line: make(chan string, 1)}
+	t.waiters = append(t.waiters, waiter)
+	t.lineMu.Unlock()
+
+	select {
+	case line, ok := <-waiter.line:
+		if !ok {
+			t.lineMu.Lock()
+			defer t.lineMu.Unlock()
+			return "", t.readErr
+		}
+		return line, nil
+	case <-ctx.Done():
+		t.lineMu.Lock()
+		defer t.lineMu.Unlock()
+		t.waiters = slices.DeleteFunc(t.waiters, func(w *lineWaiter) bool { return w == waiter })
+		// A line delivered meanwhile goes to the next caller
+		select {
+		case line, ok := <-waiter.line:
+			if ok {
+				t.pending = append([]string{line}, t.pending...)
+				t.dispatch()
+			}
+		default:
+		}
+		return "", ctx.Err()
+	}
+}
+
+// readLines reads the input until it ends, handing out each line.
+func (t *Terminal) readLines() {
+	for {
+		line, err := t.r.ReadString('\n')
+		t.lineMu.Lock()
+		if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+			t.readErr = err
+			for _, waiter := range t.waiters {
+				close(waiter.line)
+			}
+			t.waiters = nil
+			t.lineMu.Unlock()
+			return
+		}
+		t.pending = append(t.pending, strings.TrimSpace(line))
+		t.dispatch()
+		t.lineMu.Unlock()
+	}
+}
+
+// dispatch hands the pending lines to the callers that prompted last,
+// skipping those that prompted before the terminal was locked. lineMu must
+// be held.
+func (t *Terminal) dispatch() {
+	for len(t.pending) > 0 {
+		i := len(t.waiters) - 1
+		for i >= 0 && t.locked && !t.waiters[i].locked {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		t.waiters[i].line <- t.pending[0]
+		close(t.waiters[i].line)
+		t.pending = t.pending[1:]
+		t.waiters = slices.Delete(t.waiters, i, i+1)
+	}
+}
+
+// Choose asks question until the answer is a prefix of one of the options,
+// and returns that option.
+func (t *Terminal) Choose(ctx context.Context, question string, options ...string) (string, error) {
+	for {
+		answer, err := t.ReadLine(ctx, fmt.Sprintf("%s [%s] ", question, strings.Join(options, "/")))
+		if err != nil {
+			return "", err
+		}
+		answer = strings.ToLower(answer)
+		for _, option := range options {
+			if answer != "" && strings.HasPrefix(option, answer) {
+				return option, nil
+			}
+		}
+	}
+}
+
+// TerminalElicitationHandler is an ElicitationHandler for command-line
+// clients. It shows the server's message, asks whether to respond, and then
+// asks for each field of the requested schema in turn, re-asking until the
+// answer fits the field: its type, enum values, length, range and format.
+// Empty answers take the field's default, or leave optional fields out.
+//
+// For URL mode elicitations it shows the URL and asks whether the user
+// opened it. A cancelled context abandons the question being asked.
+type TerminalElicitationHandler struct {
+	term *Terminal
+}
+
+// NewTerminalElicitationHandler creates an elicitation handler asking on
+// term.
+func NewTerminalElicitationHandler(term *Terminal) *TerminalElicitationHandler {
+	return &TerminalElicitationHandler{term: term}
+}
+
+// Elicit implements ElicitationHandler.
+func (h *TerminalElicitationHandler) Elicit(ctx context.Context, request ElicitationRequest) (*ElicitationResult, error) {
+	t := h.term
+	t.Lock()
+	defer t.Unlock()
+
+	params := request.Params
+	t.Printf("\nThe server asks: %s\n", params.Message)
+	question := "Provide this information?"
+	if params.Mode == ElicitationModeURL {
+		t.Printf("Open this URL to continue:\n  %s\n", params.URL)
+		question = "Did you open it?"
+	}
+	answer, err := t.Choose(ctx, question, "accept", "decline", "cancel")
+	if err != nil {
+		return nil, err
+	}
+	result := &ElicitationResult{}
+	result.Action = ElicitationResponseAction(answer)
+	if result.Action != ElicitationResponseActionAccept || params.Mode == ElicitationModeURL {
+		return result, nil
+	}
+
+	schema, err := schemaToMap(params.RequestedSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+
+	content := make(map[string]any)
+	for _, name := range schemaPropertyOrder(params.RequestedSchema, properties) {
+		property, _ := properties[name].(map[string]any)
+		value, ok, err := h.askField(ctx, name, property, slices.Contains(required, any(name)))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			content[name] = value
+		}
+	}
+	result.Content = content
+	return result, nil
+}
+
+// elicitationChoice is a value of an enum field with its display label.
+type elicitationChoice struct {
+	value any
+	label string
+}
+
+// askField asks for one field until the answer is valid. It returns false
+// for optional fields left empty.
+func (h *TerminalElicitationHandler) askField(ctx context.Context, name string, property map[string]any, required bool) (any, bool, error) {
+	t := h.term
+	kind, _ := property["type"].(string)
+	choices := elicitationChoices(property)
+	if kind == "array" {
+		items, _ := property["items"].(map[string]any)
+		choices = elicitationChoices(items)
+	}
+
+	label := name
+	if title, ok := property["title"].(string); ok && title != "" {
+		label = title
+	}
+	var hints []string
+	switch {
+	case kind == "array":
+		hints = append(hints, "comma-separated")
+	case kind == "boolean":
+		hints = append(hints, "y/n")
+	case len(choices) == 0:
+		hints = append(hints, kind)
+	}
+	if format, ok := property["format"].(string); ok {
+		hints = append(hints, format)
+	}
+	if !required {
+		hints = append(hints, "optional")
+	}
+	prompt := label
+	if len(hints) > 0 {
+		prompt += " (" + strings.Join(hints, ", ") + ")"
+	}
+	defaultValue, hasDefault := property["default"]
+	if hasDefault {
+		prompt += fmt.Sprintf(" [%s]", formatElicitationValue(defaultValue, choices))
+	}
+	prompt += ": "
+
+	if description, ok := property["description"].(string); ok && description != "" {
+		t.Printf("%s\n", description)
+	}
+	for i, choice := range choices {
+		t.Printf("  %d) %s\n", i+1, choice.label)
+	}
+
+	for {
+		answer, err := t.ReadLine(ctx, prompt)
+		if err != nil {
+			return nil, false, err
+		}
+		if answer == "" {
+			switch {
+			case hasDefault:
+				return defaultValue, true, nil
+			case !required:
+				return nil, false, nil
+			}
+			t.Printf("  a value is required\n")
+			continue
+		}
+		value, err := parseElicitationAnswer(answer, kind, choices)
+		if err == nil {
+			err = checkElicitationValue(value, property)
+		}
+		if err != nil {
+			t.Printf("  %s\n", err)
+			continue
+		}
+		return value, true, nil
+	}
+}
+
+// elicitationChoices returns the values of an enum schema, labelled by
+// "enumNames" or by the titles of "oneOf"/"anyOf" constants.
+func elicitationChoices(schema map[string]any) []elicitationChoice {
+	var choices []elicitationChoice
+	if enum, ok := schema["enum"].([]any); ok {
+		names, _ := schema["enumNames"].([]any)
+		for i, value := range enum {
+			label := fmt.Sprint(value)
+			if i < len(names) {
+				label = fmt.Sprint(names[i])
+			}
+			choices = append(choices, elicitationChoice{value: value, label: label})
+		}
+		return choices
+	}
+	options, ok := schema["oneOf"].([]any)
+	if !ok {
+		options, _ = schema["anyOf"].([]any)
+	}
+	for _, option := range options {
+		option, _ := option.(map[string]any)
+		value, ok := option["const"]
+		if !ok {
+			continue
+		}
+		label := fmt.Sprint(value)
+		if title, ok := option["title"].(string); ok && title != "" {
+			label = title
+		}
+		choices = append(choices, elicitationChoice{value: value, label: label})
+	}
+	return choices
+}
+
+// parseElicitationAnswer converts an answer to the field's type. Enum values
+// are given by number, value or label.
+func parseElicitationAnswer(answer string, kind string, choices []elicitationChoice) (any, error) {
+	switch {
+	case kind == "array":
+		var values []any
+		for _, part := range strings.Split(answer, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			value, err := pickElicitationChoice(part, choices)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	case len(choices) > 0:
+		return pickElicitationChoice(answer, choices)
+	case kind == "boolean":
+		switch strings.ToLower(answer) {
+		case "y", "yes", "true":
+			return true, nil
+		case "n", "no", "false":
+			return false, nil
+		}
+		return nil, errors.New("answer y or n")
+	case kind == "integer":
+		value, err := strconv.ParseInt(answer, 10, 64)
+		if err != nil {
+			return nil, errors.New("enter a whole number")
+		}
+		return value, nil
+	case kind == "number":
+		value, err := strconv.ParseFloat(answer, 64)
+		if err != nil {
+			return nil, errors.New("enter a number")
+		}
+		return value, nil
+	}
+	return answer, nil
+}
+
+func pickElicitationChoice(answer string, choices []elicitationChoice) (any, error) {
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1].value, nil
+	}
+	for _, choice := range choices {
+		if fmt.Sprint(choice.value) == answer || strings.EqualFold(choice.label, answer) {
+			return choice.value, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not one of the options", answer)
+}
+
+// checkElicitationValue checks the limits and format of a field.
+func checkElicitationValue(value any, property map[string]any) error {
+	if keyword, limit, ok := violatedSchemaLimit(value, property); ok {
+		return fmt.Errorf(elicitationLimitMessages[keyword], limit)
+	}
+	if v, ok := value.(string); ok {
+		format, _ := property["format"].(string)
+		if !validSchemaFormat(v, format) {
+			return fmt.Errorf("enter a valid %s", format)
+		}
+	}
+	return nil
+}
+
+// elicitationLimitMessages tell the user how to fix a value violating a
+// schema limit.
+var elicitationLimitMessages = map[string]string{
+	"minLength": "enter at least %v characters",
+	"maxLength": "enter at most %v characters",
+	"minimum":   "enter a number of at least %v",
+	"maximum":   "enter a number of at most %v",
+	"minItems":  "choose at least %v options",
+	"maxItems":  "choose at most %v options",
+}
+
+// formatElicitationValue shows a default value as the user would type it.
+func formatElicitationValue(value any, choices []elicitationChoice) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "y"
+		}
+		return "n"
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatElicitationValue(item, choices)
+		}
+		return strings.Join(parts, ", ")
+	}
+	for _, choice := range choices {
+		if choice.value == value {
+			return choice.label
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// schemaPropertyOrder returns the names of properties in the order they
+// appear in the JSON form of schema, which is the order of the fields for
+// schemas built from structs or raw JSON.
+func schemaPropertyOrder(schema any, properties map[string]any) []string {
+	var names []string
+	if data, err := json.Marshal(schema); err == nil {
+		names = jsonObjectKeys(data, "properties")
+	}
+	// Fall back to sorted names for anything the decoder could not order
+	for _, name := range sortedPropertyNames(properties) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonObjectKeys returns the keys of the object under key in the object
+// data, in order.
+func jsonObjectKeys(data []byte, key string) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		if token != key {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+			continue
+		}
+		if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+			return nil
+		}
+		var keys []string
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return keys
+			}
+			name, _ := token.(string)
+			keys = append(keys, name)
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return keys
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+func sortedPropertyNames(properties map[string]any) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TerminalSamplingHandler is a SamplingHandler that puts a human in the
+// loop: it shows each sampling request on a terminal and only passes it to
+// the wrapped handler, which calls the actual model, once the user approves.
+// Rejected requests fail with ErrSamplingRejected.
+type TerminalSamplingHandler struct {
+	handler SamplingHandler
+	term    *Terminal
+}
+
+// NewTerminalSamplingHandler wraps handler, asking for approval on term.
+func NewTerminalSamplingHandler(handler SamplingHandler, term *Terminal) *TerminalSamplingHandler {
+	return &TerminalSamplingHandler{handler: handler, term: term}
+}
+
+// CreateMessage implements SamplingHandler.
+func (h *TerminalSamplingHandler) CreateMessage(ctx context.Context, request CreateMessageRequest) (*CreateMessageResult, error) {
+	if err := h.approve(ctx, request.CreateMessageParams); err != nil {
+		return nil, err
+	}
+	return h.handler.CreateMessage(ctx, request)
+}
+
+func (h *TerminalSamplingHandler) approve(ctx context.Context, params CreateMessageParams) error {
+	t := h.term
+	t.Lock()
+	defer t.Unlock()
+
+	t.Printf("\nThe server requests a completion of at most %d tokens.\n", params.MaxTokens)
+	if params.ModelPreferences != nil && len(params.ModelPreferences.Hints) > 0 {
+		hints := make([]string, len(params.ModelPreferences.Hints))
+		for i, hint := range params.ModelPreferences.Hints {
+			hints[i] = hint.Name
+		}
+		t.Printf("Preferred models: %s\n", strings.Join(hints, ", "))
+	}
+	if params.Temperature != 0 {
+		t.Printf("Temperature: %v\n", params.Temperature)
+	}
+	if len(params.StopSequences) > 0 {
+		t.Printf("Stop sequences: %q\n", params.StopSequences)
+	}
+	if len(params.Tools) > 0 {
+		names := make([]string, len(params.Tools))
+		for i, tool := range params.Tools {
+			names[i] = tool.Name
+		}
+		t.Printf("Tools offered to the model: %s\n", strings.Join(names, ", "))
+	}
+	if params.ToolChoice != nil && params.ToolChoice.Mode != "" {
+		t.Printf("Tool choice: %s\n", params.ToolChoice.Mode)
+	}
+	if params.IncludeContext != "" && params.IncludeContext != "none" {
+		t.Printf("Context to include: %s\n", params.IncludeContext)
+	}
+	if params.SystemPrompt != "" {
+		t.Printf("[system]\n%s\n", params.SystemPrompt)
+	}
+	for _, message := range params.Messages {
+		t.Printf("[%s]\n%s\n", message.Role, describeSamplingContent(message.Content))
+	}
+
+	answer, err := t.Choose(ctx, "Approve this request?", "yes", "no")
+	if err != nil {
+		return err
+	}
+	if answer != "yes" {
+		return ErrSamplingRejected
+	}
+	return nil
+}
+
+// describeSamplingContent shows message content as text, summarizing
+// binary content and tool calls.
+func describeSamplingContent(content any) string {
+	switch c := content.(type) {
+	case TextContent:
+		return c.Text
+	case ImageContent:
+		return fmt.Sprintf("[image %s, %d base64 bytes]", c.MIMEType, len(c.Data))
+	case AudioContent:
+		return fmt.Sprintf("[audio %s, %d base64 bytes]", c.MIMEType, len(c.Data))
+	case ToolUseContent:
+		input, _ := json.Marshal(c.Input)
+		return fmt.Sprintf("[tool use %s] %s %s", c.ID, c.Name, input)
+	case ToolResultContent:
+		header := fmt.Sprintf("[tool result %s]", c.ToolUseID)
+		if c.IsError {
+			header = fmt.Sprintf("[tool error %s]", c.ToolUseID)
+		}
+		lines := []string{header}
+		for _, item := range c.Content {
+			lines = append(lines, describeSamplingContent(item))
+		}
+		if len(c.Content) == 0 && c.StructuredContent != nil {
+			structured, _ := json.Marshal(c.StructuredContent)
+			lines = append(lines, string(structured))
+		}
+		return strings.Join(lines, "\n")
+	case []Content:
+		lines := make([]string, len(c))
+		for i, item := range c {
+			lines[i] = describeSamplingContent(item)
+		}
+		return strings.Join(lines, "\n")
+	}
+	data, _ := json.Marshal(content)
+	return string(data)
+}
+
+// Ensure interface compliance
+var (
+	_ ElicitationHandler = (*TerminalElicitationHandler)(nil)
+	_ SamplingHandler    = (*TerminalSamplingHandler)(nil)
+)
@@ -0,0 +1,333 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+type samplingHandlerFunc func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error)
+
+func (f samplingHandlerFunc) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return f(ctx, request)
+}
+
+const signupSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "title": "Full name", "minLength": 2},
+		"email": {"type": "string", "format": "email"},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"plan": {"type": "string", "enum": ["free", "pro"], "enumNames": ["Free", "Pro"], "default": "free"},
+		"topics": {"type": "array", "items": {"type": "string", "enum": ["news", "updates"]}},
+		"newsletter": {"type": "boolean", "description": "Receive the newsletter"},
+		"referrer": {"type": "string"}
+	},
+	"required": ["name", "email", "age"]
+}`
+
+func elicitOnTerminal(t *testing.T, input string, params mcp.ElicitationParams) (*mcp.ElicitationResult, string, error) {
+	t.Helper()
+	var out strings.Builder
+	handler := mcp.NewTerminalElicitationHandler(mcp.NewTerminal(strings.NewReader(input), &out))
+	result, err := handler.Elicit(context.Background(), mcp.ElicitationRequest{Params: params})
+	return result, out.String(), err
+}
+
+func TestTerminalElicitationHandler_Form(t *testing.T) {
+	input := strings.Join([]string{
+		"a",
+		"", "A", "Ada", // name is required and too short
+		"nope", "ada@example.com", // email is not an address
+		"x", "200", "36", // age is not a number and too large
+		"",           // plan takes the default
+		"1, updates", // topics by number and by value
+		"y",
+		"", // referrer is optional
+	}, "\n") + "\n"
+
+	result, out, err := elicitOnTerminal(t, input, mcp.ElicitationParams{
+		Message:         "Please sign up",
+		RequestedSchema: json.RawMessage(signupSchema),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.ElicitationResponseActionAccept, result.Action)
+	assert.Equal(t, map[string]any{
+		"name":       "Ada",
+		"email":      "ada@example.com",
+		"age":        int64(36),
+		"plan":       "free",
+		"topics":     []any{"news", "updates"},
+		"newsletter": true,
+	}, result.Content)
+
+	assert.Contains(t, out, "The server asks: Please sign up")
+	assert.Contains(t, out, "a value is required")
+	assert.Contains(t, out, "enter at least 2 characters")
+	assert.Contains(t, out, "enter a valid email")
+	assert.Contains(t, out, "enter a whole number")
+	assert.Contains(t, out, "enter a number of at most 150")
+	assert.Contains(t, out, "  1) Free\n  2) Pro\n")
+	assert.Contains(t, out, "plan (optional) [Free]: ")
+	assert.Contains(t, out, "Receive the newsletter\nnewsletter (y/n, optional): ")
+	// Fields are asked in schema order
+	assert.True(t, strings.Index(out, "Full name") < strings.Index(out, "email (string, email)"))
+	assert.True(t, strings.Index(out, "age (integer)") < strings.Index(out, "referrer"))
+}
+
+func TestTerminalElicitationHandler_Decline(t *testing.T) {
+	result, _, err := elicitOnTerminal(t, "maybe\nd\n", mcp.ElicitationParams{
+		Message:         "Please sign up",
+		RequestedSchema: json.RawMessage(signupSchema),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.ElicitationResponseActionDecline, result.Action)
+	assert.Nil(t, result.Content)
+}
+
+func TestTerminalElicitationHandler_URL(t *testing.T) {
+	result, out, err := elicitOnTerminal(t, "accept\n", mcp.ElicitationParams{
+		Mode:          mcp.ElicitationModeURL,
+		Message:       "Connect your account",
+		ElicitationID: "123",
+		URL:           "https://example.com/connect",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.ElicitationResponseActionAccept, result.Action)
+	assert.Nil(t, result.Content)
+	assert.Contains(t, out, "https://example.com/connect")
+}
+
+func TestTerminalElicitationHandler_EndOfInput(t *testing.T) {
+	_, _, err := elicitOnTerminal(t, "accept\nAda\n", mcp.ElicitationParams{
+		Message:         "Please sign up",
+		RequestedSchema: json.RawMessage(signupSchema),
+	})
+	assert.Error(t, err)
+}
+
+func TestTerminalSamplingHandler(t *testing.T) {
+	request := mcp.CreateMessageRequest{CreateMessageParams: mcp.CreateMessageParams{
+		Messages: []mcp.SamplingMessage{
+			{Role: mcp.RoleUser, Content: mcp.NewTextContent("What is the capital of France?")},
+		},
+		SystemPrompt:     "You are a geography expert.",
+		MaxTokens:        100,
+		ModelPreferences: &mcp.ModelPreferences{Hints: []mcp.ModelHint{{Name: "claude"}}},
+	}}
+
+	t.Run("approved", func(t *testing.T) {
+		var out strings.Builder
+		called := false
+		handler := mcp.NewTerminalSamplingHandler(samplingHandlerFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			called = true
+			return &mcp.CreateMessageResult{
+				SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: mcp.NewTextContent("Paris")},
+				Model:           "test-model",
+			}, nil
+		}), mcp.NewTerminal(strings.NewReader("yes\n"), &out))
+
+		result, err := handler.CreateMessage(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Equal(t, "test-model", result.Model)
+		assert.Contains(t, out.String(), "at most 100 tokens")
+		assert.Contains(t, out.String(), "Preferred models: claude")
+		assert.Contains(t, out.String(), "[system]\nYou are a geography expert.\n")
+		assert.Contains(t, out.String(), "[user]\nWhat is the capital of France?\n")
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		var out strings.Builder
+		handler := mcp.NewTerminalSamplingHandler(samplingHandlerFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			t.Fatal("rejected request was sampled")
+			return nil, nil
+		}), mcp.NewTerminal(strings.NewReader("n\n"), &out))
+
+		_, err := handler.CreateMessage(context.Background(), request)
+		assert.ErrorIs(t, err, mcp.ErrSamplingRejected)
+	})
+}
+
+func TestTerminalSamplingHandler_Tools(t *testing.T) {
+	var out strings.Builder
+	handler := mcp.NewTerminalSamplingHandler(samplingHandlerFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		return &mcp.CreateMessageResult{Model: "test-model"}, nil
+	}), mcp.NewTerminal(strings.NewReader("yes\n"), &out))
+
+	_, err := handler.CreateMessage(context.Background(), mcp.CreateMessageRequest{CreateMessageParams: mcp.CreateMessageParams{
+		Messages: []mcp.SamplingMessage{
+			{Role: mcp.RoleUser, Content: mcp.NewTextContent("What's the weather in Paris?")},
+			{Role: mcp.RoleAssistant, Content: mcp.ToolUseContent{
+				Type: "tool_use", ID: "call-1", Name: "get_weather", Input: map[string]any{"city": "Paris"},
+			}},
+			{Role: mcp.RoleUser, Content: []mcp.Content{
+				mcp.ToolResultContent{Type: "tool_result", ToolUseID: "call-1", Content: []mcp.Content{mcp.NewTextContent("18°C, sunny")}},
+				mcp.NewTextContent("Answer briefly."),
+			}},
+		},
+		MaxTokens:  100,
+		Tools:      []mcp.Tool{mcp.NewTool("get_weather"), mcp.NewTool("get_time")},
+		ToolChoice: &mcp.ToolChoice{Mode: mcp.ToolChoiceRequired},
+	}})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Tools offered to the model: get_weather, get_time\n")
+	assert.Contains(t, out.String(), "Tool choice: required\n")
+	assert.Contains(t, out.String(), "[assistant]\n[tool use call-1] get_weather {\"city\":\"Paris\"}\n")
+	assert.Contains(t, out.String(), "[user]\n[tool result call-1]\n18°C, sunny\nAnswer briefly.\n")
+}
+
+func TestTerminalHandlers_SharedInput(t *testing.T) {
+	var out strings.Builder
+	term := mcp.NewTerminal(strings.NewReader("y\ndecline\n"), &out)
+	sampler := mcp.NewTerminalSamplingHandler(samplingHandlerFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		return &mcp.CreateMessageResult{Model: "test-model"}, nil
+	}), term)
+	elicitor := mcp.NewTerminalElicitationHandler(term)
+
+	_, err := sampler.CreateMessage(context.Background(), mcp.CreateMessageRequest{})
+	require.NoError(t, err)
+	result, err := elicitor.Elicit(context.Background(), mcp.ElicitationRequest{Params: mcp.ElicitationParams{
+		Message:         "Please sign up",
+		RequestedSchema: json.RawMessage(signupSchema),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.ElicitationResponseActionDecline, result.Action)
+}
+
+// lockedBuilder is a strings.Builder safe for concurrent use.
+type lockedBuilder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (b *lockedBuilder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuilder) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func (b *lockedBuilder) waitFor(t *testing.T, s string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(b.String(), s) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q in %q", s, b.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTerminalHandlers_ConcurrentRequests(t *testing.T) {
+	input, answers := io.Pipe()
+	defer answers.Close()
+	var out lockedBuilder
+	term := mcp.NewTerminal(input, &out)
+	sampler := mcp.NewTerminalSamplingHandler(samplingHandlerFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		return &mcp.CreateMessageResult{Model: "test-model"}, nil
+	}), term)
+	elicitor := mcp.NewTerminalElicitationHandler(term)
+
+	elicited := make(chan error, 1)
+	go func() {
+		_, err := elicitor.Elicit(context.Background(), mcp.ElicitationRequest{Params: mcp.ElicitationParams{
+			Message:         "Please sign up",
+			RequestedSchema: json.RawMessage(signupSchema),
+		}})
+		elicited <- err
+	}()
+	out.waitFor(t, "Provide this information?")
+
+	sampled := make(chan error, 1)
+	go func() {
+		_, err := sampler.CreateMessage(context.Background(), mcp.CreateMessageRequest{})
+		sampled <- err
+	}()
+
+	// The sampling request waits for the elicitation to be answered
+	time.Sleep(50 * time.Millisecond)
+	assert.NotContains(t, out.String(), "requests a completion")
+	_, err := io.WriteString(answers, "decline\n")
+	require.NoError(t, err)
+	require.NoError(t, <-elicited)
+
+	out.waitFor(t, "Approve this request?")
+	_, err = io.WriteString(answers, "yes\n")
+	require.NoError(t, err)
+	require.NoError(t, <-sampled)
+}
+
+func TestTerminal_ElicitationWhileShellWaits(t *testing.T) {
+	input, answers := io.Pipe()
+	defer answers.Close()
+	var out lockedBuilder
+	term := mcp.NewTerminal(input, &out)
+	elicitor := mcp.NewTerminalElicitationHandler(term)
+
+	// A shell waits at its prompt
+	commands := make(chan string, 1)
+	go func() {
+		line, _ := term.ReadLine(context.Background(), "mcp> ")
+		commands <- line
+	}()
+	out.waitFor(t, "mcp> ")
+
+	elicited := make(chan *mcp.ElicitationResult, 1)
+	go func() {
+		result, _ := elicitor.Elicit(context.Background(), mcp.ElicitationRequest{Params: mcp.ElicitationParams{
+			Message:         "Who are you?",
+			RequestedSchema: map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}},
+		}})
+		elicited <- result
+	}()
+	out.waitFor(t, "Provide this information?")
+
+	// The answers go to the handler, which asked last
+	_, err := io.WriteString(answers, "accept\nAda\n")
+	require.NoError(t, err)
+	result := <-elicited
+	require.NotNil(t, result)
+	assert.Equal(t, map[string]any{"name": "Ada"}, result.Content)
+
+	// The shell gets the next line
+	_, err = io.WriteString(answers, "tools\n")
+	require.NoError(t, err)
+	assert.Equal(t, "tools", <-commands)
+}
+
+func TestTerminal_ReadLineCancelled(t *testing.T) {
+	input, answers := io.Pipe()
+	defer answers.Close()
+	var out lockedBuilder
+	term := mcp.NewTerminal(input, &out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	read := make(chan error, 1)
+	go func() {
+		_, err := term.ReadLine(ctx, "first> ")
+		read <- err
+	}()
+	out.waitFor(t, "first> ")
+	cancel()
+	assert.ErrorIs(t, <-read, context.Canceled)
+
+	// The line is left to the next caller
+	go func() { _, _ = io.WriteString(answers, "hello\n") }()
+	line, err := term.ReadLine(context.Background(), "second> ")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", line)
+}
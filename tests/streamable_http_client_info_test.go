@@ -48,7 +48,7 @@ func TestStreamableHttpSessionImplementsSessionWithClientInfo(t *testing.T) {
 
 	// Test SetClientCapabilities and GetClientCapabilities
 	expectedCapabilities := mcp.ClientCapabilities{
-		Sampling: &struct{}{},
+		Sampling: &mcp.SamplingCapability{},
 	}
 	clientInfoSession.SetClientCapabilities(expectedCapabilities)
 
@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tokenFileAAD binds the ciphertext to the file format.
+var tokenFileAAD = []byte("mcp-token-store-v1")
+
+// FileTokenStore is a TokenStore persisting tokens in a file encrypted
+// with AES-GCM, so that users don't need to authorize again on every run.
+//
+// Several stores can share a file: each one holds the token of a server URL
+// and client ID. The file is created with 0600 permissions, and accesses
+// are serialized with a lock file so concurrent processes don't overwrite
+// each other's tokens.
+type FileTokenStore struct {
+	path  string
+	entry string
+	aead  cipher.AEAD
+}
+
+// NewFileTokenStore creates a store for the token of clientID on the
+// server at serverURL, in the file at path. The key must be 16, 24 or 32
+// bytes long to select AES-128, AES-192 or AES-256.
+func NewFileTokenStore(path string, key []byte, serverURL, clientID string) (*FileTokenStore, error) {
+	if path == "" {
+		return nil, errors.New("token store path is empty")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token store key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token store key: %w", err)
+	}
+	return &FileTokenStore{
+		path:  path,
+		entry: strings.TrimSuffix(serverURL, "/") + " " + clientID,
+		aead:  aead,
+	}, nil
+}
+
+// GetToken returns the current token.
+// Returns ErrNoToken if no token is available.
+// Returns context.Canceled or context.DeadlineExceeded if ctx is cancelled.
+// Returns an error wrapping ErrTokenStoreDecrypt if the file was encrypted
+// with another key or is corrupted.
+func (s *FileTokenStore) GetToken(ctx context.Context) (*Token, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// The file is replaced atomically and never removed, so it is safe to
+	// check for it without the lock, whose directory might not exist yet
+	if _, err := os.Stat(s.path); errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoToken
+	}
+	unlock, err := lockFile(ctx, s.path+".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	token, ok := tokens[s.entry]
+	if !ok || token == nil {
+		return nil, ErrNoToken
+	}
+	return token, nil
+}
+
+// SaveToken saves a token, keeping the tokens of other servers and clients.
+// Returns context.Canceled or context.DeadlineExceeded if ctx is cancelled.
+func (s *FileTokenStore) SaveToken(ctx context.Context, token *Token) error {
+	return s.update(ctx, func(tokens map[string]*Token) {
+		tokens[s.entry] = token
+	})
+}
+
+// DeleteToken removes the token, e.g. when the user logs out.
+func (s *FileTokenStore) DeleteToken(ctx context.Context) error {
+	return s.update(ctx, func(tokens map[string]*Token) {
+		delete(tokens, s.entry)
+	})
+}
+
+// update applies change to the tokens of the file while holding the lock.
+func (s *FileTokenStore) update(ctx context.Context, change func(tokens map[string]*Token)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create token store directory: %w", err)
+	}
+	unlock, err := lockFile(ctx, s.path+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	change(tokens)
+	return s.write(tokens)
+}
+
+// read decrypts the tokens of the file. A missing file has no tokens.
+func (s *FileTokenStore) read() (map[string]*Token, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]*Token), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token store: %w", err)
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("%w: file too short", ErrTokenStoreDecrypt)
+	}
+	plaintext, err := s.aead.Open(nil, data[:nonceSize], data[nonceSize:], tokenFileAAD)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenStoreDecrypt, err)
+	}
+	tokens := make(map[string]*Token)
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenStoreDecrypt, err)
+	}
+	return tokens, nil
+}
+
+// write encrypts tokens to a temporary file that replaces the store, so
+// that readers never see a partially written file.
+func (s *FileTokenStore) write(tokens map[string]*Token) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	data := s.aead.Seal(nonce, nonce, plaintext, tokenFileAAD)
+
+	// CreateTemp creates the file with 0600 permissions
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write token store: %w", err)
+	}
+	return nil
+}
+
+var _ TokenStore = (*FileTokenStore)(nil)
@@ -0,0 +1,136 @@
+package mcp_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+var testTokenStoreKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestFileTokenStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens", "tokens.enc")
+	ctx := context.Background()
+
+	store, err := mcp.NewFileTokenStore(path, testTokenStoreKey, "https://mcp.example.com/", "client")
+	require.NoError(t, err)
+	_, err = store.GetToken(ctx)
+	assert.ErrorIs(t, err, mcp.ErrNoToken)
+
+	token := &mcp.Token{
+		AccessToken:  "secret-access-token",
+		TokenType:    "Bearer",
+		RefreshToken: "secret-refresh-token",
+		ExpiresAt:    time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	require.NoError(t, store.SaveToken(ctx, token))
+
+	// Another process opening the file finds the token
+	reopened, err := mcp.NewFileTokenStore(path, testTokenStoreKey, "https://mcp.example.com", "client")
+	require.NoError(t, err)
+	saved, err := reopened.GetToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, token.AccessToken, saved.AccessToken)
+	assert.Equal(t, token.RefreshToken, saved.RefreshToken)
+	assert.True(t, token.ExpiresAt.Equal(saved.ExpiresAt))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-access-token")
+	assert.NotContains(t, string(data), "mcp.example.com")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	require.NoError(t, reopened.DeleteToken(ctx))
+	_, err = store.GetToken(ctx)
+	assert.ErrorIs(t, err, mcp.ErrNoToken)
+}
+
+func TestFileTokenStore_SeparateEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.enc")
+	ctx := context.Background()
+
+	stores := map[string]*mcp.FileTokenStore{}
+	for _, entry := range []struct{ server, client string }{
+		{"https://a.example.com", "client-1"},
+		{"https://a.example.com", "client-2"},
+		{"https://b.example.com", "client-1"},
+	} {
+		store, err := mcp.NewFileTokenStore(path, testTokenStoreKey, entry.server, entry.client)
+		require.NoError(t, err)
+		name := entry.server + "/" + entry.client
+		require.NoError(t, store.SaveToken(ctx, &mcp.Token{AccessToken: name}))
+		stores[name] = store
+	}
+	for name, store := range stores {
+		token, err := store.GetToken(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, name, token.AccessToken)
+	}
+}
+
+func TestFileTokenStore_WrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.enc")
+	store, err := mcp.NewFileTokenStore(path, testTokenStoreKey, "https://mcp.example.com", "client")
+	require.NoError(t, err)
+	require.NoError(t, store.SaveToken(context.Background(), &mcp.Token{AccessToken: "token"}))
+
+	other, err := mcp.NewFileTokenStore(path, []byte("fedcba9876543210"), "https://mcp.example.com", "client")
+	require.NoError(t, err)
+	_, err = other.GetToken(context.Background())
+	assert.ErrorIs(t, err, mcp.ErrTokenStoreDecrypt)
+	// Saving must not replace the tokens it cannot read
+	assert.ErrorIs(t, other.SaveToken(context.Background(), &mcp.Token{AccessToken: "other"}), mcp.ErrTokenStoreDecrypt)
+
+	_, err = mcp.NewFileTokenStore(path, []byte("short"), "https://mcp.example.com", "client")
+	assert.Error(t, err)
+}
+
+func TestFileTokenStore_ContextCancelled(t *testing.T) {
+	store, err := mcp.NewFileTokenStore(filepath.Join(t.TempDir(), "tokens.enc"), testTokenStoreKey, "https://mcp.example.com", "client")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = store.GetToken(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, store.SaveToken(ctx, &mcp.Token{AccessToken: "token"}), context.Canceled)
+}
+
+func TestFileTokenStore_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.enc")
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store, err := mcp.NewFileTokenStore(path, testTokenStoreKey, "https://mcp.example.com", fmt.Sprintf("client-%d", i))
+			assert.NoError(t, err)
+			assert.NoError(t, store.SaveToken(ctx, &mcp.Token{AccessToken: fmt.Sprintf("token-%d", i)}))
+		}()
+	}
+	wg.Wait()
+
+	// No writer overwrote the token of another one
+	for i := 0; i < 10; i++ {
+		store, err := mcp.NewFileTokenStore(path, testTokenStoreKey, "https://mcp.example.com", fmt.Sprintf("client-%d", i))
+		require.NoError(t, err)
+		token, err := store.GetToken(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("token-%d", i), token.AccessToken)
+	}
+}
@@ -595,6 +595,12 @@ type Tool struct {
 	Icons []Icon `json:"icons,omitempty"`
 	// Execution describes execution behavior for the tool
 	Execution *ToolExecution `json:"execution,omitempty"`
+	// RequiredScopes are the OAuth scopes callers need. It is only used by
+	// the server and never sent to clients.
+	RequiredScopes []string `json:"-"`
+	// TaskPriority orders queued task executions of the tool; higher runs
+	// first. It is only used by the server and never sent to clients.
+	TaskPriority int `json:"-"`
 }
 
 // GetName returns the name of the tool.
@@ -830,6 +836,13 @@ func WithDescription(description string) ToolOption {
 	}
 }
 
+// WithRequiredScopes sets the OAuth scopes needed to list and call the tool.
+func WithRequiredScopes(scopes ...string) ToolOption {
+	return func(t *Tool) {
+		t.RequiredScopes = scopes
+	}
+}
+
 // WithDeferLoading sets the defer_loading flag for the tool.
 // This is used to implement dynamic tool loading/searching patterns.
 func WithDeferLoading(deferLoading bool) ToolOption {
@@ -888,6 +901,15 @@ func WithTaskSupport(support TaskSupport) ToolOption {
 	}
 }
 
+// WithTaskPriority sets the scheduling priority used when task executions of
+// the tool wait in the server's task queue. Higher values run first; the
+// default is 0. Clients may override it per call with TaskPriorityMetaKey.
+func WithTaskPriority(priority int) ToolOption {
+	return func(t *Tool) {
+		t.TaskPriority = priority
+	}
+}
+
 // WithRawInputSchema sets a raw JSON schema for the tool's input.
 // Use this when you need full control over the schema or when working with
 // complex schemas that can't be generated from Go types. The jsonschema library
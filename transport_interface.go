@@ -50,3 +50,13 @@ type HTTPConnection interface {
 	Interface
 	SetProtocolVersion(version string)
 }
+
+// Reconnectable is a Transport that can re-establish its connection after it
+// was lost, allowing a Client to recover a session without being rebuilt.
+type Reconnectable interface {
+	Interface
+
+	// Reconnect discards the current connection, if any, and opens a new one.
+	// Requests still waiting for a response on the old connection fail.
+	Reconnect(ctx context.Context) error
+}
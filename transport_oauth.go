@@ -3,6 +3,7 @@ package mcp
 import (
 	"bytes"
 	"context"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -37,6 +38,17 @@ type OAuthConfig struct {
 	// HTTPClient is an optional HTTP client to use for requests.
 	// If nil, a default HTTP client with a 30 second timeout will be used.
 	HTTPClient *http.Client
+	// GrantType selects how new tokens are obtained. The default,
+	// OAuthGrantAuthorizationCode, needs a user to authorize the client;
+	// OAuthGrantClientCredentials and OAuthGrantTokenExchange run unattended.
+	GrantType OAuthGrantType
+	// ClientAssertionKey authenticates the client to the token endpoint
+	// with a private_key_jwt assertion (RFC 7523) instead of ClientSecret
+	ClientAssertionKey *rsa.PrivateKey
+	// ClientAssertionKeyID is the "kid" of ClientAssertionKey (optional)
+	ClientAssertionKeyID string
+	// TokenExchange configures the OAuthGrantTokenExchange grant
+	TokenExchange *TokenExchangeConfig
 }
 
 // TokenStore is an interface for storing and retrieving OAuth tokens.
@@ -147,6 +159,8 @@ type OAuthHandler struct {
 
 	mu            sync.RWMutex // Protects expectedState
 	expectedState string       // Expected state value for CSRF protection
+
+	grantMu sync.Mutex // Serializes unattended token requests
 }
 
 // NewOAuthHandler creates a new OAuth handler
@@ -170,14 +184,18 @@ func (h *OAuthHandler) GetAuthorizationHeader(ctx context.Context) (string, erro
 	if err != nil {
 		return "", err
 	}
+	return authorizationHeader(token), nil
+}
 
+// authorizationHeader returns the Authorization header value for token.
+func authorizationHeader(token *Token) string {
 	// Some auth implementations are strict about token type
 	tokenType := token.TokenType
 	if tokenType == "bearer" {
 		tokenType = "Bearer"
 	}
 
-	return fmt.Sprintf("%s %s", tokenType, token.AccessToken), nil
+	return fmt.Sprintf("%s %s", tokenType, token.AccessToken)
 }
 
 // getValidToken returns a valid token, refreshing if necessary
@@ -199,6 +217,11 @@ func (h *OAuthHandler) getValidToken(ctx context.Context) (*Token, error) {
 		// If refresh fails, continue to authorization flow
 	}
 
+	// Grants without user interaction get a new token right away
+	if h.unattended() {
+		return h.requestUnattendedToken(ctx)
+	}
+
 	// We need to get a new token through the authorization flow
 	return nil, ErrOAuthAuthorizationRequired
 }
@@ -213,9 +236,8 @@ func (h *OAuthHandler) refreshToken(ctx context.Context, refreshToken string) (*
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("refresh_token", refreshToken)
-	data.Set("client_id", h.config.ClientID)
-	if h.config.ClientSecret != "" {
-		data.Set("client_secret", h.config.ClientSecret)
+	if err := h.setClientAuthentication(data, metadata.TokenEndpoint); err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(
@@ -350,9 +372,11 @@ func (e OAuthError) Error() string {
 
 // OAuthProtectedResource represents the response from /.well-known/oauth-protected-resource
 type OAuthProtectedResource struct {
-	AuthorizationServers []string `json:"authorization_servers"`
-	Resource             string   `json:"resource"`
-	ResourceName         string   `json:"resource_name,omitempty"`
+	AuthorizationServers   []string `json:"authorization_servers"`
+	Resource               string   `json:"resource"`
+	ResourceName           string   `json:"resource_name,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
 }
 
 // getServerMetadata fetches the OAuth server metadata
@@ -542,6 +566,11 @@ func (h *OAuthHandler) getDefaultEndpoints(baseURL string) (*AuthServerMetadata,
 
 // RegisterClient performs dynamic client registration
 func (h *OAuthHandler) RegisterClient(ctx context.Context, clientName string) error {
+	return h.registerClient(ctx, clientName, h.config.RedirectURI)
+}
+
+// registerClient registers the client with the given redirect URI.
+func (h *OAuthHandler) registerClient(ctx context.Context, clientName, redirectURI string) error {
 	metadata, err := h.getServerMetadata(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get server metadata: %w", err)
@@ -554,7 +583,7 @@ func (h *OAuthHandler) RegisterClient(ctx context.Context, clientName string) er
 	// Prepare registration request
 	regRequest := map[string]any{
 		"client_name":                clientName,
-		"redirect_uris":              []string{h.config.RedirectURI},
+		"redirect_uris":              []string{redirectURI},
 		"token_endpoint_auth_method": "none", // For public clients
 		"grant_types":                []string{"authorization_code", "refresh_token"},
 		"response_types":             []string{"code"},
@@ -618,6 +647,12 @@ var ErrInvalidState = errors.New("invalid state parameter, possible CSRF attack"
 
 // ProcessAuthorizationResponse processes the authorization response and exchanges the code for a token
 func (h *OAuthHandler) ProcessAuthorizationResponse(ctx context.Context, code, state, codeVerifier string) error {
+	return h.processAuthorizationResponse(ctx, code, state, codeVerifier, h.config.RedirectURI)
+}
+
+// processAuthorizationResponse exchanges the code of an authorization
+// response that was redirected to redirectURI.
+func (h *OAuthHandler) processAuthorizationResponse(ctx context.Context, code, state, codeVerifier, redirectURI string) error {
 	// Validate the state parameter to prevent CSRF attacks
 	h.mu.Lock()
 	expectedState := h.expectedState
@@ -643,11 +678,9 @@ func (h *OAuthHandler) ProcessAuthorizationResponse(ctx context.Context, code, s
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("code", code)
-	data.Set("client_id", h.config.ClientID)
-	data.Set("redirect_uri", h.config.RedirectURI)
-
-	if h.config.ClientSecret != "" {
-		data.Set("client_secret", h.config.ClientSecret)
+	data.Set("redirect_uri", redirectURI)
+	if err := h.setClientAuthentication(data, metadata.TokenEndpoint); err != nil {
+		return err
 	}
 
 	if h.config.PKCEEnabled && codeVerifier != "" {
@@ -711,6 +744,12 @@ func (h *OAuthHandler) ProcessAuthorizationResponse(ctx context.Context, code, s
 
 // GetAuthorizationURL returns the URL for the authorization endpoint
 func (h *OAuthHandler) GetAuthorizationURL(ctx context.Context, state, codeChallenge string) (string, error) {
+	return h.authorizationURL(ctx, state, codeChallenge, h.config.RedirectURI)
+}
+
+// authorizationURL returns the URL for the authorization endpoint
+// redirecting to redirectURI.
+func (h *OAuthHandler) authorizationURL(ctx context.Context, state, codeChallenge, redirectURI string) (string, error) {
 	metadata, err := h.getServerMetadata(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to get server metadata: %w", err)
@@ -722,7 +761,7 @@ func (h *OAuthHandler) GetAuthorizationURL(ctx context.Context, state, codeChall
 	params := url.Values{}
 	params.Set("response_type", "code")
 	params.Set("client_id", h.config.ClientID)
-	params.Set("redirect_uri", h.config.RedirectURI)
+	params.Set("redirect_uri", redirectURI)
 	params.Set("state", state)
 
 	if len(h.config.Scopes) > 0 {
@@ -24,13 +24,13 @@ import (
 // automatic reconnection and message routing between requests and responses.
 type SSE struct {
 	baseURL        *url.URL
-	endpoint       *url.URL
+	endpoint       *url.URL // Guarded by mu, replaced on Reconnect
 	httpClient     *http.Client
 	responses      map[string]chan *JSONRPCResponse
 	mu             sync.RWMutex
 	onNotification func(JSONRPCNotification)
 	notifyMu       sync.RWMutex
-	endpointChan   chan struct{}
+	endpointChan   chan struct{} // Guarded by mu, replaced on Reconnect
 	headers        map[string]string
 	headerFunc     HTTPHeaderFunc
 	host           string
@@ -38,7 +38,7 @@ type SSE struct {
 
 	started          atomic.Bool
 	closed           atomic.Bool
-	cancelSSEStream  context.CancelFunc
+	cancelSSEStream  context.CancelFunc // Guarded by mu
 	protocolVersion  atomic.Value // string
 	onConnectionLost func(error)
 	connectionLostMu sync.RWMutex
@@ -137,7 +137,10 @@ func (c *SSE) Start(ctx context.Context) error {
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
+	c.mu.Lock()
 	c.cancelSSEStream = cancel
+	endpointChan := c.endpointChan
+	c.mu.Unlock()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL.String(), nil)
 	if err != nil {
@@ -178,7 +181,7 @@ func (c *SSE) Start(ctx context.Context) error {
 		req.Header.Set("Authorization", authHeader)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doWithOAuth(c.httpClient, c.oauthHandler, req)
 	if err != nil {
 		return fmt.Errorf("failed to connect to SSE stream: %w", err)
 	}
@@ -197,7 +200,7 @@ func (c *SSE) Start(ctx context.Context) error {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	go c.readSSE(resp.Body)
+	go c.readSSE(ctx, resp.Body)
 
 	// Wait for the endpoint to be received
 	endpointTimeout := 30 * time.Second
@@ -218,7 +221,7 @@ func (c *SSE) Start(ctx context.Context) error {
 	defer timer.Stop()
 
 	select {
-	case <-c.endpointChan:
+	case <-endpointChan:
 		// Endpoint received, proceed
 	case <-ctx.Done():
 		return fmt.Errorf("context cancelled while waiting for endpoint: %w", ctx.Err())
@@ -232,8 +235,10 @@ func (c *SSE) Start(ctx context.Context) error {
 }
 
 // readSSE continuously reads the SSE stream and processes events.
-// It runs until the connection is closed or an error occurs.
-func (c *SSE) readSSE(reader io.ReadCloser) {
+// It runs until the connection is closed or an error occurs. ctx is the
+// context of the stream: once it is cancelled, by Close, Reconnect or the
+// caller of Start, the end of the stream is not reported as a lost connection.
+func (c *SSE) readSSE(ctx context.Context, reader io.ReadCloser) {
 	defer reader.Close()
 
 	br := bufio.NewReader(reader)
@@ -254,6 +259,9 @@ func (c *SSE) readSSE(reader io.ReadCloser) {
 					c.handleSSEEvent(event, data)
 				}
 			}
+			if ctx.Err() != nil {
+				return
+			}
 			c.connectionLostMu.RLock()
 			handler := c.onConnectionLost
 			c.connectionLostMu.RUnlock()
@@ -304,8 +312,15 @@ func (c *SSE) handleSSEEvent(event, data string) {
 			c.logger.Errorf("Endpoint origin does not match connection origin")
 			return
 		}
+		c.mu.Lock()
 		c.endpoint = endpoint
-		close(c.endpointChan)
+		select {
+		case <-c.endpointChan:
+			// Already announced
+		default:
+			close(c.endpointChan)
+		}
+		c.mu.Unlock()
 
 	case "message":
 		var baseMessage JSONRPCResponse
@@ -368,7 +383,8 @@ func (c *SSE) SendRequest(
 	if c.closed.Load() {
 		return nil, fmt.Errorf("transport has been closed")
 	}
-	if c.endpoint == nil {
+	endpoint := c.GetEndpoint()
+	if endpoint == nil {
 		return nil, fmt.Errorf("endpoint not received")
 	}
 
@@ -379,7 +395,7 @@ func (c *SSE) SendRequest(
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint.String(), bytes.NewReader(requestBytes))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(requestBytes))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -443,7 +459,7 @@ func (c *SSE) SendRequest(
 	}
 
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := doWithOAuth(c.httpClient, c.oauthHandler, req)
 	if err != nil {
 		deleteResponseChan()
 		return nil, fmt.Errorf("failed to send request: %w", err)
@@ -516,21 +532,45 @@ func (c *SSE) Close() error {
 		return nil // Already closed
 	}
 
+	// Clean up any pending responses
+	c.mu.Lock()
 	if c.cancelSSEStream != nil {
 		// It could stop the sse stream body, to quit the readSSE loop immediately
 		// Also, it could quit start() immediately if not receiving the endpoint
 		c.cancelSSEStream()
 	}
+	for _, ch := range c.responses {
+		close(ch)
+	}
+	c.responses = make(map[string]chan *JSONRPCResponse)
+	c.mu.Unlock()
 
-	// Clean up any pending responses
+	return nil
+}
+
+// Reconnect implements Reconnectable. It drops the current SSE stream and
+// opens a new one, waiting for the server to announce a new message endpoint.
+// ctx bounds the lifetime of the new stream, as with Start.
+func (c *SSE) Reconnect(ctx context.Context) error {
+	if c.closed.Load() {
+		return fmt.Errorf("transport is closed")
+	}
+
+	// Requests sent to the old endpoint will never be answered
 	c.mu.Lock()
+	if c.cancelSSEStream != nil {
+		c.cancelSSEStream()
+	}
 	for _, ch := range c.responses {
 		close(ch)
 	}
 	c.responses = make(map[string]chan *JSONRPCResponse)
+	c.endpoint = nil
+	c.endpointChan = make(chan struct{})
+	c.started.Store(false)
 	c.mu.Unlock()
 
-	return nil
+	return c.Start(ctx)
 }
 
 // GetSessionId returns the session ID of the
@@ -546,7 +586,8 @@ func (c *SSE) SetProtocolVersion(version string) {
 
 // SendNotification sends a JSON-RPC notification to the server without expecting a response.
 func (c *SSE) SendNotification(ctx context.Context, notification JSONRPCNotification) error {
-	if c.endpoint == nil {
+	endpoint := c.GetEndpoint()
+	if endpoint == nil {
 		return fmt.Errorf("endpoint not received")
 	}
 
@@ -558,7 +599,7 @@ func (c *SSE) SendNotification(ctx context.Context, notification JSONRPCNotifica
 	req, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
-		c.endpoint.String(),
+		endpoint.String(),
 		bytes.NewReader(notificationBytes),
 	)
 	if err != nil {
@@ -603,7 +644,7 @@ func (c *SSE) SendNotification(ctx context.Context, notification JSONRPCNotifica
 		req.Host = c.host
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doWithOAuth(c.httpClient, c.oauthHandler, req)
 	if err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
@@ -633,6 +674,8 @@ func (c *SSE) SendNotification(ctx context.Context, notification JSONRPCNotifica
 
 // GetEndpoint returns the current endpoint URL for the SSE connection.
 func (c *SSE) GetEndpoint() *url.URL {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.endpoint
 }
 
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 "github.com/tinywasm/mcp/util"
 )
 
@@ -46,6 +48,23 @@ type Stdio struct {
 	logger         util.Logger
 	started        bool
 	startedMu      sync.Mutex
+
+	// Supervisor state, only used with WithSupervisor
+	supervisor   *SupervisorPolicy
+	procMu       sync.RWMutex    // Guards the current process when it can be restarted
+	exited       chan struct{}   // Closed once the current subprocess has exited and been reaped
+	restarting   chan struct{}   // Non-nil while the subprocess is restarting, closed when ready
+	closing      bool
+	initRequest  *JSONRPCRequest // Replayed to re-initialize a restarted subprocess
+	stderrTail   *tailBuffer
+	stderrDone   chan struct{}
+	restartTimes []time.Time
+	stats        SupervisorStats
+
+	// Shutdown escalation, only used with WithShutdownPolicy
+	shutdown      *ShutdownPolicy
+	shutdownStage ShutdownStage
+	group         *processGroup // Process group of the current subprocess, guarded by procMu
 }
 
 // StdioOption defines a function that configures a Stdio transport instance.
@@ -77,11 +96,17 @@ func WithCommandLogger(logger util.Logger) StdioOption {
 // logging streams instead of spawning a subprocess.
 // This is useful for testing and simulating client behavior.
 func NewIO(input io.Reader, output io.WriteCloser, logging io.ReadCloser) *Stdio {
-	return &Stdio{
-		stdin:  output,
-		stdout: bufio.NewReader(input),
-		stderr: logging,
+	s := newStdio()
+	s.stdin = output
+	s.stdout = bufio.NewReader(input)
+	s.stderr = logging
+	return s
+}
 
+// newStdio returns a Stdio without streams or a command, ready to be
+// configured.
+func newStdio() *Stdio {
+	return &Stdio{
 		responses: make(map[string]chan *JSONRPCResponse),
 		done:      make(chan struct{}),
 		ctx:       context.Background(),
@@ -111,16 +136,10 @@ func NewStdioWithOptions(
 	args []string,
 	opts ...StdioOption,
 ) *Stdio {
-	s := &Stdio{
-		command: command,
-		args:    args,
-		env:     env,
-
-		responses: make(map[string]chan *JSONRPCResponse),
-		done:      make(chan struct{}),
-		ctx:       context.Background(),
-		logger:    util.DefaultLogger(),
-	}
+	s := newStdio()
+	s.command = command
+	s.args = args
+	s.env = env
 
 	for _, opt := range opts {
 		opt(s)
@@ -143,7 +162,18 @@ func (c *Stdio) Start(ctx context.Context) error {
 	c.ctx = ctx
 	c.ctxMu.Unlock()
 
-	if err := c.spawnCommand(ctx); err != nil {
+	// Supervision needs a subprocess to restart
+	if c.command == "" {
+		c.supervisor = nil
+	}
+
+	c.procMu.Lock()
+	err := c.spawnCommand(ctx)
+	if err == nil && c.supervisor != nil {
+		c.watchProcess()
+	}
+	c.procMu.Unlock()
+	if err != nil {
 		c.startedMu.Lock()
 		c.started = false
 		c.startedMu.Unlock()
@@ -180,6 +210,10 @@ func (c *Stdio) spawnCommand(ctx context.Context) error {
 		return err
 	}
 
+	if c.shutdown != nil {
+		setProcessGroup(cmd)
+	}
+
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdin pipe: %w", err)
@@ -196,6 +230,7 @@ func (c *Stdio) spawnCommand(ctx context.Context) error {
 	}
 
 	c.cmd = cmd
+	c.group = &processGroup{cmd: cmd}
 	c.stdin = stdin
 	c.stderr = stderr
 	c.stdout = bufio.NewReader(stdout)
@@ -217,6 +252,10 @@ func (c *Stdio) closeDone() {
 // Returns an error if there are issues closing stdin or waiting for the subprocess to terminate.
 // Safe to call multiple times and concurrently with readResponses calling closeDone().
 func (c *Stdio) Close() error {
+	if c.supervisor != nil {
+		return c.closeSupervised()
+	}
+
 	// Signal all in-flight requests to unblock.
 	c.closeDone()
 
@@ -237,7 +276,7 @@ func (c *Stdio) Close() error {
 			}
 		}
 		if c.cmd != nil {
-			if err := c.cmd.Wait(); err != nil && closeErr == nil {
+			if err := c.waitProcess(); err != nil && closeErr == nil {
 				closeErr = err
 			}
 		}
@@ -272,76 +311,86 @@ func (c *Stdio) SetRequestHandler(handler RequestHandler) {
 // readResponses continuously reads and processes responses from the server's stdout.
 // It handles both responses to requests and notifications, routing them appropriately.
 // Runs until the done channel is closed or an error occurs reading from stdout.
+// A supervised transport keeps reading after Close until the subprocess exits,
+// so that it can be reaped.
 func (c *Stdio) readResponses() {
+	stdout := c.stdout
 	for {
 		select {
 		case <-c.done:
-			return
-		default:
-			line, err := c.stdout.ReadString('\n')
-			if err != nil {
-				if err != io.EOF && !errors.Is(err, context.Canceled) {
-					c.logger.Errorf("Error reading from stdout: %v", err)
-				}
-				// Signal done so in-flight SendRequest calls unblock
-				// instead of hanging forever when the server dies.
-				c.closeDone()
+			if c.supervisor == nil {
 				return
 			}
+		default:
+		}
 
-			line = strings.TrimRight(line, "\r\n")
-			// First try to parse as a generic message to check for ID field
-			var baseMessage struct {
-				JSONRPC string         `json:"jsonrpc"`
-				ID      *RequestId `json:"id,omitempty"`
-				Method  string         `json:"method,omitempty"`
+		line, err := stdout.ReadString('\n')
+		if err != nil {
+			if err != io.EOF && !errors.Is(err, context.Canceled) && !errors.Is(err, net.ErrClosed) {
+				c.logger.Errorf("Error reading from stdout: %v", err)
 			}
-			if err := json.Unmarshal([]byte(line), &baseMessage); err != nil {
-				continue
+			if c.supervisor != nil {
+				c.handleProcessExit()
+				return
 			}
+			// Signal done so in-flight SendRequest calls unblock
+			// instead of hanging forever when the server dies.
+			c.closeDone()
+			return
+		}
 
-			// If it has a method but no ID, it's a notification
-			if baseMessage.Method != "" && baseMessage.ID == nil {
-				var notification JSONRPCNotification
-				if err := json.Unmarshal([]byte(line), &notification); err != nil {
-					continue
-				}
-				c.notifyMu.RLock()
-				if c.onNotification != nil {
-					c.onNotification(notification)
-				}
-				c.notifyMu.RUnlock()
+		line = strings.TrimRight(line, "\r\n")
+		// First try to parse as a generic message to check for ID field
+		var baseMessage struct {
+			JSONRPC string         `json:"jsonrpc"`
+			ID      *RequestId `json:"id,omitempty"`
+			Method  string         `json:"method,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(line), &baseMessage); err != nil {
+			continue
+		}
+
+		// If it has a method but no ID, it's a notification
+		if baseMessage.Method != "" && baseMessage.ID == nil {
+			var notification JSONRPCNotification
+			if err := json.Unmarshal([]byte(line), &notification); err != nil {
 				continue
 			}
-
-			// If it has a method and an ID, it's an incoming request
-			if baseMessage.Method != "" && baseMessage.ID != nil {
-				var request JSONRPCRequest
-				if err := json.Unmarshal([]byte(line), &request); err == nil {
-					c.handleIncomingRequest(request)
-					continue
-				}
+			c.notifyMu.RLock()
+			if c.onNotification != nil {
+				c.onNotification(notification)
 			}
+			c.notifyMu.RUnlock()
+			continue
+		}
 
-			// Otherwise, it's a response to our request
-			var response JSONRPCResponse
-			if err := json.Unmarshal([]byte(line), &response); err != nil {
+		// If it has a method and an ID, it's an incoming request
+		if baseMessage.Method != "" && baseMessage.ID != nil {
+			var request JSONRPCRequest
+			if err := json.Unmarshal([]byte(line), &request); err == nil {
+				c.handleIncomingRequest(request)
 				continue
 			}
+		}
 
-			// Create string key for map lookup
-			idKey := response.ID.String()
+		// Otherwise, it's a response to our request
+		var response JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			continue
+		}
 
-			c.mu.RLock()
-			ch, exists := c.responses[idKey]
-			c.mu.RUnlock()
+		// Create string key for map lookup
+		idKey := response.ID.String()
 
-			if exists {
-				ch <- &response
-				c.mu.Lock()
-				delete(c.responses, idKey)
-				c.mu.Unlock()
-			}
+		c.mu.RLock()
+		ch, exists := c.responses[idKey]
+		c.mu.RUnlock()
+
+		if exists {
+			ch <- &response
+			c.mu.Lock()
+			delete(c.responses, idKey)
+			c.mu.Unlock()
 		}
 	}
 }
@@ -363,10 +412,33 @@ func (c *Stdio) SendRequest(
 	default:
 	}
 
-	if c.stdin == nil {
+	stdin, exited, err := c.currentProcess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stdin == nil {
 		return nil, fmt.Errorf("stdio client not started")
 	}
 
+	// Remember the handshake to replay it on a restarted subprocess
+	if c.supervisor != nil && request.Method == string(MethodInitialize) {
+		c.procMu.Lock()
+		c.initRequest = &request
+		c.procMu.Unlock()
+	}
+
+	return c.roundTrip(ctx, stdin, exited, request)
+}
+
+// roundTrip writes request to stdin and waits for the matching response.
+// exited is closed when the subprocess behind stdin exits, or nil if the
+// transport is not supervised.
+func (c *Stdio) roundTrip(
+	ctx context.Context,
+	stdin io.Writer,
+	exited <-chan struct{},
+	request JSONRPCRequest,
+) (*JSONRPCResponse, error) {
 	// Marshal request
 	requestBytes, err := json.Marshal(request)
 	if err != nil {
@@ -389,7 +461,7 @@ func (c *Stdio) SendRequest(
 	}
 
 	// Send request
-	if _, err := c.stdin.Write(requestBytes); err != nil {
+	if _, err := stdin.Write(requestBytes); err != nil {
 		deleteResponseChan()
 		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
@@ -405,6 +477,14 @@ func (c *Stdio) SendRequest(
 		}
 		deleteResponseChan()
 		return nil, ErrTransportClosed
+	case <-exited:
+		select {
+		case response := <-responseChan:
+			return response, nil
+		default:
+		}
+		deleteResponseChan()
+		return nil, ErrProcessExited
 	case <-ctx.Done():
 		deleteResponseChan()
 		return nil, ctx.Err()
@@ -426,17 +506,26 @@ func (c *Stdio) SendNotification(
 	default:
 	}
 
-	if c.stdin == nil {
+	stdin, _, err := c.currentProcess(ctx)
+	if err != nil {
+		return err
+	}
+	if stdin == nil {
 		return fmt.Errorf("stdio client not started")
 	}
 
+	return c.writeNotification(stdin, notification)
+}
+
+// writeNotification writes a json RPC notification to stdin.
+func (c *Stdio) writeNotification(stdin io.Writer, notification JSONRPCNotification) error {
 	notificationBytes, err := json.Marshal(notification)
 	if err != nil {
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 	notificationBytes = append(notificationBytes, '\n')
 
-	if _, err := c.stdin.Write(notificationBytes); err != nil {
+	if _, err := stdin.Write(notificationBytes); err != nil {
 		return fmt.Errorf("failed to write notification: %w", err)
 	}
 
@@ -499,13 +588,17 @@ func (c *Stdio) sendResponse(response JSONRPCResponse) {
 	}
 	responseBytes = append(responseBytes, '\n')
 
-	if _, err := c.stdin.Write(responseBytes); err != nil {
+	c.procMu.RLock()
+	stdin := c.stdin
+	c.procMu.RUnlock()
+	if _, err := stdin.Write(responseBytes); err != nil {
 		c.logger.Errorf("Error writing response: %v", err)
 	}
 }
 
 // Stderr returns a reader for the stderr output of the subprocess.
 // This can be used to capture error messages or logs from the subprocess.
+// A supervised transport consumes stderr itself, see SupervisorStats.
 func (c *Stdio) Stderr() io.Reader {
 	return c.stderr
 }
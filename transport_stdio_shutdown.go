@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ShutdownStage reports what terminated a stdio subprocess when the
+// transport was closed.
+type ShutdownStage string
+
+const (
+	// ShutdownStageExited means the process exited on its own after its
+	// stdin was closed.
+	ShutdownStageExited ShutdownStage = "exited"
+	// ShutdownStageTerminated means the process exited after SIGTERM was
+	// sent to its process group.
+	ShutdownStageTerminated ShutdownStage = "terminated"
+	// ShutdownStageKilled means the process group had to be killed with SIGKILL.
+	ShutdownStageKilled ShutdownStage = "killed"
+)
+
+// ShutdownPolicy configures how Close stops a stdio subprocess that does not
+// exit once its stdin is closed.
+type ShutdownPolicy struct {
+	// GracePeriod is how long to wait for the process to exit after stdin
+	// is closed before sending SIGTERM. Defaults to 5s.
+	GracePeriod time.Duration
+	// TerminateTimeout is how long to wait after SIGTERM before sending
+	// SIGKILL. Defaults to 5s.
+	TerminateTimeout time.Duration
+}
+
+// WithShutdownPolicy makes Close escalate when the subprocess ignores the end
+// of its stdin: after policy.GracePeriod its process group receives SIGTERM,
+// and after policy.TerminateTimeout SIGKILL.
+//
+// The subprocess is started in its own process group (Setpgid), so that
+// processes it spawned are signalled and reaped along with it. The group is
+// only signalled until its leader is reaped, after which the group ID may be
+// reused; processes left behind by a leader that exited on its own are
+// therefore only killed on Linux, where the leader can be waited for without
+// reaping it. On platforms without process groups and signals, the process
+// is killed directly.
+// The stage that terminated the process is reported by ShutdownStage.
+func WithShutdownPolicy(policy ShutdownPolicy) StdioOption {
+	return func(s *Stdio) {
+		if policy.GracePeriod <= 0 {
+			policy.GracePeriod = 5 * time.Second
+		}
+		if policy.TerminateTimeout <= 0 {
+			policy.TerminateTimeout = 5 * time.Second
+		}
+		s.shutdown = &policy
+	}
+}
+
+// ShutdownStage returns how the subprocess terminated when the transport was
+// closed, or an empty stage if it has not been closed with a shutdown policy.
+func (c *Stdio) ShutdownStage() ShutdownStage {
+	c.procMu.RLock()
+	defer c.procMu.RUnlock()
+	return c.shutdownStage
+}
+
+// waitProcess reaps the subprocess of an unsupervised transport being closed,
+// escalating according to the shutdown policy if one is configured.
+func (c *Stdio) waitProcess() error {
+	if c.shutdown == nil {
+		return c.cmd.Wait()
+	}
+
+	exited := make(chan struct{})
+	var waitErr error
+	go func() {
+		waitErr = c.group.wait(func() bool { return true })
+		close(exited)
+	}()
+
+	c.awaitShutdown(c.group, exited)
+	return waitErr
+}
+
+// awaitShutdown waits for the leader of group to exit after its stdin was
+// closed, sending SIGTERM then SIGKILL to the group when the policy timeouts
+// elapse. exited must be closed once group.wait has returned.
+func (c *Stdio) awaitShutdown(group *processGroup, exited <-chan struct{}) ShutdownStage {
+	stage := ShutdownStageExited
+	select {
+	case <-exited:
+	case <-time.After(c.shutdown.GracePeriod):
+		stage = ShutdownStageTerminated
+		if err := group.terminate(); err != nil {
+			c.logger.Errorf("failed to terminate server process: %v", err)
+		}
+		select {
+		case <-exited:
+		case <-time.After(c.shutdown.TerminateTimeout):
+			stage = ShutdownStageKilled
+			if err := group.kill(); err != nil {
+				c.logger.Errorf("failed to kill server process: %v", err)
+			}
+			<-exited
+		}
+	}
+
+	c.procMu.Lock()
+	c.shutdownStage = stage
+	c.procMu.Unlock()
+	return stage
+}
+
+// processGroup is the process group led by a subprocess. The group ID is the
+// process ID of the leader, which the system may hand out again once the
+// leader has been reaped, so the group is only signalled until then.
+type processGroup struct {
+	cmd    *exec.Cmd
+	mu     sync.Mutex
+	reaped bool
+}
+
+// terminate sends SIGTERM to the group unless its leader has been reaped.
+func (g *processGroup) terminate() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reaped {
+		return nil
+	}
+	return terminateProcessGroup(g.cmd)
+}
+
+// kill sends SIGKILL to the group unless its leader has been reaped.
+func (g *processGroup) kill() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reaped {
+		return nil
+	}
+	return killProcessGroup(g.cmd)
+}
+
+// wait waits for the leader to exit and reaps it. If cleanup then reports
+// true, processes the leader left behind in its group are killed first,
+// while the exited but unreaped leader still reserves the group ID.
+func (g *processGroup) wait(cleanup func() bool) error {
+	if !waitExited(g.cmd) {
+		// The exit cannot be observed without reaping the leader
+		err := g.cmd.Wait()
+		g.mu.Lock()
+		g.reaped = true
+		g.mu.Unlock()
+		return err
+	}
+
+	g.mu.Lock()
+	if cleanup() {
+		_ = killProcessGroup(g.cmd)
+	}
+	g.reaped = true
+	g.mu.Unlock()
+	return g.cmd.Wait()
+}
@@ -0,0 +1,89 @@
+//go:build unix
+
+package mcp_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// processAlive reports whether pid is a running (not zombie) process.
+func processAlive(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	return !strings.Contains(string(stat), ") Z ")
+}
+
+func TestStdio_ShutdownStages(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("requires /proc")
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		stage  mcp.ShutdownStage
+	}{
+		{name: "exits on EOF", script: "cat", stage: mcp.ShutdownStageExited},
+		{name: "ignores EOF", script: "sleep 30", stage: mcp.ShutdownStageTerminated},
+		{name: "ignores SIGTERM", script: `trap "" TERM; sleep 30`, stage: mcp.ShutdownStageKilled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdio := mcp.NewStdioWithOptions("sh", nil, []string{"-c", tt.script},
+				mcp.WithShutdownPolicy(mcp.ShutdownPolicy{
+					GracePeriod:      100 * time.Millisecond,
+					TerminateTimeout: 100 * time.Millisecond,
+				}),
+			)
+			require.NoError(t, stdio.Start(context.Background()))
+
+			start := time.Now()
+			_ = stdio.Close()
+			assert.True(t, time.Since(start) < 2*time.Second)
+			assert.Equal(t, tt.stage, stdio.ShutdownStage())
+		})
+	}
+}
+
+func TestStdio_ShutdownReapsGrandchildren(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("requires /proc")
+	}
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	stdio := mcp.NewStdioWithOptions("sh", nil,
+		[]string{"-c", "sleep 30 & echo $! > " + pidFile + "; exec cat"},
+		mcp.WithShutdownPolicy(mcp.ShutdownPolicy{}),
+	)
+	require.NoError(t, stdio.Start(context.Background()))
+
+	var pid int
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	require.True(t, processAlive(pid))
+
+	require.NoError(t, stdio.Close())
+	assert.Equal(t, mcp.ShutdownStageExited, stdio.ShutdownStage())
+	require.Eventually(t, func() bool {
+		return !processAlive(pid)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,367 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrProcessExited is returned for requests that were in flight when a
+// supervised subprocess exited. The subprocess is restarted, but those
+// requests are not replayed since the server may have partially handled them.
+var ErrProcessExited = errors.New("server process exited")
+
+// supervisorInitTimeout bounds the re-initialization of a restarted subprocess.
+const supervisorInitTimeout = 30 * time.Second
+
+// SupervisorPolicy configures how a supervised Stdio transport restarts its
+// subprocess after it exits.
+type SupervisorPolicy struct {
+	// MaxRestarts is the number of restarts allowed within Window. Once
+	// exceeded, the transport gives up and behaves as closed. Defaults to 5.
+	MaxRestarts int
+	// Window is the sliding time window MaxRestarts applies to. Defaults to 1 minute.
+	Window time.Duration
+	// InitialBackoff is the delay before the first restart in a window. It
+	// doubles with each further restart. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the restart delay. Defaults to 10s.
+	MaxBackoff time.Duration
+	// StderrTailSize is the number of trailing stderr bytes kept for
+	// SupervisorStats. Defaults to 4096.
+	StderrTailSize int
+}
+
+// SupervisorStats reports the restart history of a supervised Stdio transport.
+type SupervisorStats struct {
+	Restarts      int       // Number of successful restarts
+	LastExitCode  int       // Exit code of the last exited process, -1 if killed by a signal or unknown
+	LastExitError error     // Error returned when reaping the last exited process, nil on a clean exit
+	LastExitAt    time.Time // When the last process exited, zero if none has
+	StderrTail    string    // Last bytes written to stderr by the subprocess
+}
+
+// WithSupervisor makes the Stdio transport restart its subprocess when it
+// exits unexpectedly. Restarts are delayed with exponential backoff and
+// limited to policy.MaxRestarts within policy.Window.
+//
+// After a restart the MCP session is re-initialized transparently by
+// replaying the initialize request sent through the transport. Requests in
+// flight when the process exited fail with ErrProcessExited; requests sent
+// while the process is restarting wait for it to be ready.
+//
+// In supervised mode the transport consumes the subprocess stderr itself;
+// its tail is available from SupervisorStats.
+func WithSupervisor(policy SupervisorPolicy) StdioOption {
+	return func(s *Stdio) {
+		if policy.MaxRestarts <= 0 {
+			policy.MaxRestarts = 5
+		}
+		if policy.Window <= 0 {
+			policy.Window = time.Minute
+		}
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = 100 * time.Millisecond
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = 10 * time.Second
+		}
+		if policy.StderrTailSize <= 0 {
+			policy.StderrTailSize = 4096
+		}
+		s.supervisor = &policy
+		s.stderrTail = &tailBuffer{size: policy.StderrTailSize}
+	}
+}
+
+// SupervisorStats returns the restart history of the subprocess. It returns
+// the zero value if the transport is not supervised.
+func (c *Stdio) SupervisorStats() SupervisorStats {
+	c.procMu.RLock()
+	stats := c.stats
+	c.procMu.RUnlock()
+
+	if c.stderrTail != nil {
+		stats.StderrTail = c.stderrTail.String()
+	}
+	return stats
+}
+
+// currentProcess returns the stdin of the running subprocess and the channel
+// closed when it exits. While a supervised subprocess is restarting it waits
+// for the restart to finish.
+func (c *Stdio) currentProcess(ctx context.Context) (io.WriteCloser, chan struct{}, error) {
+	for {
+		c.procMu.RLock()
+		stdin, exited, restarting := c.stdin, c.exited, c.restarting
+		c.procMu.RUnlock()
+
+		if restarting == nil {
+			return stdin, exited, nil
+		}
+		select {
+		case <-restarting:
+		case <-c.done:
+			return nil, nil, ErrTransportClosed
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// watchProcess starts draining the stderr of a freshly spawned supervised
+// subprocess and sets up its exit channel.
+// Must be called with procMu held.
+func (c *Stdio) watchProcess() {
+	c.exited = make(chan struct{})
+	c.stderrDone = make(chan struct{})
+
+	stderr, done := c.stderr, c.stderrDone
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(c.stderrTail, stderr)
+	}()
+}
+
+// handleProcessExit is called by readResponses once a supervised subprocess
+// closed its stdout. It reaps the process, fails the requests in flight and,
+// unless the transport is closing, restarts the subprocess.
+func (c *Stdio) handleProcessExit() {
+	c.procMu.RLock()
+	cmd, group, stderrDone := c.cmd, c.group, c.stderrDone
+	c.procMu.RUnlock()
+
+	// Reading stderr must complete before Wait closes the pipe; a grandchild
+	// holding it open must not block the restart though.
+	select {
+	case <-stderrDone:
+	case <-time.After(time.Second):
+	}
+	// Processes left behind are only killed when the transport is closed
+	exitErr := group.wait(func() bool {
+		c.procMu.RLock()
+		defer c.procMu.RUnlock()
+		return c.shutdown != nil && c.closing
+	})
+
+	c.procMu.Lock()
+	c.stats.LastExitAt = time.Now()
+	c.stats.LastExitError = exitErr
+	c.stats.LastExitCode = -1
+	if cmd.ProcessState != nil {
+		c.stats.LastExitCode = cmd.ProcessState.ExitCode()
+	}
+	closing := c.closing
+	if !closing && c.restarting == nil {
+		c.restarting = make(chan struct{})
+	}
+	exited := c.exited
+	c.procMu.Unlock()
+
+	// Unblock the requests waiting on the exited process
+	close(exited)
+
+	if closing {
+		return
+	}
+	c.logger.Errorf("server process exited (%v), restarting", exitErr)
+	c.restartProcess()
+}
+
+// restartProcess respawns the subprocess with backoff until it is running
+// and re-initialized, the restart budget is exhausted or the transport closes.
+func (c *Stdio) restartProcess() {
+	c.ctxMu.RLock()
+	ctx := c.ctx
+	c.ctxMu.RUnlock()
+
+	for {
+		delay, ok := c.nextRestartDelay()
+		if !ok {
+			c.logger.Errorf("server process restarted %d times within %v, giving up",
+				c.supervisor.MaxRestarts, c.supervisor.Window)
+			c.closeDone()
+			c.finishRestart()
+			return
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-c.done:
+			c.finishRestart()
+			return
+		case <-ctx.Done():
+			c.closeDone()
+			c.finishRestart()
+			return
+		}
+
+		c.procMu.Lock()
+		if c.closing {
+			c.procMu.Unlock()
+			c.finishRestart()
+			return
+		}
+		c.restartTimes = append(c.restartTimes, time.Now())
+		err := c.spawnCommand(ctx)
+		if err == nil {
+			c.watchProcess()
+			c.stats.Restarts++
+		}
+		c.procMu.Unlock()
+
+		if err != nil {
+			c.logger.Errorf("failed to restart server process: %v", err)
+			continue
+		}
+
+		go c.readResponses()
+
+		if err := c.reinitialize(ctx); err != nil {
+			// Killing the process makes readResponses handle it as another
+			// exit, which continues this restart.
+			c.logger.Errorf("failed to re-initialize restarted server process: %v", err)
+			c.procMu.RLock()
+			cmd := c.cmd
+			c.procMu.RUnlock()
+			_ = cmd.Process.Kill()
+			return
+		}
+
+		c.finishRestart()
+		return
+	}
+}
+
+// nextRestartDelay returns the backoff before the next restart, or false if
+// the restarts within the policy window are exhausted.
+func (c *Stdio) nextRestartDelay() (time.Duration, bool) {
+	c.procMu.Lock()
+	defer c.procMu.Unlock()
+
+	policy := c.supervisor
+	cutoff := time.Now().Add(-policy.Window)
+	recent := c.restartTimes[:0]
+	for _, at := range c.restartTimes {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	c.restartTimes = recent
+
+	if len(recent) >= policy.MaxRestarts {
+		return 0, false
+	}
+	delay := policy.InitialBackoff << len(recent)
+	if delay <= 0 || delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	return delay, true
+}
+
+// finishRestart releases the requests waiting for the restart to finish.
+func (c *Stdio) finishRestart() {
+	c.procMu.Lock()
+	restarting := c.restarting
+	c.restarting = nil
+	c.procMu.Unlock()
+
+	if restarting != nil {
+		close(restarting)
+	}
+}
+
+// reinitialize replays the initialize handshake of the session on the
+// restarted subprocess, so that the client keeps working unaware of the restart.
+func (c *Stdio) reinitialize(ctx context.Context) error {
+	c.procMu.RLock()
+	initRequest := c.initRequest
+	stdin, exited := c.stdin, c.exited
+	restarts := c.stats.Restarts
+	c.procMu.RUnlock()
+
+	// The client has not initialized yet, it will do so itself
+	if initRequest == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, supervisorInitTimeout)
+	defer cancel()
+
+	request := *initRequest
+	request.ID = NewRequestId(fmt.Sprintf("supervisor-init-%d", restarts))
+	response, err := c.roundTrip(ctx, stdin, exited, request)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return fmt.Errorf("initialize failed: %s (code %d)", response.Error.Message, response.Error.Code)
+	}
+
+	return c.writeNotification(stdin, JSONRPCNotification{
+		JSONRPC: JSONRPC_VERSION,
+		Notification: Notification{
+			Method: "notifications/initialized",
+		},
+	})
+}
+
+// closeSupervised closes a supervised transport: it stops restarts, closes
+// the subprocess stdin and waits for the supervisor to reap it.
+func (c *Stdio) closeSupervised() error {
+	c.procMu.Lock()
+	c.closing = true
+	stdin, exited, group := c.stdin, c.exited, c.group
+	c.procMu.Unlock()
+
+	c.closeDone()
+
+	var closeErr error
+	c.closeCleanupOnce.Do(func() {
+		if stdin != nil {
+			if err := stdin.Close(); err != nil {
+				closeErr = fmt.Errorf("failed to close stdin: %w", err)
+			}
+		}
+		if exited != nil {
+			if c.shutdown != nil {
+				c.awaitShutdown(group, exited)
+			} else {
+				<-exited
+			}
+			if closeErr == nil {
+				closeErr = c.SupervisorStats().LastExitError
+			}
+		}
+	})
+	return closeErr
+}
+
+// tailBuffer is an io.Writer keeping only the last size bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = append(b.buf[:0:0], b.buf[len(b.buf)-b.size:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+var _ io.Writer = (*tailBuffer)(nil)
@@ -0,0 +1,115 @@
+package mcp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// TestSupervisorHelperProcess is not a real test: it is run as the supervised
+// subprocess by the tests below. It answers initialize and ping, and exits
+// with status 3 when asked to "crash".
+func TestSupervisorHelperProcess(t *testing.T) {
+	if os.Getenv("MCP_SUPERVISOR_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var message map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			continue
+		}
+		id, isRequest := message["id"]
+		if !isRequest {
+			continue
+		}
+
+		var result any = map[string]any{}
+		switch message["method"] {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+				"capabilities":    map[string]any{},
+				"serverInfo":      map[string]any{"name": "helper", "version": fmt.Sprint(os.Getpid())},
+			}
+		case "crash":
+			fmt.Fprintln(os.Stderr, "helper: crashing on purpose")
+			os.Exit(3)
+		}
+		response, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+		fmt.Fprintf(os.Stdout, "%s\n", response)
+	}
+	os.Exit(0)
+}
+
+func newSupervisedClient(t *testing.T, policy mcp.SupervisorPolicy) (*mcp.Client, *mcp.Stdio) {
+	t.Helper()
+
+	trans := mcp.NewStdioWithOptions(os.Args[0],
+		[]string{"MCP_SUPERVISOR_HELPER=1"},
+		[]string{"-test.run=^TestSupervisorHelperProcess$"},
+		mcp.WithSupervisor(policy),
+	)
+	client := mcp.NewClient(trans)
+	require.NoError(t, client.Start(context.Background()))
+	_, err := client.Initialize(context.Background(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	return client, trans
+}
+
+func crash(client *mcp.Client) error {
+	_, err := client.GetTransport().SendRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId("crash"),
+		Request: mcp.Request{Method: "crash"},
+	})
+	return err
+}
+
+func TestStdioSupervisor_RestartsAndReinitializes(t *testing.T) {
+	client, trans := newSupervisedClient(t, mcp.SupervisorPolicy{InitialBackoff: time.Millisecond})
+	defer client.Close()
+
+	// The request in flight when the process dies fails
+	assert.ErrorIs(t, crash(client), mcp.ErrProcessExited)
+
+	// Later requests wait for the restart and run on the re-initialized session
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.Ping(ctx))
+
+	stats := trans.SupervisorStats()
+	assert.Equal(t, 1, stats.Restarts)
+	assert.Equal(t, 3, stats.LastExitCode)
+	assert.Error(t, stats.LastExitError)
+	assert.Contains(t, stats.StderrTail, "crashing on purpose")
+}
+
+func TestStdioSupervisor_GivesUpAfterMaxRestarts(t *testing.T) {
+	client, trans := newSupervisedClient(t, mcp.SupervisorPolicy{
+		MaxRestarts:    1,
+		Window:         time.Minute,
+		InitialBackoff: time.Millisecond,
+	})
+	defer client.Close()
+
+	assert.ErrorIs(t, crash(client), mcp.ErrProcessExited)
+	require.NoError(t, client.Ping(context.Background()))
+
+	// The second crash within the window exceeds the restart budget
+	assert.ErrorIs(t, crash(client), mcp.ErrProcessExited)
+	require.Eventually(t, func() bool {
+		return client.Ping(context.Background()) != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, client.Ping(context.Background()), mcp.ErrTransportClosed)
+	assert.Equal(t, 1, trans.SupervisorStats().Restarts)
+}
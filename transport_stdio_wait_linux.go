@@ -0,0 +1,26 @@
+package mcp
+
+import (
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// pPID is the P_PID id type of waitid.
+const pPID = 1
+
+// waitExited blocks until the process of cmd has exited, leaving it
+// unreaped, and reports whether it could wait for it.
+func waitExited(cmd *exec.Cmd) bool {
+	if cmd.Process == nil {
+		return false
+	}
+	var info [16]uint64 // siginfo_t
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, pPID, uintptr(cmd.Process.Pid),
+			uintptr(unsafe.Pointer(&info)), syscall.WEXITED|syscall.WNOWAIT, 0, 0)
+		if errno != syscall.EINTR {
+			return errno == 0
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package mcp
+
+import "os/exec"
+
+// waitExited reports that the exit of a process cannot be waited for
+// without reaping it on this platform.
+func waitExited(cmd *exec.Cmd) bool {
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -102,9 +103,11 @@ func WithStreamableHTTPHost(host string) StreamableHTTPCOption {
 //
 // https://modelcontextprotocol.io/specification/2025-03-26/basic/transports
 //
-// The current implementation does not support the following features:
-//   - resuming stream
-//     (https://modelcontextprotocol.io/specification/2025-03-26/basic/transports#resumability-and-redelivery)
+// When the server attaches event IDs to SSE events, interrupted streams are resumed
+// by reconnecting with a GET request carrying the Last-Event-ID header, so events
+// sent during a disconnect are redelivered. This applies both to the stream of a
+// POST response still waiting for its JSON-RPC response and to the listening GET stream.
+// https://modelcontextprotocol.io/specification/2025-03-26/basic/transports#resumability-and-redelivery
 type StreamableHTTP struct {
 	serverURL           *url.URL
 	httpClient          *http.Client
@@ -234,6 +237,20 @@ func (c *StreamableHTTP) Close() error {
 	return nil
 }
 
+// Reconnect implements Reconnectable. Streamable HTTP has no long-lived
+// connection to restore: it forgets the current session ID so that the next
+// initialize request starts a new session. The listening GET stream, if
+// enabled, keeps retrying on its own and picks up the new session.
+func (c *StreamableHTTP) Reconnect(ctx context.Context) error {
+	select {
+	case <-c.closed:
+		return fmt.Errorf("transport is closed")
+	default:
+	}
+	c.sessionID.Store("")
+	return nil
+}
+
 // SetProtocolVersion sets the negotiated protocol version for this connection.
 func (c *StreamableHTTP) SetProtocolVersion(version string) {
 	c.protocolVersion.Store(version)
@@ -342,7 +359,12 @@ func (c *StreamableHTTP) SendRequest(
 
 	case "text/event-stream":
 		// Server is using SSE for streaming responses
-		return c.handleSSEResponse(ctx, resp.Body, false)
+		stream := &sseStream{}
+		response, err := c.handleSSEResponse(ctx, resp.Body, false, stream)
+		if errors.Is(err, errSSEStreamClosed) && stream.resumable() {
+			return c.resumeSSEResponse(ctx, stream)
+		}
+		return response, err
 
 	default:
 		return nil, fmt.Errorf("unexpected content type: %s", resp.Header.Get("Content-Type"))
@@ -411,7 +433,7 @@ func (c *StreamableHTTP) sendHTTP(
 	}
 
 	// Send request
-	resp, err = c.httpClient.Do(req)
+	resp, err = doWithOAuth(c.httpClient, c.oauthHandler, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -425,10 +447,102 @@ func (c *StreamableHTTP) sendHTTP(
 	return resp, nil
 }
 
+// sseStream tracks the resumption state of one SSE stream across reconnections.
+type sseStream struct {
+	mu          sync.Mutex
+	lastEventID string
+	retry       time.Duration // Reconnection delay requested by the server, 0 if unset
+}
+
+// resumable reports whether the server has sent an event ID to resume from.
+func (s *sseStream) resumable() bool {
+	return s.lastID() != ""
+}
+
+// lastID returns the ID of the last event received on the stream.
+func (s *sseStream) lastID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastEventID
+}
+
+// retryInterval returns the delay to wait before reconnecting the stream.
+func (s *sseStream) retryInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.retry > 0 {
+		return s.retry
+	}
+	return retryInterval
+}
+
+// resumeHeader returns the request header used to reconnect the stream.
+func (s *sseStream) resumeHeader() http.Header {
+	header := make(http.Header)
+	if lastEventID := s.lastID(); lastEventID != "" {
+		header.Set(HeaderKeyLastEventID, lastEventID)
+	}
+	return header
+}
+
+// maxStreamResumeAttempts is the number of consecutive failed reconnections
+// after which an interrupted POST response stream is given up.
+const maxStreamResumeAttempts = 5
+
+// errSSEStreamClosed is returned by handleSSEResponse when the stream ends
+// before the response to the request was received.
+var errSSEStreamClosed = errors.New("SSE stream closed before the response was received")
+
+// resumeSSEResponse reconnects an interrupted POST response stream with GET
+// requests carrying Last-Event-ID until the server redelivers the response.
+func (c *StreamableHTTP) resumeSSEResponse(ctx context.Context, stream *sseStream) (*JSONRPCResponse, error) {
+	var lastErr error = errSSEStreamClosed
+	for attempt := 0; attempt < maxStreamResumeAttempts; attempt++ {
+		select {
+		case <-time.After(stream.retryInterval()):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		resumedFrom := stream.lastID()
+		resp, err := c.sendHTTP(ctx, http.MethodGet, nil, "text/event-stream", stream.resumeHeader())
+		if err != nil {
+			if errors.Is(err, ErrSessionTerminated) || ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to resume stream: %w", err)
+			}
+			lastErr = err
+			continue
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if resp.StatusCode != http.StatusOK || mediaType != "text/event-stream" {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusMethodNotAllowed {
+				return nil, fmt.Errorf("failed to resume stream: %w", ErrGetMethodNotAllowed)
+			}
+			lastErr = fmt.Errorf("resume request failed with status %d", resp.StatusCode)
+			continue
+		}
+
+		response, err := c.handleSSEResponse(ctx, resp.Body, false, stream)
+		if !errors.Is(err, errSSEStreamClosed) {
+			return response, err
+		}
+		lastErr = err
+
+		// A reconnection that delivered events restarts the attempt budget
+		if stream.lastID() != resumedFrom {
+			attempt = -1
+		}
+	}
+	return nil, fmt.Errorf("failed to resume stream after %d attempts: %w", maxStreamResumeAttempts, lastErr)
+}
+
 // handleSSEResponse processes an SSE stream for a specific request.
 // It returns the final result for the request once received, or an error.
 // If ignoreResponse is true, it won't return when a response messge is received. This is for continuous listening.
-func (c *StreamableHTTP) handleSSEResponse(ctx context.Context, reader io.ReadCloser, ignoreResponse bool) (*JSONRPCResponse, error) {
+// The event ID and reconnection delay announced by the server are recorded in stream.
+func (c *StreamableHTTP) handleSSEResponse(ctx context.Context, reader io.ReadCloser, ignoreResponse bool, stream *sseStream) (*JSONRPCResponse, error) {
 	// Create a channel for this specific request
 	responseChan := make(chan *JSONRPCResponse, 1)
 
@@ -440,7 +554,7 @@ func (c *StreamableHTTP) handleSSEResponse(ctx context.Context, reader io.ReadCl
 		// Ensure this goroutine respects the context
 		defer close(responseChan)
 
-		c.readSSE(ctx, reader, func(event, data string) {
+		c.readSSE(ctx, reader, stream, func(event, data string) {
 			// Try to unmarshal as a response first
 			var message JSONRPCResponse
 			if err := json.Unmarshal([]byte(data), &message); err != nil {
@@ -486,7 +600,7 @@ func (c *StreamableHTTP) handleSSEResponse(ctx context.Context, reader io.ReadCl
 	select {
 	case response := <-responseChan:
 		if response == nil {
-			return nil, fmt.Errorf("unexpected nil response")
+			return nil, errSSEStreamClosed
 		}
 		return response, nil
 	case <-ctx.Done():
@@ -495,12 +609,14 @@ func (c *StreamableHTTP) handleSSEResponse(ctx context.Context, reader io.ReadCl
 }
 
 // readSSE reads the SSE stream(reader) and calls the handler for each event and data pair.
+// Event IDs and retry fields are recorded in stream once their event is dispatched.
 // It will end when the reader is closed (or the context is done).
-func (c *StreamableHTTP) readSSE(ctx context.Context, reader io.ReadCloser, handler func(event, data string)) {
+func (c *StreamableHTTP) readSSE(ctx context.Context, reader io.ReadCloser, stream *sseStream, handler func(event, data string)) {
 	defer reader.Close()
 
 	br := bufio.NewReader(reader)
-	var event, data string
+	var event, data, id string
+	var hasID bool
 
 	for {
 		select {
@@ -532,7 +648,14 @@ func (c *StreamableHTTP) readSSE(ctx context.Context, reader io.ReadCloser, hand
 			// Remove only newline markers
 			line = strings.TrimRight(line, "\r\n")
 			if line == "" {
-				// Empty line means end of event
+				// Empty line means end of event. The event ID is recorded even
+				// for events without data, which servers use to prime resumption.
+				if hasID {
+					stream.mu.Lock()
+					stream.lastEventID = id
+					stream.mu.Unlock()
+					hasID = false
+				}
 				if data != "" {
 					// If no event type is specified, use empty string (default event type)
 					if event == "" {
@@ -549,6 +672,15 @@ func (c *StreamableHTTP) readSSE(ctx context.Context, reader io.ReadCloser, hand
 				event = strings.TrimSpace(eventStr)
 			} else if dataStr, ok := strings.CutPrefix(line, "data:"); ok {
 				data = strings.TrimSpace(dataStr)
+			} else if idStr, ok := strings.CutPrefix(line, "id:"); ok {
+				id = strings.TrimSpace(idStr)
+				hasID = true
+			} else if retryStr, ok := strings.CutPrefix(line, "retry:"); ok {
+				if ms, err := strconv.Atoi(strings.TrimSpace(retryStr)); err == nil && ms >= 0 {
+					stream.mu.Lock()
+					stream.retry = time.Duration(ms) * time.Millisecond
+					stream.mu.Unlock()
+				}
 			}
 		}
 	}
@@ -620,7 +752,15 @@ func (c *StreamableHTTP) IsOAuthEnabled() bool {
 
 func (c *StreamableHTTP) listenForever(ctx context.Context) {
 	c.logger.Infof("listening to server forever")
+	// The stream state is kept across reconnections so that each new GET
+	// request resumes after the last event received. Event IDs belong to
+	// the session that issued them, so a new session starts a new stream.
+	var stream *sseStream
+	var streamSessionID string
 	for {
+		if sessionID := c.sessionID.Load().(string); stream == nil || sessionID != streamSessionID {
+			stream, streamSessionID = &sseStream{}, sessionID
+		}
 		// Use the original context for continuous listening - no per-iteration timeout
 		// The SSE connection itself will detect disconnections via the underlying HTTP transport,
 		// and the context cancellation will propagate from the parent to stop listening gracefully.
@@ -628,7 +768,7 @@ func (c *StreamableHTTP) listenForever(ctx context.Context) {
 		// 1. Persistent SSE connections are meant to stay open indefinitely
 		// 2. Network-level timeouts and keep-alives handle connection health
 		// 3. Context cancellation (user-initiated or system shutdown) provides clean shutdown
-		err := c.createGETConnectionToServer(ctx)
+		err := c.createGETConnectionToServer(ctx, stream)
 		if errors.Is(err, ErrGetMethodNotAllowed) {
 			// server does not support listening
 			c.logger.Errorf("server does not support listening")
@@ -647,7 +787,7 @@ func (c *StreamableHTTP) listenForever(ctx context.Context) {
 
 		// Use context-aware sleep
 		select {
-		case <-time.After(retryInterval):
+		case <-time.After(stream.retryInterval()):
 		case <-ctx.Done():
 			return
 		}
@@ -662,8 +802,8 @@ var (
 	retryInterval = 1 * time.Second // a variable is convenient for testing
 )
 
-func (c *StreamableHTTP) createGETConnectionToServer(ctx context.Context) error {
-	resp, err := c.sendHTTP(ctx, http.MethodGet, nil, "text/event-stream", nil)
+func (c *StreamableHTTP) createGETConnectionToServer(ctx context.Context, stream *sseStream) error {
+	resp, err := c.sendHTTP(ctx, http.MethodGet, nil, "text/event-stream", stream.resumeHeader())
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -690,8 +830,8 @@ func (c *StreamableHTTP) createGETConnectionToServer(ctx context.Context) error
 	// messages. To be more compatible, we should handle this response, however, as the transport layer is message-based,
 	// currently, there is no convenient way to handle this response.
 	// So we ignore the response here. It's not a bug, but may be not compatible with other SDKs.
-	_, err = c.handleSSEResponse(ctx, resp.Body, true)
-	if err != nil {
+	_, err = c.handleSSEResponse(ctx, resp.Body, true, stream)
+	if err != nil && !errors.Is(err, errSSEStreamClosed) {
 		return fmt.Errorf("failed to handle SSE response: %w", err)
 	}
 
@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tinywasm/mcp/util"
+)
+
+// UnixSocket implements the transport layer of the MCP protocol over a Unix
+// domain socket, such as the one of a UnixSocketServer. Messages are
+// newline-delimited JSON-RPC, exchanged the same way as with Stdio.
+type UnixSocket struct {
+	path        string
+	dialTimeout time.Duration
+	stream      *Stdio
+
+	mu      sync.Mutex
+	started bool
+}
+
+// UnixSocketOption defines a function that configures a UnixSocket transport instance.
+type UnixSocketOption func(*UnixSocket)
+
+// WithUnixSocketDialTimeout limits the time spent connecting to the socket.
+func WithUnixSocketDialTimeout(timeout time.Duration) UnixSocketOption {
+	return func(c *UnixSocket) {
+		c.dialTimeout = timeout
+	}
+}
+
+// WithUnixSocketLogger sets a custom logger for the transport.
+func WithUnixSocketLogger(logger util.Logger) UnixSocketOption {
+	return func(c *UnixSocket) {
+		c.stream.logger = logger
+	}
+}
+
+// NewUnixSocket creates a transport connecting to the socket at path on Start.
+func NewUnixSocket(path string, options ...UnixSocketOption) *UnixSocket {
+	// The streams are set by Start, once connected
+	c := &UnixSocket{path: path, stream: newStdio()}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// NewUnixSocketMCPClient creates a client for the MCP server listening on
+// the socket at path. The connection is opened by Client.Start.
+func NewUnixSocketMCPClient(path string, options ...UnixSocketOption) (*Client, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+	return NewClient(NewUnixSocket(path, options...)), nil
+}
+
+// Start connects to the socket. Calling Start again is a no-op.
+func (c *UnixSocket) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: c.dialTimeout}
+	conn, err := dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.path, err)
+	}
+	c.stream.stdin = conn
+	c.stream.stdout = bufio.NewReader(conn)
+	if err := c.stream.Start(ctx); err != nil {
+		conn.Close()
+		return err
+	}
+	c.started = true
+	return nil
+}
+
+// SendRequest sends a JSON-RPC request to the server and waits for its response.
+func (c *UnixSocket) SendRequest(ctx context.Context, request JSONRPCRequest) (*JSONRPCResponse, error) {
+	if err := c.checkStarted(); err != nil {
+		return nil, err
+	}
+	return c.stream.SendRequest(ctx, request)
+}
+
+// SendNotification sends a JSON-RPC notification to the server.
+func (c *UnixSocket) SendNotification(ctx context.Context, notification JSONRPCNotification) error {
+	if err := c.checkStarted(); err != nil {
+		return err
+	}
+	return c.stream.SendNotification(ctx, notification)
+}
+
+// SetNotificationHandler sets the handler called for notifications from the server.
+func (c *UnixSocket) SetNotificationHandler(handler func(notification JSONRPCNotification)) {
+	c.stream.SetNotificationHandler(handler)
+}
+
+// SetRequestHandler sets the handler called for requests from the server,
+// such as sampling or elicitation requests.
+func (c *UnixSocket) SetRequestHandler(handler RequestHandler) {
+	c.stream.SetRequestHandler(handler)
+}
+
+// Close closes the connection. Requests waiting for a response fail with
+// ErrTransportClosed.
+func (c *UnixSocket) Close() error {
+	return c.stream.Close()
+}
+
+// GetSessionId returns an empty string: the connection is the session.
+func (c *UnixSocket) GetSessionId() string {
+	return ""
+}
+
+func (c *UnixSocket) checkStarted() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.started {
+		return fmt.Errorf("unix socket transport not started")
+	}
+	return nil
+}
+
+var _ BidirectionalInterface = (*UnixSocket)(nil)
@@ -0,0 +1,496 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/tinywasm/mcp/util"
+)
+
+// WebSocket implements the transport layer of the MCP protocol over a single
+// WebSocket connection negotiating the "mcp" subprotocol.
+//
+// JSON-RPC messages travel as text messages in both directions, so the server
+// can send requests (sampling, elicitation, roots) as well as notifications.
+// The connection is kept alive with pings; when it is lost, in-flight
+// requests fail and the connection lost handler is called.
+type WebSocket struct {
+	url            *url.URL
+	httpClient     *http.Client
+	headers        map[string]string
+	headerFunc     HTTPHeaderFunc
+	maxMessageSize int64
+	pingInterval   time.Duration
+	logger         util.Logger
+
+	connMu  sync.RWMutex
+	conn    *wsConn
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	closed  bool
+
+	responses map[string]chan *JSONRPCResponse
+	mu        sync.RWMutex
+
+	onNotification   func(JSONRPCNotification)
+	notifyMu         sync.RWMutex
+	onRequest        RequestHandler
+	requestMu        sync.RWMutex
+	connectionLost   func(error)
+	connectionLostMu sync.RWMutex
+}
+
+// WebSocketOption defines a function that configures a WebSocket transport instance.
+type WebSocketOption func(*WebSocket)
+
+// WithWebSocketHeaders sets HTTP headers sent with the opening handshake.
+func WithWebSocketHeaders(headers map[string]string) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.headers = headers
+	}
+}
+
+// WithWebSocketHeaderFunc sets a function computing extra handshake headers from the context.
+func WithWebSocketHeaderFunc(headerFunc HTTPHeaderFunc) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.headerFunc = headerFunc
+	}
+}
+
+// WithWebSocketHTTPClient sets the HTTP client performing the opening
+// handshake. Its transport must speak HTTP/1.1, and it must not set a
+// Timeout, which would also bound the lifetime of the connection.
+func WithWebSocketHTTPClient(httpClient *http.Client) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.httpClient = httpClient
+	}
+}
+
+// WithWebSocketMaxMessageSize limits the size of messages received from the
+// server. Defaults to DefaultWebSocketMaxMessageSize.
+func WithWebSocketMaxMessageSize(size int64) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.maxMessageSize = size
+	}
+}
+
+// WithWebSocketPingInterval sets the interval between keepalive pings; the
+// connection is considered lost after two intervals without traffic from the
+// server. Defaults to DefaultWebSocketPingInterval, a negative value disables pings.
+func WithWebSocketPingInterval(interval time.Duration) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.pingInterval = interval
+	}
+}
+
+// WithWebSocketLogger sets a custom logger for the WebSocket transport.
+func WithWebSocketLogger(logger util.Logger) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.logger = logger
+	}
+}
+
+// NewWebSocket creates a WebSocket transport for the given ws:// or wss://
+// URL (http:// and https:// are accepted too).
+func NewWebSocket(rawURL string, options ...WebSocketOption) (*WebSocket, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	switch parsedURL.Scheme {
+	case "ws":
+		parsedURL.Scheme = "http"
+	case "wss":
+		parsedURL.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported URL scheme: %q", parsedURL.Scheme)
+	}
+
+	ws := &WebSocket{
+		url:            parsedURL,
+		maxMessageSize: DefaultWebSocketMaxMessageSize,
+		pingInterval:   DefaultWebSocketPingInterval,
+		responses:      make(map[string]chan *JSONRPCResponse),
+		logger:         util.DefaultLogger(),
+	}
+	for _, opt := range options {
+		opt(ws)
+	}
+	if ws.httpClient == nil {
+		// The opening handshake requires HTTP/1.1
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		ws.httpClient = &http.Client{Transport: transport}
+	}
+	return ws, nil
+}
+
+// NewWebSocketMCPClient creates a new client connecting to an MCP server over WebSocket.
+func NewWebSocketMCPClient(rawURL string, options ...WebSocketOption) (*Client, error) {
+	transport, err := NewWebSocket(rawURL, options...)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(transport), nil
+}
+
+// Start opens the WebSocket connection. Calling Start again is a no-op.
+func (c *WebSocket) Start(ctx context.Context) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.started {
+		return nil
+	}
+	if c.closed {
+		return ErrTransportClosed
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.conn = conn
+	c.started = true
+	c.serve(conn)
+	return nil
+}
+
+// Reconnect discards the current connection and opens a new one. Requests
+// waiting for a response on the old connection fail.
+func (c *WebSocket) Reconnect(ctx context.Context) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.closed {
+		return ErrTransportClosed
+	}
+	old := c.conn
+	c.conn = nil
+	if old != nil {
+		old.abort("reconnecting")
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if c.ctx == nil {
+		c.ctx, c.cancel = context.WithCancel(context.Background())
+	}
+	c.conn = conn
+	c.started = true
+	c.serve(conn)
+	return nil
+}
+
+// dial performs the opening handshake.
+func (c *WebSocket) dial(ctx context.Context) (*wsConn, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if c.headerFunc != nil {
+		for k, v := range c.headerFunc(ctx) {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Protocol", WebSocketSubprotocol)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebSocketHandshake, err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: unexpected status %d: %s", ErrWebSocketHandshake, resp.StatusCode, body)
+	}
+
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	switch {
+	case !ok:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: connection is not writable", ErrWebSocketHandshake)
+	case !headerHasToken(resp.Header, "Upgrade", "websocket"):
+		rwc.Close()
+		return nil, fmt.Errorf("%w: missing upgrade header", ErrWebSocketHandshake)
+	case resp.Header.Get("Sec-WebSocket-Accept") != webSocketAcceptKey(key):
+		rwc.Close()
+		return nil, fmt.Errorf("%w: invalid accept key", ErrWebSocketHandshake)
+	case resp.Header.Get("Sec-WebSocket-Protocol") != WebSocketSubprotocol:
+		rwc.Close()
+		return nil, fmt.Errorf("%w: server did not select the %q subprotocol", ErrWebSocketHandshake, WebSocketSubprotocol)
+	}
+
+	return newWSConn(rwc, bufio.NewReader(rwc), true, c.maxMessageSize), nil
+}
+
+// serve starts reading messages from conn and keeping it alive.
+func (c *WebSocket) serve(conn *wsConn) {
+	go conn.keepAlive(c.pingInterval)
+	go c.readMessages(conn)
+}
+
+// readMessages dispatches incoming messages until the connection is closed.
+func (c *WebSocket) readMessages(conn *wsConn) {
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			c.handleConnectionClosed(conn, err)
+			return
+		}
+
+		// First try to parse as a generic message to check for ID field
+		var baseMessage struct {
+			ID     *RequestId `json:"id,omitempty"`
+			Method string     `json:"method,omitempty"`
+		}
+		if err := json.Unmarshal(message, &baseMessage); err != nil {
+			c.logger.Errorf("Error parsing WebSocket message: %v", err)
+			continue
+		}
+
+		switch {
+		case baseMessage.Method != "" && baseMessage.ID == nil:
+			var notification JSONRPCNotification
+			if err := json.Unmarshal(message, &notification); err != nil {
+				continue
+			}
+			c.notifyMu.RLock()
+			if c.onNotification != nil {
+				c.onNotification(notification)
+			}
+			c.notifyMu.RUnlock()
+		case baseMessage.Method != "":
+			var request JSONRPCRequest
+			if err := json.Unmarshal(message, &request); err == nil {
+				c.handleIncomingRequest(conn, request)
+			}
+		default:
+			var response JSONRPCResponse
+			if err := json.Unmarshal(message, &response); err != nil {
+				continue
+			}
+			idKey := response.ID.String()
+			c.mu.Lock()
+			ch, exists := c.responses[idKey]
+			delete(c.responses, idKey)
+			c.mu.Unlock()
+			if exists {
+				ch <- &response
+			}
+		}
+	}
+}
+
+// handleConnectionClosed reports the loss of conn unless the transport was
+// closed or reconnected on purpose.
+func (c *WebSocket) handleConnectionClosed(conn *wsConn, err error) {
+	c.connMu.RLock()
+	lost := !c.closed && c.conn == conn
+	c.connMu.RUnlock()
+	if !lost {
+		return
+	}
+
+	c.logger.Errorf("WebSocket connection lost: %v", err)
+	c.connectionLostMu.RLock()
+	handler := c.connectionLost
+	c.connectionLostMu.RUnlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// current returns the open connection.
+func (c *WebSocket) current() (*wsConn, context.Context, error) {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if c.closed {
+		return nil, nil, ErrTransportClosed
+	}
+	if c.conn == nil {
+		return nil, nil, fmt.Errorf("websocket transport not started")
+	}
+	return c.conn, c.ctx, nil
+}
+
+// SendRequest sends a JSON-RPC request to the server and waits for its response.
+func (c *WebSocket) SendRequest(ctx context.Context, request JSONRPCRequest) (*JSONRPCResponse, error) {
+	conn, _, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	idKey := request.ID.String()
+	responseChan := make(chan *JSONRPCResponse, 1)
+	c.mu.Lock()
+	c.responses[idKey] = responseChan
+	c.mu.Unlock()
+	deleteResponseChan := func() {
+		c.mu.Lock()
+		delete(c.responses, idKey)
+		c.mu.Unlock()
+	}
+
+	if err := conn.writeMessage(requestBytes); err != nil {
+		deleteResponseChan()
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	select {
+	case response := <-responseChan:
+		return response, nil
+	case <-conn.closed:
+		select {
+		case response := <-responseChan:
+			return response, nil
+		default:
+		}
+		deleteResponseChan()
+		return nil, fmt.Errorf("%w: %w", ErrTransportClosed, conn.closeError())
+	case <-ctx.Done():
+		deleteResponseChan()
+		return nil, ctx.Err()
+	}
+}
+
+// SendNotification sends a JSON-RPC notification to the server.
+func (c *WebSocket) SendNotification(ctx context.Context, notification JSONRPCNotification) error {
+	conn, _, err := c.current()
+	if err != nil {
+		return err
+	}
+	notificationBytes, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	if err := conn.writeMessage(notificationBytes); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	return nil
+}
+
+// handleIncomingRequest runs the request handler for a request from the
+// server and sends the response back on the same connection.
+func (c *WebSocket) handleIncomingRequest(conn *wsConn, request JSONRPCRequest) {
+	c.requestMu.RLock()
+	handler := c.onRequest
+	c.requestMu.RUnlock()
+
+	if handler == nil {
+		c.sendResponse(conn, *NewJSONRPCErrorResponse(request.ID, METHOD_NOT_FOUND, "No request handler configured", nil))
+		return
+	}
+
+	_, ctx, err := c.current()
+	if err != nil {
+		return
+	}
+	go func() {
+		response, err := handler(ctx, request)
+		if err != nil {
+			c.sendResponse(conn, *NewJSONRPCErrorResponse(request.ID, INTERNAL_ERROR, err.Error(), nil))
+			return
+		}
+		if response != nil {
+			c.sendResponse(conn, *response)
+		}
+	}()
+}
+
+func (c *WebSocket) sendResponse(conn *wsConn, response JSONRPCResponse) {
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		c.logger.Errorf("Error marshaling response: %v", err)
+		return
+	}
+	if err := conn.writeMessage(responseBytes); err != nil {
+		c.logger.Errorf("Error writing response: %v", err)
+	}
+}
+
+// SetNotificationHandler sets the handler for notifications from the server.
+func (c *WebSocket) SetNotificationHandler(handler func(notification JSONRPCNotification)) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	c.onNotification = handler
+}
+
+// SetRequestHandler sets the handler for requests from the server.
+func (c *WebSocket) SetRequestHandler(handler RequestHandler) {
+	c.requestMu.Lock()
+	defer c.requestMu.Unlock()
+	c.onRequest = handler
+}
+
+// SetConnectionLostHandler sets a handler called when the connection is lost
+// for any other reason than Close.
+func (c *WebSocket) SetConnectionLostHandler(handler func(error)) {
+	c.connectionLostMu.Lock()
+	defer c.connectionLostMu.Unlock()
+	c.connectionLost = handler
+}
+
+// Close performs the closing handshake and closes the connection.
+func (c *WebSocket) Close() error {
+	c.connMu.Lock()
+	if c.closed {
+		c.connMu.Unlock()
+		return nil
+	}
+	c.closed = true
+	conn := c.conn
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.connMu.Unlock()
+
+	if conn != nil {
+		return conn.close(WebSocketCloseNormal, "")
+	}
+	return nil
+}
+
+// GetSessionId returns an empty string: a WebSocket connection is its own session.
+func (c *WebSocket) GetSessionId() string {
+	return ""
+}
+
+var (
+	_ BidirectionalInterface = (*WebSocket)(nil)
+	_ Reconnectable          = (*WebSocket)(nil)
+)
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/tinywasm/mcp/internal/jsonschema"
+)
+
+// TypedElicitationResult is the response to Elicit. Content is only set
+// when the user accepted.
+type TypedElicitationResult[T any] struct {
+	Action  ElicitationResponseAction
+	Content T
+}
+
+// Accepted reports whether the user provided the requested information.
+func (r *TypedElicitationResult[T]) Accepted() bool {
+	return r.Action == ElicitationResponseActionAccept
+}
+
+// elicitationFormats are the string formats form elicitation allows.
+var elicitationFormats = map[string]bool{"email": true, "uri": true, "date": true, "date-time": true}
+
+// elicitationSchemas caches the requested schemas by type.
+var elicitationSchemas sync.Map // reflect.Type -> elicitationSchema
+
+type elicitationSchema struct {
+	raw json.RawMessage
+	m   map[string]any
+	err error
+}
+
+// ElicitationSchema returns the requested schema of a form elicitation for
+// T, a struct whose fields are strings, numbers, booleans, string enums or
+// slices of string enums. Titles, descriptions, defaults, formats and
+// limits are taken from jsonschema tags; fields without omitempty are
+// required. Other shapes, such as nested structs, are rejected with
+// ErrUnsupportedElicitationSchema since clients render the schema as a
+// flat form.
+func ElicitationSchema[T any]() (json.RawMessage, error) {
+	schema := elicitationSchemaFor[T]()
+	return schema.raw, schema.err
+}
+
+func elicitationSchemaFor[T any]() elicitationSchema {
+	typ := reflect.TypeFor[T]()
+	if cached, ok := elicitationSchemas.Load(typ); ok {
+		return cached.(elicitationSchema)
+	}
+
+	var zero T
+	reflector := jsonschema.Reflector{
+		DoNotReference:            true,
+		Anonymous:                 true,
+		AllowAdditionalProperties: true,
+	}
+	schema := elicitationSchema{}
+	requested, err := restrictElicitationSchema(reflector.Reflect(zero))
+	if err == nil {
+		schema.raw, err = json.Marshal(requested)
+	}
+	if err == nil {
+		schema.m, err = schemaToMap(schema.raw)
+	}
+	schema.err = err
+	elicitationSchemas.Store(typ, schema)
+	return schema
+}
+
+// restrictElicitationSchema copies the keywords form elicitation allows
+// from a reflected schema, keeping the order of the properties.
+func restrictElicitationSchema(schema *jsonschema.Schema) (*jsonschema.Schema, error) {
+	if schema.Type != "object" || schema.Properties == nil {
+		return nil, fmt.Errorf("%w: requested schema must be a struct, got %q", ErrUnsupportedElicitationSchema, schema.Type)
+	}
+	requested := &jsonschema.Schema{
+		Type:       "object",
+		Properties: jsonschema.NewProperties(),
+		Required:   schema.Required,
+	}
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		property, err := restrictElicitationProperty(pair.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: property %q: %w", ErrUnsupportedElicitationSchema, pair.Key, err)
+		}
+		requested.Properties.Set(pair.Key, property)
+	}
+	return requested, nil
+}
+
+func restrictElicitationProperty(schema *jsonschema.Schema) (*jsonschema.Schema, error) {
+	property := &jsonschema.Schema{
+		Type:        schema.Type,
+		Title:       schema.Title,
+		Description: schema.Description,
+		Default:     schema.Default,
+	}
+	switch schema.Type {
+	case "string":
+		if schema.Format != "" && !elicitationFormats[schema.Format] {
+			return nil, fmt.Errorf("format %q is not one of email, uri, date or date-time", schema.Format)
+		}
+		property.Format = schema.Format
+		property.MinLength = schema.MinLength
+		property.MaxLength = schema.MaxLength
+		property.Enum = schema.Enum
+	case "number", "integer":
+		property.Minimum = schema.Minimum
+		property.Maximum = schema.Maximum
+		if len(schema.Enum) > 0 {
+			return nil, fmt.Errorf("enums must be strings")
+		}
+	case "boolean":
+	case "array":
+		// Multi-select: an array of values from a string enum
+		if schema.Items == nil || schema.Items.Type != "string" || len(schema.Items.Enum) == 0 {
+			return nil, fmt.Errorf("arrays must have string enum items")
+		}
+		property.Items = &jsonschema.Schema{Type: "string", Enum: schema.Items.Enum}
+		property.MinItems = schema.MinItems
+		property.MaxItems = schema.MaxItems
+	default:
+		return nil, fmt.Errorf("type %q is not a primitive", schema.Type)
+	}
+	return property, nil
+}
+
+// Elicit asks the user of the client that sent the current request for the
+// fields of T, through an elicitation request with the schema returned by
+// ElicitationSchema. It must be called from a request handler, such as a
+// tool handler, and returns ErrSessionDoesNotSupportElicitation if the
+// session cannot send elicitation requests.
+//
+// Accepted content is validated against the schema before it is decoded
+// into T; content that does not match is returned as an error wrapping
+// ErrInvalidElicitationContent.
+func Elicit[T any](ctx context.Context, message string) (*TypedElicitationResult[T], error) {
+	schema := elicitationSchemaFor[T]()
+	if schema.err != nil {
+		return nil, schema.err
+	}
+	session, ok := ClientSessionFromContext(ctx).(SessionWithElicitation)
+	if !ok {
+		return nil, ErrSessionDoesNotSupportElicitation
+	}
+
+	result, err := session.RequestElicitation(ctx, ElicitationRequest{
+		Params: ElicitationParams{
+			Message:         message,
+			RequestedSchema: schema.raw,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	typed := &TypedElicitationResult[T]{Action: result.Action}
+	if !typed.Accepted() {
+		return typed, nil
+	}
+	data, err := json.Marshal(result.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal elicitation content: %w", err)
+	}
+	var content any
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidElicitationContent, err)
+	}
+	if err := validateJSONSchema("content", content, schema.m); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidElicitationContent, err)
+	}
+	if err := json.Unmarshal(data, &typed.Content); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidElicitationContent, err)
+	}
+	return typed, nil
+}
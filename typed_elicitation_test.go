@@ -0,0 +1,184 @@
+package mcp_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+type contactForm struct {
+	Name       string   `json:"name" jsonschema:"title=Full name,minLength=1"`
+	Email      string   `json:"email" jsonschema:"format=email"`
+	Age        int      `json:"age,omitempty" jsonschema:"minimum=0,maximum=150"`
+	Plan       string   `json:"plan,omitempty" jsonschema:"enum=free,enum=pro,default=free"`
+	Topics     []string `json:"topics,omitempty" jsonschema:"enum=news,enum=updates"`
+	Newsletter bool     `json:"newsletter,omitempty" jsonschema:"description=Receive the newsletter"`
+}
+
+type elicitationHandlerFunc func(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error)
+
+func (f elicitationHandlerFunc) Elicit(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	return f(ctx, request)
+}
+
+// callWithElicitation calls a tool running handler on an in-process client
+// answering elicitations with answer.
+func callWithElicitation(t *testing.T, answer elicitationHandlerFunc, handler mcp.ToolHandlerFunc) {
+	t.Helper()
+	server := mcp.NewMCPServer("elicit-server", "1.0.0", mcp.WithElicitation())
+	server.AddTool(mcp.NewTool("signup"), handler)
+
+	transport := mcp.NewInProcessTransportWithOptions(server, mcp.WithInProcessElicitationHandler(answer))
+	client := mcp.NewClient(transport, mcp.WithElicitationHandler(answer))
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "signup"
+	_, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+}
+
+func accept(content map[string]any) elicitationHandlerFunc {
+	return func(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+			Action:  mcp.ElicitationResponseActionAccept,
+			Content: content,
+		}}, nil
+	}
+}
+
+func TestElicitationSchema(t *testing.T) {
+	schema, err := mcp.ElicitationSchema[contactForm]()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "title": "Full name", "minLength": 1},
+			"email": {"type": "string", "format": "email"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"plan": {"type": "string", "enum": ["free", "pro"], "default": "free"},
+			"topics": {"type": "array", "items": {"type": "string", "enum": ["news", "updates"]}},
+			"newsletter": {"type": "boolean", "description": "Receive the newsletter"}
+		},
+		"required": ["name", "email"]
+	}`, string(schema))
+
+	// Forms are rendered in field order
+	assert.True(t, strings.Index(string(schema), `"name"`) < strings.Index(string(schema), `"newsletter"`))
+}
+
+func TestElicitationSchema_Unsupported(t *testing.T) {
+	type nested struct {
+		Address struct {
+			Street string `json:"street"`
+		} `json:"address"`
+	}
+	_, err := mcp.ElicitationSchema[nested]()
+	assert.ErrorIs(t, err, mcp.ErrUnsupportedElicitationSchema)
+	assert.Contains(t, err.Error(), `"address"`)
+
+	type freeList struct {
+		Tags []string `json:"tags"`
+	}
+	_, err = mcp.ElicitationSchema[freeList]()
+	assert.ErrorIs(t, err, mcp.ErrUnsupportedElicitationSchema)
+
+	type address struct {
+		IP string `json:"ip" jsonschema:"format=ipv4"`
+	}
+	_, err = mcp.ElicitationSchema[address]()
+	assert.ErrorIs(t, err, mcp.ErrUnsupportedElicitationSchema)
+
+	_, err = mcp.ElicitationSchema[string]()
+	assert.ErrorIs(t, err, mcp.ErrUnsupportedElicitationSchema)
+
+	// The error is returned before anything is sent
+	_, err = mcp.Elicit[nested](context.Background(), "Where do you live?")
+	assert.ErrorIs(t, err, mcp.ErrUnsupportedElicitationSchema)
+}
+
+func TestElicit_Accept(t *testing.T) {
+	var received mcp.ElicitationRequest
+	answer := func(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		received = request
+		return accept(map[string]any{
+			"name":   "Ada",
+			"email":  "ada@example.com",
+			"age":    36,
+			"topics": []any{"news"},
+		})(ctx, request)
+	}
+
+	var result *mcp.TypedElicitationResult[contactForm]
+	var elicitErr error
+	callWithElicitation(t, answer, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, elicitErr = mcp.Elicit[contactForm](ctx, "Please sign up")
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	require.NoError(t, elicitErr)
+	assert.True(t, result.Accepted())
+	assert.Equal(t, contactForm{Name: "Ada", Email: "ada@example.com", Age: 36, Topics: []string{"news"}}, result.Content)
+	assert.Equal(t, "Please sign up", received.Params.Message)
+	schema, err := mcp.ElicitationSchema[contactForm]()
+	require.NoError(t, err)
+	assert.Equal(t, schema, received.Params.RequestedSchema)
+}
+
+func TestElicit_Decline(t *testing.T) {
+	answer := func(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}, nil
+	}
+
+	var result *mcp.TypedElicitationResult[contactForm]
+	var elicitErr error
+	callWithElicitation(t, answer, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, elicitErr = mcp.Elicit[contactForm](ctx, "Please sign up")
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	require.NoError(t, elicitErr)
+	assert.False(t, result.Accepted())
+	assert.Equal(t, mcp.ElicitationResponseActionDecline, result.Action)
+	assert.Equal(t, contactForm{}, result.Content)
+}
+
+func TestElicit_InvalidContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content map[string]any
+		want    string
+	}{
+		{"missing required", map[string]any{"name": "Ada"}, `"email"`},
+		{"wrong type", map[string]any{"name": "Ada", "email": "ada@example.com", "age": "old"}, "content.age"},
+		{"not in enum", map[string]any{"name": "Ada", "email": "ada@example.com", "plan": "gold"}, "content.plan"},
+		{"too short", map[string]any{"name": "", "email": "ada@example.com"}, `content.name: "" violates minLength 1`},
+		{"below minimum", map[string]any{"name": "Ada", "email": "ada@example.com", "age": -1}, "content.age: -1 violates minimum 0"},
+		{"above maximum", map[string]any{"name": "Ada", "email": "ada@example.com", "age": 200}, "content.age: 200 violates maximum 150"},
+		{"invalid format", map[string]any{"name": "Ada", "email": "ada"}, `content.email: "ada" is not a valid email`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var elicitErr error
+			callWithElicitation(t, accept(tt.content), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				_, elicitErr = mcp.Elicit[contactForm](ctx, "Please sign up")
+				return mcp.NewToolResultText("done"), nil
+			})
+			require.Error(t, elicitErr)
+			assert.True(t, errors.Is(elicitErr, mcp.ErrInvalidElicitationContent))
+			assert.Contains(t, elicitErr.Error(), tt.want)
+		})
+	}
+}
+
+func TestElicit_NoSession(t *testing.T) {
+	_, err := mcp.Elicit[contactForm](context.Background(), "Please sign up")
+	assert.ErrorIs(t, err, mcp.ErrSessionDoesNotSupportElicitation)
+}
@@ -233,7 +233,7 @@ func (p NotificationParams) MarshalJSON() ([]byte, error) {
 	m := make(map[string]any)
 
 	// Add Meta if it exists
-	if p.Meta != nil {
+	if len(p.Meta) > 0 {
 		m["_meta"] = p.Meta
 	}
 
@@ -369,7 +369,6 @@ type JSONRPCRequest struct {
 // JSONRPCNotification represents a notification which does not expect a response.
 type JSONRPCNotification struct {
 	JSONRPC string `json:"jsonrpc"`
-	Params  any    `json:"params,omitempty"`
 	Notification
 }
 
@@ -427,6 +426,10 @@ const (
 	// RESOURCE_NOT_FOUND indicates that the requested resource was not found.
 	RESOURCE_NOT_FOUND = -32002
 
+	// INSUFFICIENT_SCOPE indicates that the caller lacks the OAuth scopes
+	// required by a tool, prompt or resource.
+	INSUFFICIENT_SCOPE = -32003
+
 	// URL_ELICITATION_REQUIRED is the error code for when URL elicitation is required.
 	URL_ELICITATION_REQUIRED = -32042
 )
@@ -519,7 +522,7 @@ type ClientCapabilities struct {
 		ListChanged bool `json:"listChanged,omitempty"`
 	} `json:"roots,omitempty"`
 	// Present if the client supports sampling from an LLM.
-	Sampling *struct{} `json:"sampling,omitempty"`
+	Sampling *SamplingCapability `json:"sampling,omitempty"`
 	// Present if the client supports elicitation requests from the
 	Elicitation *ElicitationCapability `json:"elicitation,omitempty"`
 	// Present if the client supports task-based execution.
@@ -768,6 +771,9 @@ type Resource struct {
 	MIMEType string `json:"mimeType,omitempty"`
 	// Icons provides visual identifiers for the resource
 	Icons []Icon `json:"icons,omitempty"`
+	// RequiredScopes are the OAuth scopes callers need. It is only used by
+	// the server and never sent to clients.
+	RequiredScopes []string `json:"-"`
 }
 
 // GetName returns the name of the resource.
@@ -798,6 +804,9 @@ type ResourceTemplate struct {
 	MIMEType string `json:"mimeType,omitempty"`
 	// Icons provides visual identifiers for the resource template
 	Icons []Icon `json:"icons,omitempty"`
+	// RequiredScopes are the OAuth scopes callers need. It is only used by
+	// the server and never sent to clients.
+	RequiredScopes []string `json:"-"`
 }
 
 // GetName returns the name of the resourceTemplate.
@@ -1022,8 +1031,40 @@ type CreateMessageParams struct {
 	MaxTokens        int               `json:"maxTokens"`
 	StopSequences    []string          `json:"stopSequences,omitempty"`
 	Metadata         any               `json:"metadata,omitempty"`
+	// Tools the model may use. Requests with tools or a tool choice fail
+	// with ErrSamplingToolsNotSupported unless the client declared the
+	// sampling tools capability.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice controls whether the model must, may or must not use tools.
+	ToolChoice *ToolChoice `json:"toolChoice,omitempty"`
+}
+
+// ToolChoice controls how the model uses the tools of a sampling request.
+type ToolChoice struct {
+	// Mode is one of ToolChoiceAuto (the default), ToolChoiceRequired or
+	// ToolChoiceNone.
+	Mode string `json:"mode,omitempty"`
 }
 
+const (
+	// ToolChoiceAuto lets the model decide whether to use tools.
+	ToolChoiceAuto = "auto"
+	// ToolChoiceRequired makes the model use at least one tool.
+	ToolChoiceRequired = "required"
+	// ToolChoiceNone keeps the model from using tools.
+	ToolChoiceNone = "none"
+)
+
+// Reasons why sampling stopped, reported in CreateMessageResult.StopReason.
+const (
+	StopReasonEndTurn      = "endTurn"
+	StopReasonStopSequence = "stopSequence"
+	StopReasonMaxTokens    = "maxTokens"
+	// StopReasonToolUse means the model is waiting for the results of the
+	// tool uses in its message.
+	StopReasonToolUse = "toolUse"
+)
+
 // CreateMessageResult is the client's response to a sampling/create_message
 // request from the  The client should inform the user before returning the
 // sampled message, to allow them to inspect the response (human in the loop) and
@@ -1040,7 +1081,9 @@ type CreateMessageResult struct {
 // SamplingMessage describes a message issued to or received from an LLM API.
 type SamplingMessage struct {
 	Role    Role `json:"role"`
-	Content any  `json:"content"` // Can be TextContent, ImageContent or AudioContent
+	// Can be TextContent, ImageContent, AudioContent, ToolUseContent or
+	// ToolResultContent, or a []Content of them
+	Content any `json:"content"`
 }
 
 type Annotations struct {
@@ -1147,6 +1190,64 @@ type EmbeddedResource struct {
 
 func (EmbeddedResource) isContent() {}
 
+// ToolUseContent is a request from the model to call a tool, sent in an
+// assistant message of a sampling result.
+// It must have Type set to "tool_use".
+type ToolUseContent struct {
+	// Meta is a metadata object that is reserved by MCP for storing additional information.
+	Meta *Meta  `json:"_meta,omitempty"`
+	Type string `json:"type"` // Must be "tool_use"
+	// ID identifies the tool use in the matching ToolResultContent.
+	ID string `json:"id"`
+	// The name of the tool to call.
+	Name string `json:"name"`
+	// The arguments of the tool call.
+	Input map[string]any `json:"input"`
+}
+
+func (ToolUseContent) isContent() {}
+
+// ToolResultContent is the result of a tool use, sent back to the model in
+// a user message of a sampling request.
+// It must have Type set to "tool_result".
+type ToolResultContent struct {
+	// Meta is a metadata object that is reserved by MCP for storing additional information.
+	Meta *Meta  `json:"_meta,omitempty"`
+	Type string `json:"type"` // Must be "tool_result"
+	// ToolUseID is the ID of the ToolUseContent this is the result of.
+	ToolUseID string `json:"toolUseId"`
+	// The unstructured result of the tool call.
+	Content []Content `json:"content"`
+	// The structured result of the tool call, if the tool has an output schema.
+	StructuredContent any `json:"structuredContent,omitempty"`
+	// Whether the tool call failed.
+	IsError bool `json:"isError,omitempty"`
+}
+
+func (ToolResultContent) isContent() {}
+
+// UnmarshalJSON implements custom JSON unmarshaling for the Content array.
+func (c *ToolResultContent) UnmarshalJSON(data []byte) error {
+	type alias ToolResultContent
+	var raw struct {
+		alias
+		Content []json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = ToolResultContent(raw.alias)
+	c.Content = make([]Content, len(raw.Content))
+	for i, item := range raw.Content {
+		content, err := UnmarshalContent(item)
+		if err != nil {
+			return err
+		}
+		c.Content[i] = content
+	}
+	return nil
+}
+
 // ModelPreferences represents the server's preferences for model selection,
 // requested of the client during sampling.
 //
@@ -1576,11 +1677,25 @@ func UnmarshalContent(data []byte) (Content, error) {
 		var content EmbeddedResource
 		err := json.Unmarshal(data, &content)
 		return content, err
+	case ContentTypeToolUse:
+		var content ToolUseContent
+		err := json.Unmarshal(data, &content)
+		return content, err
+	case ContentTypeToolResult:
+		var content ToolResultContent
+		err := json.Unmarshal(data, &content)
+		return content, err
 	default:
 		return nil, fmt.Errorf("unknown content type: %s", contentType)
 	}
 }
 
+// SamplingCapability represents the sampling capabilities of a client.
+type SamplingCapability struct {
+	Context *struct{} `json:"context,omitempty"` // Supports includeContext other than "none"
+	Tools   *struct{} `json:"tools,omitempty"`   // Supports tools and toolChoice
+}
+
 // ElicitationCapability represents the elicitation capabilities of a client or
 type ElicitationCapability struct {
 	Form *struct{} `json:"form,omitempty"` // Supports form mode
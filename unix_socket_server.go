@@ -0,0 +1,313 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultUnixSocketMode is the permission of the socket file created by
+// UnixSocketServer.ListenAndServe: only the owner can connect.
+const DefaultUnixSocketMode os.FileMode = 0600
+
+// DefaultUnixSocketMaxMessageSize is the default limit of the messages read
+// by UnixSocketServer.
+const DefaultUnixSocketMaxMessageSize = 4 << 20
+
+// UnixSocketContextFunc derives the context of a connection, e.g. to record
+// the credentials of the peer process.
+type UnixSocketContextFunc func(ctx context.Context, conn net.Conn) context.Context
+
+// UnixSocketServer serves MCP on a Unix domain socket, so that several local
+// tools can share a long-running server without competing for TCP ports.
+// Each connection exchanges newline-delimited JSON-RPC messages, as stdio
+// does, and is registered with the MCPServer as its own ClientSession.
+type UnixSocketServer struct {
+	server         *MCPServer
+	mode           os.FileMode
+	maxMessageSize int64
+	contextFunc    UnixSocketContextFunc
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*lineConn]struct{}
+	shutdown bool
+	wg       sync.WaitGroup
+}
+
+// UnixSocketServerOption configures a UnixSocketServer.
+type UnixSocketServerOption func(*UnixSocketServer)
+
+// WithUnixSocketMode sets the permission of the socket file. Defaults to
+// DefaultUnixSocketMode.
+func WithUnixSocketMode(mode os.FileMode) UnixSocketServerOption {
+	return func(s *UnixSocketServer) {
+		s.mode = mode
+	}
+}
+
+// WithUnixSocketMaxMessageSize limits the size of messages received from
+// clients; connections sending larger messages are closed.
+func WithUnixSocketMaxMessageSize(size int64) UnixSocketServerOption {
+	return func(s *UnixSocketServer) {
+		s.maxMessageSize = size
+	}
+}
+
+// WithUnixSocketContextFunc sets a function deriving the context of each connection.
+func WithUnixSocketContextFunc(fn UnixSocketContextFunc) UnixSocketServerOption {
+	return func(s *UnixSocketServer) {
+		s.contextFunc = fn
+	}
+}
+
+// NewUnixSocketServer creates a server serving server on a Unix socket.
+func NewUnixSocketServer(server *MCPServer, options ...UnixSocketServerOption) *UnixSocketServer {
+	s := &UnixSocketServer{
+		server:         server,
+		mode:           DefaultUnixSocketMode,
+		maxMessageSize: DefaultUnixSocketMaxMessageSize,
+		conns:          make(map[*lineConn]struct{}),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the socket at path and serves connections until
+// Shutdown is called. A socket left behind by a process that exited without
+// cleaning up is replaced, while ErrUnixSocketInUse is returned if another
+// server is still listening on it. The socket file is removed on Shutdown.
+func (s *UnixSocketServer) ListenAndServe(path string) error {
+	listener, err := listenUnixSocket(path, s.mode)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Shutdown is called, and then
+// returns ErrUnixSocketServerClosed.
+func (s *UnixSocketServer) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrUnixSocketServerClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			shutdown := s.shutdown
+			s.mu.Unlock()
+			if shutdown {
+				return ErrUnixSocketServerClosed
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.shutdown {
+			s.mu.Unlock()
+			netConn.Close()
+			return ErrUnixSocketServerClosed
+		}
+		conn := newLineConn(netConn, s.maxMessageSize)
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn runs the MCP session of a connection.
+func (s *UnixSocketServer) serveConn(conn *lineConn) {
+	defer s.wg.Done()
+	defer func() {
+		conn.close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if s.contextFunc != nil {
+		ctx = s.contextFunc(ctx, conn.conn)
+	}
+
+	session := newConnSession("unix-", conn)
+	if err := s.server.RegisterSession(ctx, session); err != nil {
+		return
+	}
+	defer s.server.UnregisterSession(ctx, session.SessionID())
+
+	session.serve(s.server.WithContext(ctx, session), s.server, conn.readMessage)
+}
+
+// Shutdown stops listening, removes the socket file, closes the open
+// connections and waits for their sessions to end, or for ctx to be done.
+func (s *UnixSocketServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shutdown = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.close()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// listenUnixSocket listens on the socket at path with the given permission,
+// removing a stale socket file first.
+func listenUnixSocket(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: %s", ErrUnixSocketInUse, path)
+		}
+		// Nobody answers: the socket was left behind by a crashed server
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// lineConn carries newline-delimited JSON-RPC messages over a stream connection.
+type lineConn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	maxMessageSize int64
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closed    chan struct{}
+	errMu     sync.Mutex
+	err       error // Why the connection was closed
+}
+
+func newLineConn(conn net.Conn, maxMessageSize int64) *lineConn {
+	return &lineConn{
+		conn:           conn,
+		br:             bufio.NewReader(conn),
+		maxMessageSize: maxMessageSize,
+		closed:         make(chan struct{}),
+	}
+}
+
+// readMessage returns the next non-empty line, without its terminator.
+func (c *lineConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		chunk, err := c.br.ReadSlice('\n')
+		if c.maxMessageSize > 0 && int64(len(message)+len(chunk)) > c.maxMessageSize {
+			return nil, c.fail(fmt.Errorf("message exceeds %d bytes", c.maxMessageSize))
+		}
+		message = append(message, chunk...)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return nil, c.fail(err)
+		}
+
+		message = bytes.TrimRight(message, "\r\n")
+		if len(bytes.TrimSpace(message)) == 0 {
+			message = message[:0]
+			continue
+		}
+		return message, nil
+	}
+}
+
+// writeMessage writes message followed by a newline.
+func (c *lineConn) writeMessage(message []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	select {
+	case <-c.closed:
+		return c.closeError()
+	default:
+	}
+	if _, err := c.conn.Write(append(message, '\n')); err != nil {
+		return c.fail(err)
+	}
+	return nil
+}
+
+// done is closed once the connection is closed.
+func (c *lineConn) done() <-chan struct{} {
+	return c.closed
+}
+
+// fail closes the connection, recording err as the reason.
+func (c *lineConn) fail(err error) error {
+	c.errMu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.errMu.Unlock()
+	c.close()
+	return c.closeError()
+}
+
+func (c *lineConn) close() {
+	c.closeOnce.Do(func() {
+		_ = c.conn.Close()
+		close(c.closed)
+	})
+}
+
+// closeError returns why the connection was closed.
+func (c *lineConn) closeError() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.err == nil || errors.Is(c.err, io.EOF) {
+		return net.ErrClosed
+	}
+	return c.err
+}
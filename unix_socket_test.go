@@ -0,0 +1,159 @@
+package mcp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// startUnixSocketServer serves an MCP server with an echo tool on a socket
+// in a temporary directory.
+func startUnixSocketServer(t *testing.T, path string, options ...mcp.UnixSocketServerOption) *mcp.UnixSocketServer {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("socket permissions are not supported on windows")
+	}
+
+	mcpServer := mcp.NewMCPServer("unix-server", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("echo", mcp.WithString("message")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.GetString("message", "")), nil
+	})
+
+	server := mcp.NewUnixSocketServer(mcpServer, options...)
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe(path) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+		assert.ErrorIs(t, <-served, mcp.ErrUnixSocketServerClosed)
+	})
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return server
+}
+
+func TestUnixSocket_ConcurrentClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	startUnixSocketServer(t, path)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := mcp.NewUnixSocketMCPClient(path)
+			require.NoError(t, err)
+			defer client.Close()
+			require.NoError(t, client.Start(context.Background()))
+			initializeClient(t, client)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Name = "echo"
+			request.Params.Arguments = map[string]any{"message": "hello"}
+			result, err := client.CallTool(context.Background(), request)
+			require.NoError(t, err)
+			require.Len(t, result.Content, 1)
+			assert.Equal(t, "hello", result.Content[0].(mcp.TextContent).Text)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUnixSocket_Permissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	startUnixSocketServer(t, path, mcp.WithUnixSocketMode(0660))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+}
+
+func TestUnixSocket_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+
+	// Leave a socket file behind, as a crashed server would
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	listener.SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	startUnixSocketServer(t, path)
+	client, err := mcp.NewUnixSocketMCPClient(path)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+}
+
+func TestUnixSocket_InUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	startUnixSocketServer(t, path)
+
+	other := mcp.NewUnixSocketServer(mcp.NewMCPServer("other", "1.0.0"))
+	assert.ErrorIs(t, other.ListenAndServe(path), mcp.ErrUnixSocketInUse)
+
+	regular := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(regular, []byte("keep"), 0600))
+	assert.Error(t, other.ListenAndServe(regular))
+	data, err := os.ReadFile(regular)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(data))
+}
+
+func TestUnixSocket_Shutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	server := startUnixSocketServer(t, path)
+
+	client, err := mcp.NewUnixSocketMCPClient(path)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(ctx))
+
+	_, err = os.Stat(path)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.Eventually(t, func() bool {
+		_, err := client.ListTools(context.Background(), mcp.ListToolsRequest{})
+		return errors.Is(err, mcp.ErrTransportClosed)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestUnixSocket_MessageTooBig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	startUnixSocketServer(t, path, mcp.WithUnixSocketMaxMessageSize(64))
+
+	client, err := mcp.NewUnixSocketMCPClient(path)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "a-client-with-a-rather-long-name", Version: "1.0.0"}
+	_, err = client.Initialize(context.Background(), initRequest)
+	assert.ErrorIs(t, err, mcp.ErrTransportClosed)
+}
@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultURLElicitationTTL is how long a URL elicitation waits for the user
+// to finish the out-of-band interaction before it expires.
+const DefaultURLElicitationTTL = 10 * time.Minute
+
+// URLElicitationIDParam is the query parameter carrying the elicitation ID
+// in the URL sent to the client.
+const URLElicitationIDParam = "elicitationId"
+
+// URLElicitationTokenParam is the query or form parameter carrying the
+// completion token in requests to the completion handler.
+const URLElicitationTokenParam = "completionToken"
+
+// URLElicitationManagerOption configures a URLElicitationManager.
+type URLElicitationManagerOption func(*URLElicitationManager)
+
+// WithURLElicitationTTL sets how long elicitations wait for completion
+// before they expire. Defaults to DefaultURLElicitationTTL.
+func WithURLElicitationTTL(ttl time.Duration) URLElicitationManagerOption {
+	return func(m *URLElicitationManager) {
+		m.ttl = ttl
+	}
+}
+
+// WithURLElicitationCompletionPage sets the page the completion handler
+// redirects the user's browser to. By default it answers with a short
+// plain text message.
+func WithURLElicitationCompletionPage(pageURL string) URLElicitationManagerOption {
+	return func(m *URLElicitationManager) {
+		m.completionPage = pageURL
+	}
+}
+
+// URLElicitationManager tracks URL mode elicitations, where the user
+// provides information on a web page instead of through the client, such as
+// when authorizing access to a third-party service.
+//
+// Start sends the elicitation to the client of the current request and
+// registers it under a generated ID. The page completes it by calling
+// Complete or by sending the user's browser to CompletionHandler with the
+// elicitation's CompletionToken, which notifies the client with
+// notifications/elicitation/complete and wakes up any Wait. Elicitations
+// that are not completed in time expire.
+type URLElicitationManager struct {
+	server         *MCPServer
+	ttl            time.Duration
+	completionPage string
+
+	mu      sync.Mutex
+	pending map[string]*URLElicitation // By ID
+	tokens  map[string]string          // Elicitation IDs by completion token
+}
+
+// NewURLElicitationManager creates a manager sending completion
+// notifications through server.
+func NewURLElicitationManager(server *MCPServer, options ...URLElicitationManagerOption) *URLElicitationManager {
+	m := &URLElicitationManager{
+		server:  server,
+		ttl:     DefaultURLElicitationTTL,
+		pending: make(map[string]*URLElicitation),
+		tokens:  make(map[string]string),
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+// URLElicitation is a URL mode elicitation started by a URLElicitationManager.
+type URLElicitation struct {
+	ID        string                    // The generated elicitation ID
+	URL       string                    // The URL sent to the client, with the ID added
+	SessionID string                    // The session the elicitation was sent to
+	ExpiresAt time.Time                 // When the elicitation expires if not completed
+	Action    ElicitationResponseAction // The client's response to the elicitation request
+
+	// CompletionToken is a generated secret completing the elicitation
+	// through CompletionHandler. Unlike the ID, it is never sent to the
+	// client, so that only the page can complete the elicitation.
+	CompletionToken string
+
+	done   chan struct{}
+	err    error // Set before done is closed; nil once completed
+	expire *time.Timer
+}
+
+// Accepted reports whether the user agreed to open the URL.
+func (e *URLElicitation) Accepted() bool {
+	return e.Action == ElicitationResponseActionAccept
+}
+
+// Done returns a channel closed once the elicitation is no longer pending:
+// it was completed, expired, or not accepted by the user.
+func (e *URLElicitation) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait blocks until the elicitation is completed. It returns
+// ErrURLElicitationNotAccepted if the user did not accept it,
+// ErrURLElicitationExpired if it expired, and ErrURLElicitationTimeout if
+// timeout elapses first; a timeout of zero waits until the elicitation
+// completes or expires. After a timeout the elicitation stays pending and
+// can be waited on again.
+func (e *URLElicitation) Wait(ctx context.Context, timeout time.Duration) error {
+	if !e.Accepted() {
+		return ErrURLElicitationNotAccepted
+	}
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-e.done:
+		return e.err
+	case <-timer:
+		return ErrURLElicitationTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start asks the client of the current request to have the user open
+// pageURL, with a newly generated elicitation ID added as the
+// URLElicitationIDParam query parameter. It must be called from a request
+// handler, such as a tool handler, and returns once the client has
+// responded; if the user declined or cancelled, the elicitation is not
+// tracked and its Action says so.
+func (m *URLElicitationManager) Start(ctx context.Context, message string, pageURL string) (*URLElicitation, error) {
+	session, ok := ClientSessionFromContext(ctx).(SessionWithElicitation)
+	if !ok {
+		return nil, ErrSessionDoesNotSupportElicitation
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid elicitation URL: %w", err)
+	}
+
+	var id, token [16]byte
+	_, _ = rand.Read(id[:])
+	_, _ = rand.Read(token[:])
+	elicitation := &URLElicitation{
+		ID:              hex.EncodeToString(id[:]),
+		SessionID:       session.SessionID(),
+		ExpiresAt:       time.Now().Add(m.ttl),
+		CompletionToken: hex.EncodeToString(token[:]),
+		done:            make(chan struct{}),
+	}
+	query := u.Query()
+	query.Set(URLElicitationIDParam, elicitation.ID)
+	u.RawQuery = query.Encode()
+	elicitation.URL = u.String()
+
+	// Register before sending, since the user may finish on the page before
+	// the client's response arrives
+	m.mu.Lock()
+	m.pending[elicitation.ID] = elicitation
+	m.tokens[elicitation.CompletionToken] = elicitation.ID
+	elicitation.expire = time.AfterFunc(m.ttl, func() {
+		m.finish(elicitation.ID, ErrURLElicitationExpired)
+	})
+	m.mu.Unlock()
+
+	result, err := session.RequestElicitation(ctx, ElicitationRequest{
+		Params: ElicitationParams{
+			Mode:          ElicitationModeURL,
+			Message:       message,
+			ElicitationID: elicitation.ID,
+			URL:           elicitation.URL,
+		},
+	})
+	if err != nil {
+		m.finish(elicitation.ID, err)
+		return nil, err
+	}
+	elicitation.Action = result.Action
+	if !elicitation.Accepted() {
+		m.finish(elicitation.ID, ErrURLElicitationNotAccepted)
+	}
+	return elicitation, nil
+}
+
+// Complete marks the elicitation with the given ID as completed, notifies
+// the client it was sent to and wakes up any Wait. It returns
+// ErrURLElicitationNotFound if the ID is unknown, already completed or
+// expired. The elicitation is completed even if the notification cannot be
+// sent, for example because the client has disconnected.
+//
+// The ID is known to the client, so Complete is for server code that has
+// checked who is completing the elicitation. Requests from the browser go
+// through CompletionHandler, which requires the completion token.
+func (m *URLElicitationManager) Complete(id string) error {
+	elicitation := m.finish(id, nil)
+	if elicitation == nil {
+		return ErrURLElicitationNotFound
+	}
+	return m.server.SendNotificationToSpecificClient(
+		elicitation.SessionID,
+		string(MethodNotificationElicitationComplete),
+		map[string]any{"elicitationId": id},
+	)
+}
+
+// Pending returns the elicitation with the given ID, if it is waiting for
+// completion. Pages use it to look up the CompletionToken of the
+// elicitation whose ID is in their URL.
+func (m *URLElicitationManager) Pending(id string) (*URLElicitation, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elicitation, ok := m.pending[id]
+	return elicitation, ok
+}
+
+// CompletionHandler returns an http.Handler completing the elicitation whose
+// CompletionToken is in the URLElicitationTokenParam query or form
+// parameter. Pages can redirect the user's browser to it or call it
+// themselves once the interaction is done. Unknown tokens and those of
+// completed or expired elicitations are answered with 404 Not Found.
+func (m *URLElicitationManager) CompletionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		token := r.FormValue(URLElicitationTokenParam)
+		if token == "" {
+			http.Error(w, "missing "+URLElicitationTokenParam, http.StatusBadRequest)
+			return
+		}
+		m.mu.Lock()
+		id, ok := m.tokens[token]
+		m.mu.Unlock()
+		if !ok {
+			http.Error(w, "elicitation not found or expired", http.StatusNotFound)
+			return
+		}
+		// The client may have disconnected; the elicitation is completed
+		// regardless, so only unknown elicitations are reported
+		if err := m.Complete(id); errors.Is(err, ErrURLElicitationNotFound) {
+			http.Error(w, "elicitation not found or expired", http.StatusNotFound)
+			return
+		}
+		if m.completionPage != "" {
+			http.Redirect(w, r, m.completionPage, http.StatusSeeOther)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintln(w, "Done. You can close this window and return to the application.")
+	})
+}
+
+// finish removes a pending elicitation and releases its waiters with err.
+// It returns nil if the elicitation is no longer pending.
+func (m *URLElicitationManager) finish(id string, err error) *URLElicitation {
+	m.mu.Lock()
+	elicitation, ok := m.pending[id]
+	if ok {
+		delete(m.pending, id)
+		delete(m.tokens, elicitation.CompletionToken)
+		elicitation.expire.Stop()
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	elicitation.err = err
+	close(elicitation.done)
+	return elicitation
+}
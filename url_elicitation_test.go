@@ -0,0 +1,240 @@
+package mcp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// serveURLElicitation serves a "connect" tool over WebSocket and returns a
+// client answering its elicitations with answer.
+func serveURLElicitation(
+	t *testing.T,
+	answer elicitationHandlerFunc,
+	tool func(manager *mcp.URLElicitationManager) mcp.ToolHandlerFunc,
+	options ...mcp.URLElicitationManagerOption,
+) (*mcp.Client, *mcp.URLElicitationManager) {
+	t.Helper()
+	server := mcp.NewMCPServer("url-elicitation-server", "1.0.0", mcp.WithElicitation())
+	manager := mcp.NewURLElicitationManager(server, options...)
+	server.AddTool(mcp.NewTool("connect"), tool(manager))
+	httpServer := httptest.NewServer(mcp.NewWebSocketServer(server))
+	t.Cleanup(httpServer.Close)
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	client := mcp.NewClient(transport, mcp.WithElicitationHandler(answer))
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+	return client, manager
+}
+
+func callConnect(t *testing.T, client *mcp.Client) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "connect"
+	result, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	return result
+}
+
+func TestURLElicitation_Complete(t *testing.T) {
+	var completion *httptest.Server
+	var manager *mcp.URLElicitationManager
+	var requested mcp.ElicitationParams
+	var token string
+	completed := make(chan *http.Response, 1)
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	answer := func(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		requested = request.Params
+		// The client cannot complete the elicitation with its ID
+		resp, err := noRedirect.Get(completion.URL + "?" + mcp.URLElicitationTokenParam + "=" + url.QueryEscape(request.Params.ElicitationID))
+		if err == nil {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		}
+
+		// The page looks up the completion token and the user finishes there
+		elicitation, ok := manager.Pending(request.Params.ElicitationID)
+		if !ok {
+			return nil, errors.New("elicitation not pending")
+		}
+		token = elicitation.CompletionToken
+		go func() {
+			resp, err := noRedirect.Get(completion.URL + "?" + mcp.URLElicitationTokenParam + "=" + url.QueryEscape(token))
+			if err == nil {
+				resp.Body.Close()
+			}
+			completed <- resp
+		}()
+		return accept(nil)(ctx, request)
+	}
+
+	client, manager := serveURLElicitation(t, answer, func(m *mcp.URLElicitationManager) mcp.ToolHandlerFunc {
+		manager = m
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			elicitation, err := manager.Start(ctx, "Connect your account", "https://example.com/connect?service=github")
+			if err != nil {
+				return nil, err
+			}
+			if err := elicitation.Wait(ctx, 5*time.Second); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return mcp.NewToolResultText("connected"), nil
+		}
+	}, mcp.WithURLElicitationCompletionPage("https://example.com/done"))
+	completion = httptest.NewServer(manager.CompletionHandler())
+	defer completion.Close()
+
+	notifications := make(chan mcp.JSONRPCNotification, 1)
+	client.OnNotification(func(notification mcp.JSONRPCNotification) {
+		notifications <- notification
+	})
+
+	result := callConnect(t, client)
+	require.False(t, result.IsError)
+	assert.Equal(t, "connected", result.Content[0].(mcp.TextContent).Text)
+
+	assert.Equal(t, mcp.ElicitationModeURL, requested.Mode)
+	assert.Equal(t, "Connect your account", requested.Message)
+	assert.NotEmpty(t, requested.ElicitationID)
+	pageURL, err := url.Parse(requested.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "github", pageURL.Query().Get("service"))
+	assert.Equal(t, requested.ElicitationID, pageURL.Query().Get(mcp.URLElicitationIDParam))
+	assert.NotEmpty(t, token)
+	assert.NotContains(t, requested.URL, token)
+
+	resp := <-completed
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "https://example.com/done", resp.Header.Get("Location"))
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, string(mcp.MethodNotificationElicitationComplete), notification.Method)
+		assert.Equal(t, requested.ElicitationID, notification.Params.AdditionalFields["elicitationId"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the completion notification")
+	}
+	_, pending := manager.Pending(requested.ElicitationID)
+	assert.False(t, pending)
+}
+
+func TestURLElicitation_Declined(t *testing.T) {
+	answer := func(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+		return &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}, nil
+	}
+	var elicitation *mcp.URLElicitation
+	var waitErr error
+	client, manager := serveURLElicitation(t, answer, func(manager *mcp.URLElicitationManager) mcp.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var err error
+			elicitation, err = manager.Start(ctx, "Connect your account", "https://example.com/connect")
+			if err != nil {
+				return nil, err
+			}
+			waitErr = elicitation.Wait(ctx, 0)
+			return mcp.NewToolResultText("done"), nil
+		}
+	})
+
+	callConnect(t, client)
+	require.NotNil(t, elicitation)
+	assert.False(t, elicitation.Accepted())
+	assert.ErrorIs(t, waitErr, mcp.ErrURLElicitationNotAccepted)
+	_, pending := manager.Pending(elicitation.ID)
+	assert.False(t, pending)
+	assert.ErrorIs(t, manager.Complete(elicitation.ID), mcp.ErrURLElicitationNotFound)
+}
+
+func TestURLElicitation_Expired(t *testing.T) {
+	var token string
+	var waitErr error
+	client, manager := serveURLElicitation(t, accept(nil), func(manager *mcp.URLElicitationManager) mcp.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			elicitation, err := manager.Start(ctx, "Connect your account", "https://example.com/connect")
+			if err != nil {
+				return nil, err
+			}
+			token = elicitation.CompletionToken
+			waitErr = elicitation.Wait(ctx, 0)
+			return mcp.NewToolResultText("done"), nil
+		}
+	}, mcp.WithURLElicitationTTL(50*time.Millisecond))
+
+	callConnect(t, client)
+	assert.ErrorIs(t, waitErr, mcp.ErrURLElicitationExpired)
+
+	// The page can no longer complete it
+	recorder := httptest.NewRecorder()
+	manager.CompletionHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/complete?completionToken="+token, nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestURLElicitation_WaitTimeout(t *testing.T) {
+	var timeoutErr, waitErr error
+	client, _ := serveURLElicitation(t, accept(nil), func(manager *mcp.URLElicitationManager) mcp.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			elicitation, err := manager.Start(ctx, "Connect your account", "https://example.com/connect")
+			if err != nil {
+				return nil, err
+			}
+			timeoutErr = elicitation.Wait(ctx, 20*time.Millisecond)
+
+			// Still pending after the timeout
+			if _, ok := manager.Pending(elicitation.ID); !ok {
+				return mcp.NewToolResultError("not pending"), nil
+			}
+			if err := manager.Complete(elicitation.ID); err != nil {
+				return nil, err
+			}
+			waitErr = elicitation.Wait(ctx, 0)
+			return mcp.NewToolResultText("done"), nil
+		}
+	})
+
+	result := callConnect(t, client)
+	require.False(t, result.IsError)
+	assert.ErrorIs(t, timeoutErr, mcp.ErrURLElicitationTimeout)
+	assert.NoError(t, waitErr)
+}
+
+func TestURLElicitation_CompletionHandlerErrors(t *testing.T) {
+	manager := mcp.NewURLElicitationManager(mcp.NewMCPServer("server", "1.0.0"))
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		expected int
+	}{
+		{"unknown token", http.MethodGet, "/complete?completionToken=unknown", http.StatusNotFound},
+		{"missing token", http.MethodPost, "/complete", http.StatusBadRequest},
+		{"wrong method", http.MethodPut, "/complete?completionToken=unknown", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			manager.CompletionHandler().ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.expected, recorder.Code)
+		})
+	}
+}
+
+func TestURLElicitation_NoSession(t *testing.T) {
+	manager := mcp.NewURLElicitationManager(mcp.NewMCPServer("server", "1.0.0"))
+	_, err := manager.Start(context.Background(), "Connect your account", "https://example.com/connect")
+	assert.ErrorIs(t, err, mcp.ErrSessionDoesNotSupportElicitation)
+}
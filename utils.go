@@ -266,6 +266,29 @@ func NewEmbeddedResource(resource ResourceContents) EmbeddedResource {
 	}
 }
 
+// NewToolUseContent creates a tool use of a sampling result, as returned
+// by models using tools.
+func NewToolUseContent(id, name string, input map[string]any) ToolUseContent {
+	return ToolUseContent{
+		Type:  ContentTypeToolUse,
+		ID:    id,
+		Name:  name,
+		Input: input,
+	}
+}
+
+// NewToolResultContent creates the content sent back to the model for the
+// tool use with the given ID, from the result of calling the tool.
+func NewToolResultContent(toolUseID string, result *CallToolResult) ToolResultContent {
+	return ToolResultContent{
+		Type:              ContentTypeToolResult,
+		ToolUseID:         toolUseID,
+		Content:           result.Content,
+		StructuredContent: result.StructuredContent,
+		IsError:           result.IsError,
+	}
+}
+
 // NewToolResultText creates a new CallToolResult with a text content
 func NewToolResultText(text string) *CallToolResult {
 	return &CallToolResult{
@@ -641,11 +664,66 @@ func ParseContent(contentMap map[string]any) (Content, error) {
 		c := NewEmbeddedResource(resourceContents)
 		c.Annotations = annotations
 		return c, nil
+
+	case ContentTypeToolUse:
+		id := ExtractString(contentMap, "id")
+		name := ExtractString(contentMap, "name")
+		if id == "" || name == "" {
+			return nil, fmt.Errorf("tool_use id or name is missing")
+		}
+		return NewToolUseContent(id, name, ExtractMap(contentMap, "input")), nil
+
+	case ContentTypeToolResult:
+		toolUseID := ExtractString(contentMap, "toolUseId")
+		if toolUseID == "" {
+			return nil, fmt.Errorf("tool_result toolUseId is missing")
+		}
+		result := &CallToolResult{StructuredContent: contentMap["structuredContent"]}
+		result.IsError, _ = contentMap["isError"].(bool)
+		items, _ := contentMap["content"].([]any)
+		for _, item := range items {
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("tool_result content item is not an object")
+			}
+			c, err := ParseContent(itemMap)
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, c)
+		}
+		return NewToolResultContent(toolUseID, result), nil
 	}
 
 	return nil, fmt.Errorf("unsupported content type: %s", contentType)
 }
 
+// parseSamplingContent parses the content of a sampling message decoded
+// from JSON, which is a single content object or, for messages with tool
+// uses or results, an array of them. Content that is already parsed is
+// returned as is.
+func parseSamplingContent(content any) (any, error) {
+	switch c := content.(type) {
+	case map[string]any:
+		return ParseContent(c)
+	case []any:
+		contents := make([]Content, len(c))
+		for i, item := range c {
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("content item is not an object")
+			}
+			parsed, err := ParseContent(itemMap)
+			if err != nil {
+				return nil, err
+			}
+			contents[i] = parsed
+		}
+		return contents, nil
+	}
+	return content, nil
+}
+
 func ParseGetPromptResult(rawMessage *json.RawMessage) (*GetPromptResult, error) {
 	if rawMessage == nil {
 		return nil, fmt.Errorf("response is nil")
@@ -0,0 +1,417 @@
+package mcp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocketSubprotocol is the WebSocket subprotocol negotiated for MCP.
+const WebSocketSubprotocol = "mcp"
+
+const (
+	// DefaultWebSocketMaxMessageSize is the default limit on the size of a
+	// received message, fragments included.
+	DefaultWebSocketMaxMessageSize = 4 << 20
+	// DefaultWebSocketPingInterval is the default interval between keepalive pings.
+	DefaultWebSocketPingInterval = 30 * time.Second
+
+	webSocketGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	webSocketCloseTimeout = 5 * time.Second
+)
+
+// webSocketWriteTimeout bounds the write of a frame, so that a peer that
+// stops reading cannot block the writers forever. A variable is convenient
+// for testing.
+var webSocketWriteTimeout = 10 * time.Second
+
+// WebSocket close codes (RFC 6455, section 7.4.1)
+const (
+	WebSocketCloseNormal          = 1000
+	WebSocketCloseGoingAway       = 1001
+	WebSocketCloseProtocolError   = 1002
+	WebSocketCloseUnsupportedData = 1003
+	WebSocketCloseNoStatus        = 1005
+	WebSocketCloseAbnormal        = 1006
+	WebSocketCloseInvalidPayload  = 1007
+	WebSocketClosePolicyViolation = 1008
+	WebSocketCloseMessageTooBig   = 1009
+	WebSocketCloseInternalError   = 1011
+)
+
+// WebSocket opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// WebSocketCloseError reports why a WebSocket connection was closed, either
+// by the peer's close frame or locally.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+}
+
+// wsConn implements the WebSocket framing of an established connection:
+// text messages, fragmentation, ping/pong and the closing handshake.
+type wsConn struct {
+	conn           io.ReadWriteCloser
+	br             *bufio.Reader
+	client         bool // Client side: mask outgoing frames, expect unmasked ones
+	maxMessageSize int64
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	errMu     sync.Mutex
+	err       *WebSocketCloseError // Why the connection was closed
+	lastSeen  atomic.Int64         // Unix nanoseconds of the last frame received
+}
+
+func newWSConn(conn io.ReadWriteCloser, br *bufio.Reader, client bool, maxMessageSize int64) *wsConn {
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultWebSocketMaxMessageSize
+	}
+	c := &wsConn{
+		conn:           conn,
+		br:             br,
+		client:         client,
+		maxMessageSize: maxMessageSize,
+		closed:         make(chan struct{}),
+	}
+	c.lastSeen.Store(time.Now().UnixNano())
+	return c
+}
+
+// writeMessage sends a text message.
+func (c *wsConn) writeMessage(message []byte) error {
+	return c.writeFrame(wsOpText, message)
+}
+
+// writeFrame sends a single, final frame.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return c.closeError()
+	}
+	if opcode == wsOpClose {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|opcode)
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if !c.client {
+		frame = append(frame, payload...)
+	} else {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		frame = append(frame, key[:]...)
+		for i, b := range payload {
+			frame = append(frame, b^key[i%4])
+		}
+	}
+
+	if conn, ok := c.conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		_ = conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		// A partly written frame breaks the framing of the connection
+		c.abort(err.Error())
+		return err
+	}
+	return nil
+}
+
+// readMessage returns the next text message, answering pings and completing
+// the closing handshake on the way. It fails with a *WebSocketCloseError once
+// the connection is closed.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	fragmented := false
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		c.lastSeen.Store(time.Now().UnixNano())
+
+		switch opcode {
+		case wsOpPing:
+			_ = c.writeFrame(wsOpPong, payload)
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return nil, c.handleClose(payload)
+		case wsOpText:
+			if fragmented {
+				return nil, c.fail(WebSocketCloseProtocolError, "expected continuation frame")
+			}
+			message = payload
+		case wsOpContinuation:
+			if !fragmented {
+				return nil, c.fail(WebSocketCloseProtocolError, "unexpected continuation frame")
+			}
+			message = append(message, payload...)
+		case wsOpBinary:
+			return nil, c.fail(WebSocketCloseUnsupportedData, "binary messages are not supported")
+		default:
+			return nil, c.fail(WebSocketCloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(message)) > c.maxMessageSize {
+			return nil, c.fail(WebSocketCloseMessageTooBig, "message too big")
+		}
+		fragmented = !fin
+		if !fragmented {
+			if !utf8.Valid(message) {
+				return nil, c.fail(WebSocketCloseInvalidPayload, "invalid UTF-8 text")
+			}
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads and unmasks one frame.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(WebSocketCloseProtocolError, "reserved bits set")
+	}
+	if masked == c.client {
+		return false, 0, nil, c.fail(WebSocketCloseProtocolError, "invalid frame masking")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= wsOpClose && (!fin || length > 125) {
+		return false, 0, nil, c.fail(WebSocketCloseProtocolError, "invalid control frame")
+	}
+	if length > uint64(c.maxMessageSize) {
+		return false, 0, nil, c.fail(WebSocketCloseMessageTooBig, "message too big")
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// handleClose answers the peer's close frame and closes the connection.
+func (c *wsConn) handleClose(payload []byte) error {
+	closeErr := &WebSocketCloseError{Code: WebSocketCloseNoStatus}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+	}
+	c.setError(closeErr)
+
+	// Echo the status code, unless we started the closing handshake
+	code := closeErr.Code
+	if code == WebSocketCloseNoStatus {
+		code = WebSocketCloseNormal
+	}
+	_ = c.writeFrame(wsOpClose, closePayload(code, ""))
+	c.closeConn()
+	return c.closeError()
+}
+
+// fail closes the connection after a protocol violation or policy breach.
+func (c *wsConn) fail(code int, reason string) error {
+	c.setError(&WebSocketCloseError{Code: code, Reason: reason})
+	_ = c.writeFrame(wsOpClose, closePayload(code, reason))
+	c.closeConn()
+	return c.closeError()
+}
+
+// close starts the closing handshake and closes the connection once the peer
+// has answered, or after a timeout.
+func (c *wsConn) close(code int, reason string) error {
+	c.setError(&WebSocketCloseError{Code: code, Reason: reason})
+	if err := c.writeFrame(wsOpClose, closePayload(code, reason)); err == nil {
+		select {
+		case <-c.closed:
+		case <-time.After(webSocketCloseTimeout):
+		}
+	}
+	c.closeConn()
+	return nil
+}
+
+// abort closes the connection without a closing handshake.
+func (c *wsConn) abort(reason string) {
+	c.setError(&WebSocketCloseError{Code: WebSocketCloseAbnormal, Reason: reason})
+	c.closeConn()
+}
+
+// done is closed once the connection is closed.
+func (c *wsConn) done() <-chan struct{} {
+	return c.closed
+}
+
+func (c *wsConn) closeConn() {
+	c.closeOnce.Do(func() {
+		_ = c.conn.Close()
+		close(c.closed)
+	})
+}
+
+// keepAlive pings the peer every interval, and aborts the connection when
+// nothing was received from it for two intervals.
+func (c *wsConn) keepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, c.lastSeen.Load())) > 2*interval {
+				c.abort("keepalive timeout")
+				return
+			}
+			if err := c.writeFrame(wsOpPing, nil); err != nil {
+				c.abort(err.Error())
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConn) setError(err *WebSocketCloseError) {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// closeError returns why the connection was closed.
+func (c *wsConn) closeError() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.err == nil {
+		return &WebSocketCloseError{Code: WebSocketCloseAbnormal}
+	}
+	return c.err
+}
+
+// readError turns a failed read into the reason the connection was closed.
+func (c *wsConn) readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		c.setError(&WebSocketCloseError{Code: WebSocketCloseAbnormal, Reason: "connection closed without close frame"})
+	} else {
+		c.setError(&WebSocketCloseError{Code: WebSocketCloseAbnormal, Reason: err.Error()})
+	}
+	c.closeConn()
+	return c.closeError()
+}
+
+// closePayload encodes the body of a close frame. Reasons are truncated to
+// fit the 125 bytes limit of control frames.
+func closePayload(code int, reason string) []byte {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return append(payload, reason...)
+}
+
+// webSocketAcceptKey computes the Sec-WebSocket-Accept value for a key.
+func webSocketAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken reports whether a comma-separated header contains token,
+// case-insensitively.
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
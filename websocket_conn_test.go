@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+func TestWSConn_WriteToStalledPeer(t *testing.T) {
+	webSocketWriteTimeout = 50 * time.Millisecond
+	defer func() { webSocketWriteTimeout = 10 * time.Second }()
+
+	// The peer never reads from its end of the pipe
+	local, peer := net.Pipe()
+	defer peer.Close()
+	conn := newWSConn(local, bufio.NewReader(local), false, 0)
+
+	written := make(chan error, 1)
+	go func() { written <- conn.writeMessage([]byte(`{"jsonrpc":"2.0","method":"ping"}`)) }()
+	select {
+	case err := <-written:
+		require.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("write to a stalled peer did not time out")
+	}
+
+	// The connection is dropped, which also releases the keepalive
+	select {
+	case <-conn.done():
+	case <-time.After(time.Second):
+		t.Fatal("connection not closed after the failed write")
+	}
+	var closeErr *WebSocketCloseError
+	require.ErrorAs(t, conn.closeError(), &closeErr)
+	assert.Equal(t, WebSocketCloseAbnormal, closeErr.Code)
+}
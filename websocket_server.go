@@ -0,0 +1,233 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocketServer serves MCP over WebSocket connections negotiating the
+// "mcp" subprotocol. Each connection is registered with the MCPServer as its
+// own ClientSession, which supports notifications, logging, and requests to
+// the client (sampling, elicitation, roots).
+type WebSocketServer struct {
+	server         *MCPServer
+	maxMessageSize int64
+	pingInterval   time.Duration
+	checkOrigin    func(r *http.Request) bool
+	contextFunc    HTTPContextFunc
+
+	mu       sync.Mutex
+	conns    map[string]*wsConn
+	shutdown bool
+	wg       sync.WaitGroup
+}
+
+// WebSocketServerOption configures a WebSocketServer.
+type WebSocketServerOption func(*WebSocketServer)
+
+// WithWebSocketServerMaxMessageSize limits the size of messages received
+// from clients. Defaults to DefaultWebSocketMaxMessageSize; larger messages
+// close the connection with WebSocketCloseMessageTooBig.
+func WithWebSocketServerMaxMessageSize(size int64) WebSocketServerOption {
+	return func(s *WebSocketServer) {
+		s.maxMessageSize = size
+	}
+}
+
+// WithWebSocketServerPingInterval sets the interval between keepalive pings;
+// connections without traffic from the client for two intervals are dropped.
+// Defaults to DefaultWebSocketPingInterval, a negative value disables pings.
+func WithWebSocketServerPingInterval(interval time.Duration) WebSocketServerOption {
+	return func(s *WebSocketServer) {
+		s.pingInterval = interval
+	}
+}
+
+// WithWebSocketOriginCheck sets the function deciding whether a connection
+// from a browser Origin is accepted. By default, requests without an Origin
+// header and same-origin requests are accepted.
+func WithWebSocketOriginCheck(checkOrigin func(r *http.Request) bool) WebSocketServerOption {
+	return func(s *WebSocketServer) {
+		s.checkOrigin = checkOrigin
+	}
+}
+
+// WithWebSocketContextFunc sets a function deriving the context of a
+// connection from its opening handshake request.
+func WithWebSocketContextFunc(fn HTTPContextFunc) WebSocketServerOption {
+	return func(s *WebSocketServer) {
+		s.contextFunc = fn
+	}
+}
+
+// NewWebSocketServer creates an http.Handler serving server over WebSocket.
+func NewWebSocketServer(server *MCPServer, options ...WebSocketServerOption) *WebSocketServer {
+	s := &WebSocketServer{
+		server:         server,
+		maxMessageSize: DefaultWebSocketMaxMessageSize,
+		pingInterval:   DefaultWebSocketPingInterval,
+		checkOrigin:    sameOrigin,
+		conns:          make(map[string]*wsConn),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// ServeHTTP performs the opening handshake and serves the connection until
+// it is closed.
+func (s *WebSocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if nonce, err := base64.StdEncoding.DecodeString(key); err != nil || len(nonce) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return
+	}
+	if !headerHasToken(r.Header, "Sec-WebSocket-Protocol", WebSocketSubprotocol) {
+		http.Error(w, fmt.Sprintf("the %q subprotocol is required", WebSocketSubprotocol), http.StatusBadRequest)
+		return
+	}
+	if !s.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	if s.shutdown {
+		s.mu.Unlock()
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported by this connection", http.StatusInternalServerError)
+		return
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, "failed to upgrade connection", http.StatusInternalServerError)
+		return
+	}
+	// The handshake is complete once hijacked: clear deadlines set by the http.Server
+	_ = netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + webSocketAcceptKey(key) + "\r\n" +
+		"Sec-WebSocket-Protocol: " + WebSocketSubprotocol + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return
+	}
+
+	conn := newWSConn(netConn, rw.Reader, false, s.maxMessageSize)
+	s.serveConn(r, conn)
+}
+
+// serveConn runs the MCP session of an upgraded connection.
+func (s *WebSocketServer) serveConn(r *http.Request, conn *wsConn) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The identity authenticated by a ResourceServer holds for the whole connection
+	if info, ok := AuthInfoFromContext(r.Context()); ok {
+		ctx = WithAuthInfo(ctx, info)
+	}
+	if s.contextFunc != nil {
+		ctx = s.contextFunc(ctx, r)
+	}
+
+	session := newConnSession("ws-", conn)
+	if err := s.server.RegisterSession(ctx, session); err != nil {
+		_ = conn.close(WebSocketCloseInternalError, "failed to register session")
+		return
+	}
+	defer s.server.UnregisterSession(ctx, session.SessionID())
+
+	s.mu.Lock()
+	s.conns[session.SessionID()] = conn
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, session.SessionID())
+		s.mu.Unlock()
+	}()
+
+	ctx = s.server.WithContext(ctx, session)
+	go conn.keepAlive(s.pingInterval)
+	session.serve(ctx, s.server, conn.readMessage)
+}
+
+// Shutdown stops accepting connections, closes the open ones with
+// WebSocketCloseGoingAway and waits for their sessions to end, or for ctx
+// to be done.
+func (s *WebSocketServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.shutdown = true
+	conns := make([]*wsConn, 0, len(s.conns))
+	for _, conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		go conn.close(WebSocketCloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sameOrigin accepts requests without an Origin header, as sent by
+// non-browser clients, and requests whose Origin matches the Host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Ensure interface compliance
+var _ http.Handler = (*WebSocketServer)(nil)
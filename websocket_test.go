@@ -0,0 +1,283 @@
+package mcp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tinywasm/mcp"
+	"github.com/tinywasm/mcp/internal/testutils/assert"
+	"github.com/tinywasm/mcp/internal/testutils/require"
+)
+
+// newWebSocketTestServer serves an MCP server with an echo tool and a tool
+// sampling from the client.
+func newWebSocketTestServer(t *testing.T, options ...mcp.WebSocketServerOption) (*httptest.Server, *mcp.WebSocketServer) {
+	t.Helper()
+
+	mcpServer := mcp.NewMCPServer("ws-server", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("echo", mcp.WithString("message")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(request.GetString("message", "")), nil
+	})
+	mcpServer.AddTool(mcp.NewTool("ask"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, ok := mcp.ClientSessionFromContext(ctx).(mcp.SessionWithSampling)
+		if !ok {
+			return mcp.NewToolResultError("session does not support sampling"), nil
+		}
+		result, err := session.RequestSampling(ctx, mcp.CreateMessageRequest{
+			CreateMessageParams: mcp.CreateMessageParams{
+				Messages:  []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent("question")}},
+				MaxTokens: 10,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(result.Content.(mcp.TextContent).Text), nil
+	})
+
+	wsServer := mcp.NewWebSocketServer(mcpServer, options...)
+	httpServer := httptest.NewServer(wsServer)
+	t.Cleanup(httpServer.Close)
+	return httpServer, wsServer
+}
+
+func webSocketURL(httpServer *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(httpServer.URL, "http")
+}
+
+func initializeClient(t *testing.T, client *mcp.Client) {
+	t.Helper()
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "ws-client", Version: "1.0.0"}
+	_, err := client.Initialize(context.Background(), initRequest)
+	require.NoError(t, err)
+}
+
+func TestWebSocket_RoundTrip(t *testing.T) {
+	httpServer, _ := newWebSocketTestServer(t)
+
+	client, err := mcp.NewWebSocketMCPClient(webSocketURL(httpServer))
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	tools, err := client.ListTools(context.Background(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, tools.Tools, 2)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "echo"
+	request.Params.Arguments = map[string]any{"message": "hello"}
+	result, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "hello", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestWebSocket_ServerToClientRequests(t *testing.T) {
+	httpServer, _ := newWebSocketTestServer(t)
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	client := mcp.NewClient(transport, mcp.WithSamplingHandler(stubSamplingHandler{}))
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "ask"
+	result, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "sampled reply", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestWebSocket_Elicitation(t *testing.T) {
+	mcpServer := mcp.NewMCPServer("ws-server", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("whoami"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session := mcp.ClientSessionFromContext(ctx).(mcp.SessionWithElicitation)
+		result, err := session.RequestElicitation(ctx, mcp.ElicitationRequest{Params: mcp.ElicitationParams{
+			Message:         "Who are you?",
+			RequestedSchema: map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}},
+		}})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(result.Content.(map[string]any)["name"].(string)), nil
+	})
+	httpServer := httptest.NewServer(mcp.NewWebSocketServer(mcpServer))
+	defer httpServer.Close()
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	handler := stubElicitationHandler{result: &mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{
+		Action:  mcp.ElicitationResponseActionAccept,
+		Content: map[string]any{"name": "Ada"},
+	}}}
+	client := mcp.NewClient(transport, mcp.WithElicitationHandler(handler))
+	defer client.Close()
+	require.NoError(t, client.Start(context.Background()))
+	initializeClient(t, client)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "whoami"
+	result, err := client.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "Ada", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestWebSocket_HandshakeRejected(t *testing.T) {
+	httpServer, _ := newWebSocketTestServer(t)
+
+	tests := []struct {
+		name     string
+		header   map[string]string
+		expected int
+	}{
+		{
+			name:     "not an upgrade",
+			header:   map[string]string{},
+			expected: http.StatusUpgradeRequired,
+		},
+		{
+			name: "missing subprotocol",
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Version": "13",
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+			},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "cross origin",
+			header: map[string]string{
+				"Connection":             "Upgrade",
+				"Upgrade":                "websocket",
+				"Sec-WebSocket-Version":  "13",
+				"Sec-WebSocket-Key":      "dGhlIHNhbXBsZSBub25jZQ==",
+				"Sec-WebSocket-Protocol": "mcp",
+				"Origin":                 "https://evil.example",
+			},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, httpServer.URL, nil)
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+}
+
+func TestWebSocket_MessageTooBig(t *testing.T) {
+	httpServer, _ := newWebSocketTestServer(t, mcp.WithWebSocketServerMaxMessageSize(1024))
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	defer transport.Close()
+	lost := make(chan error, 1)
+	transport.SetConnectionLostHandler(func(err error) { lost <- err })
+	require.NoError(t, transport.Start(context.Background()))
+
+	_, err = transport.SendRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Request: mcp.Request{Method: "tools/call"},
+		Params:  map[string]any{"name": "echo", "arguments": map[string]any{"message": strings.Repeat("x", 2048)}},
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, mcp.ErrTransportClosed)
+	var closeErr *mcp.WebSocketCloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, mcp.WebSocketCloseMessageTooBig, closeErr.Code)
+
+	select {
+	case err := <-lost:
+		assert.ErrorAs(t, err, &closeErr)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection lost handler not called")
+	}
+}
+
+func TestWebSocket_Shutdown(t *testing.T) {
+	httpServer, wsServer := newWebSocketTestServer(t)
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	defer transport.Close()
+	lost := make(chan error, 1)
+	transport.SetConnectionLostHandler(func(err error) { lost <- err })
+	require.NoError(t, transport.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, wsServer.Shutdown(ctx))
+
+	select {
+	case err := <-lost:
+		var closeErr *mcp.WebSocketCloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, mcp.WebSocketCloseGoingAway, closeErr.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection lost handler not called")
+	}
+
+	// New connections are refused once shut down
+	other, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.Start(context.Background()), mcp.ErrWebSocketHandshake)
+}
+
+func TestWebSocket_CloseIsClean(t *testing.T) {
+	httpServer, _ := newWebSocketTestServer(t)
+
+	transport, err := mcp.NewWebSocket(webSocketURL(httpServer))
+	require.NoError(t, err)
+	lost := make(chan error, 1)
+	transport.SetConnectionLostHandler(func(err error) { lost <- err })
+	require.NoError(t, transport.Start(context.Background()))
+	require.NoError(t, transport.Close())
+
+	select {
+	case err := <-lost:
+		t.Fatalf("connection lost handler called on Close: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.ErrorIs(t, transport.SendNotification(context.Background(), mcp.JSONRPCNotification{}), mcp.ErrTransportClosed)
+}
+
+// stubSamplingHandler answers every sampling request with a fixed reply.
+type stubSamplingHandler struct{}
+
+func (stubSamplingHandler) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: mcp.NewTextContent("sampled reply")},
+		Model:           "stub-model",
+		StopReason:      "endTurn",
+	}, nil
+}
+
+// stubElicitationHandler answers every elicitation with result.
+type stubElicitationHandler struct {
+	result *mcp.ElicitationResult
+}
+
+func (h stubElicitationHandler) Elicit(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	return h.result, nil
+}